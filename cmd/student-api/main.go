@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"log"
 	"log/slog"
 	"net/http"
 	"os"
@@ -9,9 +10,19 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/gourav224/student-api/internal/avatar"
 	"github.com/gourav224/student-api/internal/config"
-	"github.com/gourav224/student-api/internal/http/handlers/student"
-	"github.com/gourav224/student-api/internal/storage/sqlite"
+	"github.com/gourav224/student-api/internal/http/middleware"
+	"github.com/gourav224/student-api/internal/http/router"
+	"github.com/gourav224/student-api/internal/idempotency"
+	"github.com/gourav224/student-api/internal/logging"
+	"github.com/gourav224/student-api/internal/storage"
+	_ "github.com/gourav224/student-api/internal/storage/mysql"  // Registers the "mysql" storage driver
+	_ "github.com/gourav224/student-api/internal/storage/sqlite" // Registers the "sqlite" storage driver
+	"github.com/gourav224/student-api/internal/tracing"
+	"github.com/gourav224/student-api/internal/types"
+	"github.com/gourav224/student-api/internal/utils/request"
+	"github.com/gourav224/student-api/internal/version"
 )
 
 func main() {
@@ -19,48 +30,107 @@ func main() {
 	// 1️⃣ Load configuration
 	// -------------------------------
 	cfg := config.MustLoad()
+	// Settings in config.Reloadable are read from live and change on SIGHUP
+	live := config.NewLive(cfg)
 
 	// -------------------------------
 	// 2️⃣ Setup structured logger
 	// -------------------------------
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-	}))
+	// The level can change on SIGHUP; see reloadConfig
+	var logLevel slog.LevelVar
+	if err := logging.SetLevel(&logLevel, cfg.LogLevel); err != nil {
+		log.Fatalf("failed to set up logger: %v", err)
+	}
+	logger, err := logging.New(os.Stdout, &logLevel, cfg.LogFormat)
+	if err != nil {
+		log.Fatalf("failed to set up logger: %v", err)
+	}
 	slog.SetDefault(logger)
 
-	slog.Info("initializing server", "address", cfg.HTTPServer.Addr)
+	// Timestamps in every JSON response follow the configured format
+	if err := types.SetTimeFormat(cfg.TimeFormat); err != nil {
+		slog.Error("failed to set time format", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	// Created and updated students must have an email at one of these domains
+	request.SetAllowedEmailDomains(cfg.AllowedEmailDomains)
+	// Names may contain letters, spaces and this punctuation
+	request.SetNamePunctuation(cfg.NamePunctuation)
+
+	// Tracing stays a no-op unless an OTLP endpoint is configured
+	tp, shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing.OTLPEndpoint)
+	if err != nil {
+		slog.Error("failed to set up tracing", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	slog.Info("initializing server", "address", cfg.HTTPServer.Addr, "version", version.Version, "commit", version.Commit)
 
 	// -------------------------------
 	// 3️⃣ Initialize Database
 	// -------------------------------
-	db, err := sqlite.New(cfg)
+	db, err := storage.New(cfg)
 	if err != nil {
 		slog.Error("failed to initialize database", slog.String("error", err.Error()))
 		os.Exit(1)
 	}
-	defer func() {
-		if cerr := db.Db.Close(); cerr != nil {
-			slog.Warn("failed to close database", slog.String("error", cerr.Error()))
-		}
-	}()
-	slog.Info("connected to sqlite database", "path", cfg.StoragePath)
+	// The database is closed explicitly at the end of shutdown, once no
+	// request can use it anymore, rather than by a defer of unclear order
+	slog.Info("connected to database", "driver", cfg.StorageDriver)
+
+	if tracing.Enabled(tp) {
+		db = storage.WithTracing(db, tp)
+		slog.Info("tracing enabled", "otlp_endpoint", cfg.Tracing.OTLPEndpoint)
+	}
 
 	// -------------------------------
 	// 4️⃣ Setup HTTP Router
 	// -------------------------------
-	router := http.NewServeMux()
-	router.HandleFunc("POST /api/students", student.New(db))
-	router.HandleFunc("GET /api/students", student.GetList(db))
-	router.HandleFunc("GET /api/students/{id}", student.GetById(db))
-	router.HandleFunc("PATCH /api/students/{id}", student.UpdateById(db))
-	router.HandleFunc("DELETE /api/students/{id}", student.DeleteById(db))
+	idempotencyKeys := idempotency.New(cfg.IdempotencyTTL.Std())
+
+	avatars, err := avatar.New(cfg.AvatarDir, cfg.APIPrefix+"/avatars/", cfg.AvatarMaxBytes)
+	if err != nil {
+		slog.Error("failed to initialize avatar storage", slog.String("error", err.Error()))
+		closeDatabase(db)
+		os.Exit(1)
+	}
+
+	mux := router.New(live, db, idempotencyKeys, avatars)
 
 	// -------------------------------
 	// 5️⃣ Create HTTP Server
 	// -------------------------------
+	// Global middleware, outermost first (see middleware.Chain for the ordering rationale)
+	var inFlight middleware.InFlight
+	mws := []middleware.Middleware{inFlight.Track, middleware.StripTrailingSlash}
+	if tracing.Enabled(tp) {
+		mws = append(mws, middleware.Tracing(tp))
+	}
+	mws = append(mws, middleware.RealIP(cfg.TrustedProxyPrefixes()), middleware.RequestLogger, middleware.ResponseTime, middleware.Recover)
+	if len(cfg.CORS.AllowedOrigins) > 0 {
+		mws = append(mws, middleware.CORS(middleware.CORSOptions{
+			AllowedOrigins:   cfg.CORS.AllowedOrigins,
+			AllowCredentials: cfg.CORS.AllowCredentials,
+			MaxAge:           cfg.CORS.MaxAge.Std(),
+		}))
+	}
+	if cfg.ReadOnly {
+		slog.Warn("read-only mode is on: write requests will be rejected with 503")
+	}
+	mws = append(mws,
+		middleware.ReadOnly(func() bool { return live.Config().ReadOnly }),
+		middleware.Gzip(middleware.DefaultGzipMinSize),
+		middleware.Timeout(cfg.HTTPServer.RequestTimeout.Std()),
+		middleware.PrettyJSON(cfg.Env == "dev"),
+		middleware.RawResponse(cfg.RawResponses),
+		middleware.JSONAPI("students"),
+		middleware.RouteErrors,
+	)
+	handler := middleware.Chain(mux, mws...)
+
 	server := &http.Server{
 		Addr:    cfg.HTTPServer.Addr,
-		Handler: router,
+		Handler: handler,
 	}
 
 	// -------------------------------
@@ -69,13 +139,29 @@ func main() {
 	done := make(chan os.Signal, 1)
 	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 
+	// SIGHUP reloads the config instead of stopping the server
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	go func() {
+		for range hangup {
+			reloadConfig(live, &logLevel)
+		}
+	}()
+
 	// -------------------------------
 	// 7️⃣ Run Server in Goroutine
 	// -------------------------------
 	go func() {
-		slog.Info("server is listening", "address", cfg.HTTPServer.Addr)
-
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		// HTTPS also enables HTTP/2, which net/http negotiates automatically
+		var err error
+		if cfg.HTTPServer.TLSEnabled() {
+			slog.Info("server is listening", "address", cfg.HTTPServer.Addr, "scheme", "https")
+			err = server.ListenAndServeTLS(cfg.HTTPServer.CertFile, cfg.HTTPServer.KeyFile)
+		} else {
+			slog.Info("server is listening", "address", cfg.HTTPServer.Addr, "scheme", "http")
+			err = server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			slog.Error("server error", slog.String("error", err.Error()))
 		}
 	}()
@@ -89,12 +175,101 @@ func main() {
 	// -------------------------------
 	// 9️⃣ Graceful Shutdown with Timeout
 	// -------------------------------
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	// Shutdown stops accepting new connections first, then waits for
+	// in-flight requests to finish
+	shutdownTimeout := cfg.HTTPServer.ShutdownTimeout.Std()
+	slog.Info("stopped accepting connections, draining in-flight requests",
+		slog.Int64("in_flight", inFlight.Count()),
+		slog.Duration("timeout", shutdownTimeout),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
 	defer cancel()
 
-	if err := server.Shutdown(ctx); err != nil {
-		slog.Error("failed to shutdown gracefully", slog.String("error", err.Error()))
+	// Report progress while Shutdown waits for requests to finish
+	stopProgress := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				slog.Info("still draining", slog.Int64("in_flight", inFlight.Count()))
+			case <-stopProgress:
+				return
+			}
+		}
+	}()
+
+	err = server.Shutdown(ctx)
+	close(stopProgress)
+
+	if err != nil {
+		slog.Error("failed to shutdown gracefully, closing remaining connections",
+			slog.String("error", err.Error()),
+			slog.Int64("in_flight", inFlight.Count()),
+		)
+		server.Close()
 	} else {
 		slog.Info("server stopped gracefully")
 	}
+
+	// Flush the spans still buffered, with a fresh deadline since draining
+	// may have used up the shutdown one
+	tracingCtx, cancelTracing := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancelTracing()
+	if err := shutdownTracing(tracingCtx); err != nil {
+		slog.Warn("failed to flush traces", slog.String("error", err.Error()))
+	}
+
+	// -------------------------------
+	// 🔟 Close Database
+	// -------------------------------
+	// Handlers that outlived the shutdown timeout may still be running even
+	// after their connections were closed; leave the database to the process
+	// exit rather than pull it out from under them
+	if n := inFlight.Count(); n > 0 {
+		slog.Warn("requests still running, leaving database open", slog.Int64("in_flight", n))
+		return
+	}
+	closeDatabase(db)
+}
+
+// reloadConfig reloads live and applies the new log level to logLevel,
+// logging which settings changed. The other reloadable settings are read
+// from live where they are used. An invalid config is logged and the current
+// one kept.
+func reloadConfig(live *config.Live, logLevel *slog.LevelVar) {
+	slog.Info("reloading config", "path", live.Config().Path())
+
+	applied, needRestart, err := live.Reload()
+	if err != nil {
+		slog.Error("failed to reload config, keeping the current one", slog.String("error", err.Error()))
+		return
+	}
+
+	cfg := live.Config()
+	if err := logging.SetLevel(logLevel, cfg.LogLevel); err != nil {
+		slog.Error("failed to apply log level", slog.String("error", err.Error()))
+	}
+	if len(needRestart) > 0 {
+		slog.Warn("changed settings take effect only after a restart", "settings", needRestart)
+	}
+	slog.Info("config reloaded", "applied", applied,
+		"log_level", cfg.LogLevel,
+		"read_only", cfg.ReadOnly,
+		"max_concurrent_requests", cfg.HTTPServer.MaxConcurrentRequests,
+		"rate_limit", cfg.HTTPServer.RateLimit,
+		"rate_limit_burst", cfg.HTTPServer.RateLimitBurst,
+	)
+}
+
+// closeDatabase closes the storage backend, logging the outcome.
+func closeDatabase(db storage.Storage) {
+	slog.Info("closing database")
+	if err := db.Close(); err != nil {
+		slog.Warn("failed to close database", slog.String("error", err.Error()))
+		return
+	}
+	slog.Info("database closed")
 }
@@ -0,0 +1,204 @@
+package avatar
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrUnsupportedType is returned by Save when the upload isn't a supported image.
+var ErrUnsupportedType = errors.New("avatar must be a JPEG, PNG, GIF or WebP image")
+
+// ErrTooLarge is returned by Save when the upload exceeds the size limit.
+var ErrTooLarge = errors.New("avatar is too large")
+
+// extensions maps the accepted image content types to file extensions.
+var extensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// Store keeps student avatars as files in a local directory.
+//
+// Files are named "<student id>-<timestamp><ext>", so a new upload gets a new
+// URL (and bypasses stale caches) while all files of a student remain easy
+// to find and remove.
+type Store struct {
+	dir       string
+	urlPrefix string
+	maxBytes  int64
+
+	mu    sync.Mutex
+	locks map[int64]*studentLock
+}
+
+// studentLock serialises avatar changes of one student; refs counts the
+// callers holding or waiting for it, so it can be dropped once unused.
+type studentLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// New returns a Store writing to dir, creating it if needed. Files are
+// addressed by URLs starting with urlPrefix, e.g. "/api/avatars/", under
+// which Handler must be mounted. Uploads larger than maxBytes are rejected.
+func New(dir, urlPrefix string, maxBytes int64) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create avatar directory: %w", err)
+	}
+	return &Store{dir: dir, urlPrefix: urlPrefix, maxBytes: maxBytes, locks: make(map[int64]*studentLock)}, nil
+}
+
+// Lock waits until no one else changes the avatar of student id and returns
+// the function ending the change. Hold it from Save until the new URL is
+// stored and Remove has run, so that concurrent uploads can't remove each
+// other's file.
+func (s *Store) Lock(id int64) (unlock func()) {
+	s.mu.Lock()
+	l, ok := s.locks[id]
+	if !ok {
+		l = &studentLock{}
+		s.locks[id] = l
+	}
+	l.refs++
+	s.mu.Unlock()
+
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+		s.mu.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(s.locks, id)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// MaxBytes returns the largest accepted upload size.
+func (s *Store) MaxBytes() int64 {
+	return s.maxBytes
+}
+
+// Save stores the image read from r as a new avatar of student id and
+// returns its URL. The type is sniffed from the content rather than trusted
+// from the client. Earlier avatars of the student are kept; see Remove.
+func (s *Store) Save(id int64, r io.Reader) (string, error) {
+	br := bufio.NewReaderSize(r, 512)
+	head, err := br.Peek(512)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+
+	ext, ok := extensions[http.DetectContentType(head)]
+	if !ok {
+		return "", ErrUnsupportedType
+	}
+
+	// Write to a temp file first so a failed upload never leaves a partial avatar
+	tmp, err := os.CreateTemp(s.dir, ".upload-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := io.Copy(tmp, io.LimitReader(br, s.maxBytes+1))
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", err
+	}
+	if n > s.maxBytes {
+		return "", ErrTooLarge
+	}
+
+	name := fmt.Sprintf("%d-%d%s", id, time.Now().UnixNano(), ext)
+	if err := os.Rename(tmp.Name(), filepath.Join(s.dir, name)); err != nil {
+		return "", err
+	}
+
+	return s.urlPrefix + name, nil
+}
+
+// Remove deletes every avatar file of student id except the one at keep,
+// a URL returned by Save. An empty keep removes them all. Files saved by a
+// concurrent upload are removed too, unless both hold Lock.
+func (s *Store) Remove(id int64, keep string) error {
+	matches, err := filepath.Glob(filepath.Join(s.dir, fmt.Sprintf("%d-*", id)))
+	if err != nil {
+		return err
+	}
+
+	keepName := path.Base(keep)
+	var errs []error
+	for _, m := range matches {
+		if keep != "" && filepath.Base(m) == keepName {
+			continue
+		}
+		if err := os.Remove(m); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Discard deletes the single avatar file at url, a URL returned by Save.
+func (s *Store) Discard(url string) error {
+	name := strings.TrimPrefix(url, s.urlPrefix)
+	if name == url || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("not an avatar URL: %q", url)
+	}
+	err := os.Remove(filepath.Join(s.dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// RemoveAll deletes every avatar file.
+func (s *Store) RemoveAll() error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dir, e.Name())); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// URLPrefix returns the path prefix of avatar URLs.
+func (s *Store) URLPrefix() string {
+	return s.urlPrefix
+}
+
+// Handler serves avatar files for requests whose full path starts with
+// URLPrefix. Directory listings and in-progress uploads are not served.
+func (s *Store) Handler() http.Handler {
+	files := http.StripPrefix(s.urlPrefix, http.FileServer(http.Dir(s.dir)))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/") || strings.HasPrefix(path.Base(r.URL.Path), ".") {
+			http.NotFound(w, r)
+			return
+		}
+		files.ServeHTTP(w, r)
+	})
+}
@@ -1,25 +1,148 @@
 package config
 
 import (
+	"crypto/tls"
+	"errors"
 	"flag"
+	"fmt"
 	"log"
+	"net"
+	"net/netip"
+	"net/url"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
 
+	"github.com/gourav224/student-api/internal/logging"
+	"github.com/gourav224/student-api/internal/types"
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
+// SupportedFormats lists the config file extensions Load understands.
+// The format is inferred from the extension of the config path.
+var SupportedFormats = []string{".yaml", ".yml", ".json", ".toml"}
+
+// Environments lists the accepted values for Config.Env.
+var Environments = []string{"dev", "staging", "prod"}
+
+// DefaultEnv is used when no environment is configured and StrictEnv is off.
+const DefaultEnv = "dev"
+
+// Duration is a time.Duration that reads as a Go duration string such as
+// "30s" or "24h" from every supported file format and from env vars.
+type Duration time.Duration
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Std returns d as a time.Duration.
+func (d Duration) Std() time.Duration {
+	return time.Duration(d)
+}
+
+// String formats d like time.Duration does.
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
 type HTTPServer struct {
-	Addr string `yaml:"address" env:"HTTP_SERVER_ADDR" env-default:":8080"`
+	Addr                  string   `yaml:"address" json:"address" toml:"address" env:"HTTP_SERVER_ADDR" env-default:":8080"`
+	RequestTimeout        Duration `yaml:"request_timeout" json:"request_timeout" toml:"request_timeout" env:"HTTP_REQUEST_TIMEOUT" env-default:"30s"`
+	ShutdownTimeout       Duration `yaml:"shutdown_timeout" json:"shutdown_timeout" toml:"shutdown_timeout" env:"HTTP_SHUTDOWN_TIMEOUT" env-default:"5s"`
+	MaxConcurrentRequests int      `yaml:"max_concurrent_requests" json:"max_concurrent_requests" toml:"max_concurrent_requests" env:"HTTP_MAX_CONCURRENT_REQUESTS" env-default:"0"`
+	RateLimit             float64  `yaml:"rate_limit" json:"rate_limit" toml:"rate_limit" env:"HTTP_RATE_LIMIT" env-default:"0"`
+	RateLimitBurst        int      `yaml:"rate_limit_burst" json:"rate_limit_burst" toml:"rate_limit_burst" env:"HTTP_RATE_LIMIT_BURST" env-default:"10"`
+	CertFile              string   `yaml:"cert_file" json:"cert_file" toml:"cert_file" env:"HTTP_TLS_CERT_FILE"`
+	KeyFile               string   `yaml:"key_file" json:"key_file" toml:"key_file" env:"HTTP_TLS_KEY_FILE"`
+}
+
+// TLSEnabled reports whether the server should serve HTTPS, i.e. whether a
+// certificate and key are configured.
+func (h HTTPServer) TLSEnabled() bool {
+	return h.CertFile != "" && h.KeyFile != ""
+}
+
+// CORS configures cross-origin access. CORS headers are only sent when
+// AllowedOrigins is non-empty.
+type CORS struct {
+	AllowedOrigins   []string `yaml:"allowed_origins" json:"allowed_origins" toml:"allowed_origins" env:"CORS_ALLOWED_ORIGINS" env-separator:","`
+	AllowCredentials bool     `yaml:"allow_credentials" json:"allow_credentials" toml:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS" env-default:"false"`
+	MaxAge           Duration `yaml:"max_age" json:"max_age" toml:"max_age" env:"CORS_MAX_AGE" env-default:"10m"`
+}
+
+// Tracing configures OpenTelemetry tracing. Tracing is disabled unless
+// OTLPEndpoint is set.
+type Tracing struct {
+	OTLPEndpoint string `yaml:"otlp_endpoint" json:"otlp_endpoint" toml:"otlp_endpoint" env:"TRACING_OTLP_ENDPOINT"`
 }
 
 type Config struct {
-	Env         string     `yaml:"env" env:"ENV" env-required:"true"`
-	StoragePath string     `yaml:"storage_path" env:"STORAGE_PATH" env-required:"true"`
-	HTTPServer  HTTPServer `yaml:"http_server"`
+	Env                    string     `yaml:"env" json:"env" toml:"env" env:"ENV"`
+	StrictEnv              bool       `yaml:"strict_env" json:"strict_env" toml:"strict_env" env:"STRICT_ENV"`
+	StorageDriver          string     `yaml:"storage_driver" json:"storage_driver" toml:"storage_driver" env:"STORAGE_DRIVER" env-default:"sqlite"`
+	StoragePath            string     `yaml:"storage_path" json:"storage_path" toml:"storage_path" env:"STORAGE_PATH"`
+	StorageDSN             string     `yaml:"storage_dsn" json:"storage_dsn" toml:"storage_dsn" env:"STORAGE_DSN"`
+	StorageTable           string     `yaml:"storage_table" json:"storage_table" toml:"storage_table" env:"STORAGE_TABLE" env-default:"students"`
+	AutoMigrate            bool       `yaml:"auto_migrate" json:"auto_migrate" toml:"auto_migrate" env:"AUTO_MIGRATE" env-default:"true"`
+	StorageConnectAttempts int        `yaml:"storage_connect_attempts" json:"storage_connect_attempts" toml:"storage_connect_attempts" env:"STORAGE_CONNECT_ATTEMPTS" env-default:"5"`
+	StorageConnectInterval Duration   `yaml:"storage_connect_interval" json:"storage_connect_interval" toml:"storage_connect_interval" env:"STORAGE_CONNECT_INTERVAL" env-default:"1s"`
+	StorageBusyRetries     int        `yaml:"storage_busy_retries" json:"storage_busy_retries" toml:"storage_busy_retries" env:"STORAGE_BUSY_RETRIES" env-default:"3"`
+	StorageBusyBackoff     Duration   `yaml:"storage_busy_backoff" json:"storage_busy_backoff" toml:"storage_busy_backoff" env:"STORAGE_BUSY_BACKOFF" env-default:"10ms"`
+	SQLiteFTS              bool       `yaml:"sqlite_fts" json:"sqlite_fts" toml:"sqlite_fts" env:"SQLITE_FTS" env-default:"false"`
+	StorageTimeout         Duration   `yaml:"storage_timeout" json:"storage_timeout" toml:"storage_timeout" env:"STORAGE_TIMEOUT" env-default:"0"`
+	SlowQueryThreshold     Duration   `yaml:"slow_query_threshold" json:"slow_query_threshold" toml:"slow_query_threshold" env:"SLOW_QUERY_THRESHOLD" env-default:"0"`
+	StudentCacheSize       int        `yaml:"student_cache_size" json:"student_cache_size" toml:"student_cache_size" env:"STUDENT_CACHE_SIZE" env-default:"0"`
+	StudentCacheTTL        Duration   `yaml:"student_cache_ttl" json:"student_cache_ttl" toml:"student_cache_ttl" env:"STUDENT_CACHE_TTL" env-default:"30s"`
+	HTTPServer             HTTPServer `yaml:"http_server" json:"http_server" toml:"http_server"`
+	CORS                   CORS       `yaml:"cors" json:"cors" toml:"cors"`
+	Tracing                Tracing    `yaml:"tracing" json:"tracing" toml:"tracing"`
+	AvatarDir              string     `yaml:"avatar_dir" json:"avatar_dir" toml:"avatar_dir" env:"AVATAR_DIR" env-default:"storage/avatars"`
+	AvatarMaxBytes         int64      `yaml:"avatar_max_bytes" json:"avatar_max_bytes" toml:"avatar_max_bytes" env:"AVATAR_MAX_BYTES" env-default:"2097152"`
+	MaxPageSize            int        `yaml:"max_page_size" json:"max_page_size" toml:"max_page_size" env:"MAX_PAGE_SIZE" env-default:"100"`
+	MaxStudents            int        `yaml:"max_students" json:"max_students" toml:"max_students" env:"MAX_STUDENTS" env-default:"0"`
+	APIPrefix              string     `yaml:"api_prefix" json:"api_prefix" toml:"api_prefix" env:"API_PREFIX" env-default:"/api"`
+	RawResponses           bool       `yaml:"raw_responses" json:"raw_responses" toml:"raw_responses" env:"RAW_RESPONSES" env-default:"false"`
+	TimeFormat             string     `yaml:"time_format" json:"time_format" toml:"time_format" env:"TIME_FORMAT" env-default:"rfc3339"`
+	AllowedEmailDomains    []string   `yaml:"allowed_email_domains" json:"allowed_email_domains" toml:"allowed_email_domains" env:"ALLOWED_EMAIL_DOMAINS" env-separator:","`
+	NamePunctuation        string     `yaml:"name_punctuation" json:"name_punctuation" toml:"name_punctuation" env:"NAME_PUNCTUATION" env-default:"-'"`
+	TrustedProxies         []string   `yaml:"trusted_proxies" json:"trusted_proxies" toml:"trusted_proxies" env:"TRUSTED_PROXIES" env-separator:","`
+	AdminToken             string     `yaml:"admin_token" json:"admin_token" toml:"admin_token" env:"ADMIN_TOKEN"`
+	ReadOnly               bool       `yaml:"read_only" json:"read_only" toml:"read_only" env:"READ_ONLY" env-default:"false"`
+	LogLevel               string     `yaml:"log_level" json:"log_level" toml:"log_level" env:"LOG_LEVEL" env-default:"info"`
+	LogFormat              string     `yaml:"log_format" json:"log_format" toml:"log_format" env:"LOG_FORMAT" env-default:"json"`
+	IdempotencyTTL         Duration   `yaml:"idempotency_ttl" json:"idempotency_ttl" toml:"idempotency_ttl" env:"IDEMPOTENCY_TTL" env-default:"24h"`
+
+	envDefaulted bool   // set by Load when Env fell back to DefaultEnv
+	path         string // the file Load read, "" for environment variables only
+}
+
+// configPathFlag returns the value of the --config flag in args, the
+// command-line arguments without the program name, or "" when it is absent.
+// It parses args with its own flag.FlagSet rather than flag.CommandLine, so
+// it can be called any number of times, e.g. once per test. Like flag.Parse,
+// it exits the process on -h or an unknown flag.
+func configPathFlag(args []string) string {
+	var configPath string
+	flags := flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ExitOnError)
+	flags.StringVar(&configPath, "config", "", "path to config file")
+	flags.Parse(args) // ExitOnError: never returns an error
+	return configPath
 }
 
-// MustLoad reads configuration from file or environment variables
-// and panics if something goes wrong.
+// MustLoad resolves the config path from the CONFIG_PATH env var or the
+// --config flag, loads it with Load, and exits the process on failure.
+// When neither is set, configuration is read from environment variables only.
 func MustLoad() *Config {
 	var configPath string
 
@@ -28,25 +151,312 @@ func MustLoad() *Config {
 		configPath = path
 	} else {
 		// 2️⃣ Priority 2: Command-line flag
-		flag.StringVar(&configPath, "config", "", "path to config file")
-		flag.Parse()
+		configPath = configPathFlag(os.Args[1:])
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-		if configPath == "" {
-			log.Fatal("Config path is not set (use --config or CONFIG_PATH env var)")
+	if cfg.EnvDefaulted() {
+		log.Printf("⚠️ ENV is not set, defaulting to %q; set STRICT_ENV=true to make it required", DefaultEnv)
+	}
+
+	if configPath == "" {
+		log.Printf("✅ Config loaded from environment variables")
+	} else {
+		log.Printf("✅ Config loaded from %s", configPath)
+	}
+	return cfg
+}
+
+// Load reads the config file at path, applies environment variable
+// overrides, and validates the result.
+// The file format (YAML, JSON or TOML) is inferred from its extension.
+// An empty path populates the config purely from environment variables.
+func Load(path string) (*Config, error) {
+	if path == "" {
+		var cfg Config
+		if err := cleanenv.ReadEnv(&cfg); err != nil {
+			return nil, fmt.Errorf("cannot read config from environment: %w", err)
 		}
+		cfg.defaultEnv()
+		if err := cfg.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid config from environment:\n%w", err)
+		}
+		return &cfg, nil
+	}
+
+	// 1️⃣ Check existence
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, fmt.Errorf("config file does not exist: %s", path)
 	}
 
-	// 3️⃣ Check existence
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		log.Fatalf("Config file does not exist: %s", configPath)
+	// 2️⃣ Check the format can be inferred from the extension
+	if ext := strings.ToLower(filepath.Ext(path)); !slices.Contains(SupportedFormats, ext) {
+		return nil, fmt.Errorf("unsupported config file format %q (supported: %s)", ext, strings.Join(SupportedFormats, ", "))
 	}
 
-	// 4️⃣ Parse YAML into struct
+	// 3️⃣ Parse file into struct
 	var cfg Config
-	if err := cleanenv.ReadConfig(configPath, &cfg); err != nil {
-		log.Fatalf("cannot read config file: %v", err)
+	if err := cleanenv.ReadConfig(path, &cfg); err != nil {
+		return nil, fmt.Errorf("cannot read config file: %w", err)
+	}
+	cfg.defaultEnv()
+
+	// 4️⃣ Validate values
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config %s:\n%w", path, err)
+	}
+
+	cfg.path = path
+	return &cfg, nil
+}
+
+// defaultEnv falls back to DefaultEnv when no environment is configured,
+// unless StrictEnv demands an explicit one.
+func (c *Config) defaultEnv() {
+	if c.Env == "" && !c.StrictEnv {
+		c.Env = DefaultEnv
+		c.envDefaulted = true
+	}
+}
+
+// EnvDefaulted reports whether Env was not configured and fell back to DefaultEnv.
+func (c *Config) EnvDefaulted() bool {
+	return c.envDefaulted
+}
+
+// Path returns the config file c was loaded from, or "" when it was read
+// from environment variables only.
+func (c *Config) Path() string {
+	return c.path
+}
+
+// Validate checks the loaded values for consistency.
+// It reports every problem it finds, joined into a single error,
+// instead of stopping at the first one.
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.Env == "" && c.StrictEnv {
+		errs = append(errs, fmt.Errorf("env is required when strict_env is set (one of: %s)", strings.Join(Environments, ", ")))
+	} else if !slices.Contains(Environments, c.Env) {
+		errs = append(errs, fmt.Errorf("env %q must be one of: %s", c.Env, strings.Join(Environments, ", ")))
+	}
+
+	switch c.StorageDriver {
+	case "sqlite":
+		if c.StoragePath == "" {
+			errs = append(errs, errors.New("storage_path is required for the sqlite driver"))
+		} else if err := checkWritable(c.StoragePath); err != nil {
+			errs = append(errs, fmt.Errorf("storage_path %q is not writable: %w", c.StoragePath, err))
+		}
+	case "mysql":
+		if c.StorageDSN == "" {
+			errs = append(errs, errors.New("storage_dsn is required for the mysql driver"))
+		}
+	}
+
+	if c.StorageConnectAttempts < 1 {
+		errs = append(errs, fmt.Errorf("storage_connect_attempts %d must be at least 1", c.StorageConnectAttempts))
+	}
+	if c.StorageConnectInterval < 0 {
+		errs = append(errs, fmt.Errorf("storage_connect_interval %s must not be negative", c.StorageConnectInterval))
+	}
+	if c.StorageBusyRetries < 0 {
+		errs = append(errs, fmt.Errorf("storage_busy_retries %d must not be negative", c.StorageBusyRetries))
+	}
+	if c.StorageBusyBackoff < 0 {
+		errs = append(errs, fmt.Errorf("storage_busy_backoff %s must not be negative", c.StorageBusyBackoff))
+	}
+	if c.StorageTimeout < 0 {
+		errs = append(errs, fmt.Errorf("storage_timeout %s must not be negative", c.StorageTimeout))
+	}
+	if c.SlowQueryThreshold < 0 {
+		errs = append(errs, fmt.Errorf("slow_query_threshold %s must not be negative", c.SlowQueryThreshold))
+	}
+	if c.StudentCacheSize < 0 {
+		errs = append(errs, fmt.Errorf("student_cache_size %d must not be negative", c.StudentCacheSize))
+	}
+	if c.StudentCacheSize > 0 && c.StudentCacheTTL <= 0 {
+		errs = append(errs, fmt.Errorf("student_cache_ttl %s must be positive when student_cache_size is set", c.StudentCacheTTL))
+	}
+
+	if _, err := logging.ParseLevel(c.LogLevel); err != nil {
+		errs = append(errs, fmt.Errorf("log_level: %w", err))
+	}
+	if !slices.Contains(logging.Formats, strings.ToLower(c.LogFormat)) {
+		errs = append(errs, fmt.Errorf("log_format %q must be one of: %s", c.LogFormat, strings.Join(logging.Formats, ", ")))
+	}
+
+	if c.IdempotencyTTL <= 0 {
+		errs = append(errs, fmt.Errorf("idempotency_ttl %s must be positive", c.IdempotencyTTL))
+	}
+
+	if c.HTTPServer.RequestTimeout < 0 {
+		errs = append(errs, fmt.Errorf("http_server.request_timeout %s must not be negative", c.HTTPServer.RequestTimeout))
+	}
+
+	if c.AvatarDir == "" {
+		errs = append(errs, errors.New("avatar_dir is required"))
+	}
+	if c.AvatarMaxBytes < 1 {
+		errs = append(errs, fmt.Errorf("avatar_max_bytes %d must be positive", c.AvatarMaxBytes))
+	}
+
+	if c.MaxPageSize < 1 {
+		errs = append(errs, fmt.Errorf("max_page_size %d must be at least 1", c.MaxPageSize))
+	}
+	if c.MaxStudents < 0 {
+		errs = append(errs, fmt.Errorf("max_students %d must not be negative", c.MaxStudents))
+	}
+
+	if !slices.Contains(types.TimeFormats, strings.ToLower(c.TimeFormat)) {
+		errs = append(errs, fmt.Errorf("time_format %q must be one of: %s", c.TimeFormat, strings.Join(types.TimeFormats, ", ")))
+	}
+
+	for _, domain := range c.AllowedEmailDomains {
+		if domain == "" || strings.ContainsAny(domain, "@ ") {
+			errs = append(errs, fmt.Errorf("allowed_email_domains entry %q must be a bare domain such as \"school.edu\"", domain))
+		}
+	}
+	if strings.ContainsFunc(c.NamePunctuation, func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsSpace(r) || unicode.IsControl(r)
+	}) {
+		errs = append(errs, fmt.Errorf("name_punctuation %q must only contain punctuation and symbols", c.NamePunctuation))
+	}
+
+	if c.APIPrefix != "" && (!strings.HasPrefix(c.APIPrefix, "/") || strings.HasSuffix(c.APIPrefix, "/")) {
+		errs = append(errs, fmt.Errorf("api_prefix %q must start with '/' and not end with '/'", c.APIPrefix))
+	}
+
+	if c.HTTPServer.MaxConcurrentRequests < 0 {
+		errs = append(errs, fmt.Errorf("http_server.max_concurrent_requests %d must not be negative", c.HTTPServer.MaxConcurrentRequests))
+	}
+	if c.HTTPServer.RateLimit < 0 {
+		errs = append(errs, fmt.Errorf("http_server.rate_limit %g must not be negative", c.HTTPServer.RateLimit))
+	}
+	if c.HTTPServer.RateLimit > 0 && c.HTTPServer.RateLimitBurst < 1 {
+		errs = append(errs, fmt.Errorf("http_server.rate_limit_burst %d must be at least 1 when http_server.rate_limit is set", c.HTTPServer.RateLimitBurst))
+	}
+
+	if c.HTTPServer.ShutdownTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("http_server.shutdown_timeout %s must be positive", c.HTTPServer.ShutdownTimeout))
+	}
+
+	if c.Tracing.OTLPEndpoint != "" {
+		if u, err := url.Parse(c.Tracing.OTLPEndpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("tracing.otlp_endpoint %q must be an http or https URL", c.Tracing.OTLPEndpoint))
+		}
+	}
+
+	if c.CORS.AllowCredentials && slices.Contains(c.CORS.AllowedOrigins, "*") {
+		errs = append(errs, errors.New(`cors.allow_credentials cannot be combined with the "*" origin; list the allowed origins explicitly`))
+	}
+	if c.CORS.MaxAge < 0 {
+		errs = append(errs, fmt.Errorf("cors.max_age %s must not be negative", c.CORS.MaxAge))
+	}
+
+	switch {
+	case (c.HTTPServer.CertFile == "") != (c.HTTPServer.KeyFile == ""):
+		errs = append(errs, errors.New("http_server.cert_file and http_server.key_file must be set together"))
+	case c.HTTPServer.TLSEnabled():
+		if _, err := tls.LoadX509KeyPair(c.HTTPServer.CertFile, c.HTTPServer.KeyFile); err != nil {
+			errs = append(errs, fmt.Errorf("http_server.cert_file / key_file: %w", err))
+		}
+	}
+
+	if err := checkAddr(c.HTTPServer.Addr); err != nil {
+		errs = append(errs, fmt.Errorf("http_server.address %q is invalid: %w", c.HTTPServer.Addr, err))
+	}
+
+	for _, p := range c.TrustedProxies {
+		if _, err := parsePrefix(p); err != nil {
+			errs = append(errs, fmt.Errorf("trusted_proxies: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// TrustedProxyPrefixes returns TrustedProxies as network prefixes.
+// Entries that don't parse are skipped; Validate reports them.
+func (c *Config) TrustedProxyPrefixes() []netip.Prefix {
+	prefixes := make([]netip.Prefix, 0, len(c.TrustedProxies))
+	for _, p := range c.TrustedProxies {
+		if prefix, err := parsePrefix(p); err == nil {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return prefixes
+}
+
+// parsePrefix parses a CIDR such as "10.0.0.0/8", or a single address, which
+// is treated as a prefix covering just that address.
+func parsePrefix(s string) (netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid CIDR %q", s)
+		}
+		return prefix.Masked(), nil
+	}
+
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid IP address %q", s)
+	}
+	return netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()), nil
+}
+
+// checkWritable reports whether the file at path can be opened for writing,
+// or created if it doesn't exist yet, along with any missing parent
+// directories.
+func checkWritable(path string) error {
+	if _, err := os.Stat(path); err == nil {
+		f, err := os.OpenFile(path, os.O_WRONLY, 0)
+		if err != nil {
+			return err
+		}
+		return f.Close()
+	}
+
+	// File doesn't exist yet: the nearest existing ancestor directory must
+	// accept new files, since the missing ones are created on startup
+	dir := filepath.Dir(path)
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	f, err := os.CreateTemp(dir, ".write-check-*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// checkAddr validates a "host:port" listen address.
+func checkAddr(addr string) error {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+
+	n, err := strconv.Atoi(port)
+	if err != nil || n < 0 || n > 65535 {
+		return fmt.Errorf("port %q must be a number between 0 and 65535", port)
 	}
 
-	log.Printf("✅ Config loaded from %s", configPath)
-	return &cfg
+	return nil
 }
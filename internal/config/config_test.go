@@ -0,0 +1,442 @@
+package config
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeConfig writes content to a file called name in a temporary directory
+// and returns its path. A "$DIR" in content is replaced by that directory.
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(strings.ReplaceAll(content, "$DIR", dir)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// validConfig loads a minimal valid config, with every other value defaulted.
+func validConfig(t *testing.T) *Config {
+	t.Helper()
+	cfg, err := Load(writeConfig(t, "config.yaml", "env: dev\nstorage_path: $DIR/students.db\n"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	return cfg
+}
+
+// unwritablePath returns a path whose parent is a regular file, so the
+// database can't be created there, even by root.
+func unwritablePath(t *testing.T) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(file, "students.db")
+}
+
+// writeCertificate writes a self-signed certificate and its key to a
+// temporary directory and returns their paths.
+func writeCertificate(t *testing.T) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr string
+	}{
+		{"defaults", func(*Config) {}, ""},
+		{"staging", func(c *Config) { c.Env = "staging" }, ""},
+		{"prod", func(c *Config) { c.Env = "prod" }, ""},
+		{"unknown env", func(c *Config) { c.Env = "qa" }, `env "qa" must be one of: dev, staging, prod`},
+		{"missing env", func(c *Config) { c.Env = "" }, `env "" must be one of`},
+		{"missing storage path", func(c *Config) { c.StoragePath = "" }, "storage_path is required"},
+		{"unwritable storage path", func(c *Config) { c.StoragePath = unwritablePath(t) }, "is not writable"},
+		{"address without port", func(c *Config) { c.HTTPServer.Addr = "localhost" }, `http_server.address "localhost" is invalid`},
+		{"address with bad port", func(c *Config) { c.HTTPServer.Addr = ":99999" }, "must be a number between 0 and 65535"},
+		{"address with host", func(c *Config) { c.HTTPServer.Addr = "127.0.0.1:8080" }, ""},
+		{"cors credentials", func(c *Config) {
+			c.CORS.AllowedOrigins = []string{"https://app.example.com"}
+			c.CORS.AllowCredentials = true
+		}, ""},
+		{"cors credentials with wildcard", func(c *Config) {
+			c.CORS.AllowedOrigins = []string{"*"}
+			c.CORS.AllowCredentials = true
+		}, "cors.allow_credentials cannot be combined"},
+		{"negative cors max age", func(c *Config) { c.CORS.MaxAge = -1 }, "cors.max_age"},
+		{"no busy retries", func(c *Config) { c.StorageBusyRetries = 0 }, ""},
+		{"negative busy retries", func(c *Config) { c.StorageBusyRetries = -1 }, "storage_busy_retries -1 must not be negative"},
+		{"negative busy backoff", func(c *Config) { c.StorageBusyBackoff = -1 }, "storage_busy_backoff"},
+		{"negative storage timeout", func(c *Config) { c.StorageTimeout = -1 }, "storage_timeout"},
+		{"negative slow query threshold", func(c *Config) { c.SlowQueryThreshold = -1 }, "slow_query_threshold"},
+		{"negative student cache size", func(c *Config) { c.StudentCacheSize = -1 }, "student_cache_size"},
+		{"student cache without ttl", func(c *Config) { c.StudentCacheSize = 100; c.StudentCacheTTL = 0 }, "student_cache_ttl"},
+		{"negative max students", func(c *Config) { c.MaxStudents = -1 }, "max_students -1 must not be negative"},
+		{"unix time format", func(c *Config) { c.TimeFormat = "unix" }, ""},
+		{"negative max concurrent requests", func(c *Config) { c.HTTPServer.MaxConcurrentRequests = -1 }, "http_server.max_concurrent_requests -1 must not be negative"},
+		{"rate limit", func(c *Config) { c.HTTPServer.RateLimit = 2.5 }, ""},
+		{"negative rate limit", func(c *Config) { c.HTTPServer.RateLimit = -1 }, "http_server.rate_limit -1 must not be negative"},
+		{"rate limit without burst", func(c *Config) { c.HTTPServer.RateLimit = 5; c.HTTPServer.RateLimitBurst = 0 }, "http_server.rate_limit_burst 0 must be at least 1"},
+		{"unknown time format", func(c *Config) { c.TimeFormat = "iso" }, `time_format "iso" must be one of: rfc3339, unix`},
+		{"trusted proxies", func(c *Config) { c.TrustedProxies = []string{"10.0.0.0/8", "127.0.0.1", "::1"} }, ""},
+		{"invalid trusted proxy CIDR", func(c *Config) { c.TrustedProxies = []string{"10.0.0.0/33"} }, `trusted_proxies: invalid CIDR "10.0.0.0/33"`},
+		{"invalid trusted proxy IP", func(c *Config) { c.TrustedProxies = []string{"proxy.local"} }, `trusted_proxies: invalid IP address "proxy.local"`},
+		{"allowed email domains", func(c *Config) { c.AllowedEmailDomains = []string{"school.edu", "college.edu"} }, ""},
+		{"email address as domain", func(c *Config) { c.AllowedEmailDomains = []string{"admin@school.edu"} }, `allowed_email_domains entry "admin@school.edu" must be a bare domain`},
+		{"name punctuation", func(c *Config) { c.NamePunctuation = "-'." }, ""},
+		{"letters as name punctuation", func(c *Config) { c.NamePunctuation = "-x" }, "name_punctuation"},
+		{"otlp endpoint", func(c *Config) { c.Tracing.OTLPEndpoint = "http://otel-collector:4318" }, ""},
+		{"invalid otlp endpoint", func(c *Config) { c.Tracing.OTLPEndpoint = "otel-collector:4318" }, `tracing.otlp_endpoint "otel-collector:4318" must be an http or https URL`},
+		{"tls", func(c *Config) { c.HTTPServer.CertFile, c.HTTPServer.KeyFile = writeCertificate(t) }, ""},
+		{"cert without key", func(c *Config) { c.HTTPServer.CertFile, _ = writeCertificate(t) }, "cert_file and http_server.key_file must be set together"},
+		{"missing cert files", func(c *Config) {
+			c.HTTPServer.CertFile, c.HTTPServer.KeyFile = "/nonexistent/cert.pem", "/nonexistent/key.pem"
+		}, "http_server.cert_file / key_file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig(t)
+			tt.modify(cfg)
+			err := cfg.Validate()
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("Validate: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("Validate error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateReportsAllProblems(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.Env = "qa"
+	cfg.StoragePath = unwritablePath(t)
+	cfg.HTTPServer.Addr = "localhost"
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate succeeded, want an error")
+	}
+	for _, want := range []string{`env "qa"`, "storage_path", "http_server.address"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q doesn't report %s", err, want)
+		}
+	}
+	if lines := strings.Count(err.Error(), "\n") + 1; lines != 3 {
+		t.Errorf("error has %d lines, want one per problem:\n%v", lines, err)
+	}
+}
+
+func TestTrustedProxyPrefixes(t *testing.T) {
+	c := Config{TrustedProxies: []string{"10.1.2.3/8", " 127.0.0.1 ", "::ffff:192.0.2.1", "bogus"}}
+
+	var got []string
+	for _, p := range c.TrustedProxyPrefixes() {
+		got = append(got, p.String())
+	}
+	want := []string{"10.0.0.0/8", "127.0.0.1/32", "192.0.2.1/32"}
+	if !slices.Equal(got, want) {
+		t.Errorf("TrustedProxyPrefixes = %v, want %v", got, want)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		env     map[string]string
+		wantErr string
+		check   func(*testing.T, *Config)
+	}{
+		{
+			name:    "minimal",
+			file:    "config.yaml",
+			content: "env: dev\nstorage_path: $DIR/students.db\n",
+			check: func(t *testing.T, c *Config) {
+				if c.StorageDriver != "sqlite" || c.HTTPServer.Addr != ":8080" || c.MaxPageSize != 100 || c.ReadOnly || !c.AutoMigrate {
+					t.Errorf("defaults not applied: driver %q, address %q, max_page_size %d, read_only %v, auto_migrate %v", c.StorageDriver, c.HTTPServer.Addr, c.MaxPageSize, c.ReadOnly, c.AutoMigrate)
+				}
+			},
+		},
+		{
+			name:    "env overrides file",
+			file:    "config.yaml",
+			content: "env: dev\nstorage_path: $DIR/students.db\nhttp_server:\n  address: \":8080\"\n",
+			env:     map[string]string{"HTTP_SERVER_ADDR": ":9090", "MAX_PAGE_SIZE": "50"},
+			check: func(t *testing.T, c *Config) {
+				if c.HTTPServer.Addr != ":9090" || c.MaxPageSize != 50 {
+					t.Errorf("env overrides not applied: address %q, max_page_size %d", c.HTTPServer.Addr, c.MaxPageSize)
+				}
+			},
+		},
+		{
+			name:    "auto migrate switched off by env",
+			file:    "config.yaml",
+			content: "env: prod\nstorage_path: $DIR/students.db\n",
+			env:     map[string]string{"AUTO_MIGRATE": "false"},
+			check: func(t *testing.T, c *Config) {
+				if c.AutoMigrate {
+					t.Error("AUTO_MIGRATE=false not applied")
+				}
+			},
+		},
+		{
+			name:    "read only from file",
+			file:    "config.yaml",
+			content: "env: prod\nstorage_path: $DIR/students.db\nread_only: true\n",
+			check: func(t *testing.T, c *Config) {
+				if !c.ReadOnly {
+					t.Error("read_only: true not applied")
+				}
+			},
+		},
+		{
+			name:    "read only switched off by env",
+			file:    "config.yaml",
+			content: "env: prod\nstorage_path: $DIR/students.db\nread_only: true\n",
+			env:     map[string]string{"READ_ONLY": "false"},
+			check: func(t *testing.T, c *Config) {
+				if c.ReadOnly {
+					t.Error("READ_ONLY=false did not override the file")
+				}
+			},
+		},
+		{
+			name:    "malformed yaml",
+			file:    "config.yaml",
+			content: "env: dev\nstorage_path: [unterminated\n",
+			wantErr: "cannot read config file",
+		},
+		{
+			name:    "wrong value type",
+			file:    "config.yaml",
+			content: "env: dev\nstorage_path: $DIR/students.db\nmax_page_size: lots\n",
+			wantErr: "cannot read config file",
+		},
+		{
+			name:    "missing storage path",
+			file:    "config.yaml",
+			content: "env: dev\n",
+			wantErr: "storage_path is required for the sqlite driver",
+		},
+		{
+			name:    "missing dsn",
+			file:    "config.yaml",
+			content: "env: dev\nstorage_driver: mysql\n",
+			wantErr: "storage_dsn is required for the mysql driver",
+		},
+		{
+			name:    "unsupported format",
+			file:    "config.ini",
+			content: "env = dev\n",
+			wantErr: `unsupported config file format ".ini"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			cfg, err := Load(writeConfig(t, tt.file, tt.content))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	_, err := Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err == nil || !strings.Contains(err.Error(), "config file does not exist") {
+		t.Errorf("Load error = %v, want a missing file error", err)
+	}
+}
+
+func TestLoadFromEnvironment(t *testing.T) {
+	t.Setenv("ENV", "staging")
+	t.Setenv("STORAGE_PATH", filepath.Join(t.TempDir(), "students.db"))
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Env != "staging" {
+		t.Errorf("Load(\"\") = env %q, want staging from the environment", cfg.Env)
+	}
+}
+
+// unsetenv removes the environment variable key until t ends.
+func unsetenv(t *testing.T, key string) {
+	t.Helper()
+	t.Setenv(key, "") // registers restoring the original value
+	os.Unsetenv(key)
+}
+
+func TestLoadDefaultsEnv(t *testing.T) {
+	unsetenv(t, "ENV")
+	unsetenv(t, "STRICT_ENV")
+	t.Setenv("STORAGE_PATH", filepath.Join(t.TempDir(), "students.db"))
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Env != DefaultEnv || !cfg.EnvDefaulted() {
+		t.Errorf("Load(\"\") = env %q (defaulted %v), want %q by default", cfg.Env, cfg.EnvDefaulted(), DefaultEnv)
+	}
+
+	t.Setenv("STRICT_ENV", "true")
+	if _, err := Load(""); err == nil || !strings.Contains(err.Error(), "env is required when strict_env is set") {
+		t.Errorf("Load error = %v, want a missing env error under STRICT_ENV", err)
+	}
+
+	t.Setenv("ENV", "prod")
+	cfg, err = Load("")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Env != "prod" || cfg.EnvDefaulted() {
+		t.Errorf("Load(\"\") = env %q (defaulted %v), want prod as configured", cfg.Env, cfg.EnvDefaulted())
+	}
+}
+
+func TestLoadFormats(t *testing.T) {
+	files := map[string]string{
+		"config.yaml": `
+env: staging
+storage_path: $DIR/students.db
+max_page_size: 25
+http_server:
+  address: ":9000"
+  request_timeout: 15s
+`,
+		"config.yml": `
+env: staging
+storage_path: $DIR/students.db
+max_page_size: 25
+http_server:
+  address: ":9000"
+  request_timeout: 15s
+`,
+		"config.json": `{
+  "env": "staging",
+  "storage_path": "$DIR/students.db",
+  "max_page_size": 25,
+  "http_server": {"address": ":9000", "request_timeout": "15s"}
+}`,
+		"config.toml": `
+env = "staging"
+storage_path = "$DIR/students.db"
+max_page_size = 25
+
+[http_server]
+address = ":9000"
+request_timeout = "15s"
+`,
+	}
+
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := Load(writeConfig(t, name, content))
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if cfg.Env != "staging" || cfg.MaxPageSize != 25 || cfg.HTTPServer.Addr != ":9000" || cfg.HTTPServer.RequestTimeout.String() != "15s" {
+				t.Errorf("Load = env %q, max_page_size %d, address %q, request_timeout %s",
+					cfg.Env, cfg.MaxPageSize, cfg.HTTPServer.Addr, cfg.HTTPServer.RequestTimeout)
+			}
+			if !strings.HasSuffix(cfg.StoragePath, "students.db") {
+				t.Errorf("storage_path = %q", cfg.StoragePath)
+			}
+		})
+	}
+}
+
+func TestConfigPathFlag(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{nil, ""},
+		{[]string{"--config", "a.yaml"}, "a.yaml"},
+		{[]string{"-config=b.yaml"}, "b.yaml"},
+	}
+
+	// Parsing repeatedly must not redefine a global flag
+	for range 2 {
+		for _, tt := range tests {
+			if got := configPathFlag(tt.args); got != tt.want {
+				t.Errorf("configPathFlag(%q) = %q, want %q", tt.args, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestMustLoadTwice(t *testing.T) {
+	path := writeConfig(t, "config.yaml", "env: dev\nstorage_path: $DIR/students.db\nhttp_server:\n  request_timeout: 7s\n")
+	t.Setenv("CONFIG_PATH", "")
+	args := os.Args
+	t.Cleanup(func() { os.Args = args })
+	os.Args = []string{"student-api", "--config", path}
+
+	for i := range 2 {
+		if cfg := MustLoad(); cfg.HTTPServer.RequestTimeout.Std() != 7*time.Second {
+			t.Errorf("MustLoad %d: request_timeout = %s, want 7s from %s", i+1, cfg.HTTPServer.RequestTimeout, path)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// Reloadable lists the settings, by their config file keys, that Live.Reload
+// applies to the running server. Every other setting only takes effect after
+// a restart.
+var Reloadable = []string{
+	"log_level",
+	"read_only",
+	"http_server.max_concurrent_requests",
+	"http_server.rate_limit",
+	"http_server.rate_limit_burst",
+}
+
+// Live holds the active configuration of a running server. Components whose
+// settings are in Reloadable read them from Config on every use rather than
+// once at startup, so Reload takes effect right away.
+type Live struct {
+	active atomic.Pointer[Config]
+}
+
+// NewLive returns a Live whose active configuration is cfg.
+func NewLive(cfg *Config) *Live {
+	l := &Live{}
+	l.active.Store(cfg)
+	return l
+}
+
+// Config returns the active configuration. It must not be modified.
+func (l *Live) Config() *Config {
+	return l.active.Load()
+}
+
+// Reload loads the configuration again from where the active one came from
+// (see Config.Path) and atomically swaps in a copy of the active one with the
+// Reloadable settings updated. It returns the keys of the reloadable settings
+// that changed and of the other settings that changed but keep their current
+// value until a restart. An invalid configuration is reported as an error
+// and leaves the active one in place.
+//
+// Concurrent calls must be serialized by the caller.
+func (l *Live) Reload() (applied, needRestart []string, err error) {
+	current := l.Config()
+	loaded, err := Load(current.Path())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	next := *current
+	next.LogLevel = loaded.LogLevel
+	next.ReadOnly = loaded.ReadOnly
+	next.HTTPServer.MaxConcurrentRequests = loaded.HTTPServer.MaxConcurrentRequests
+	next.HTTPServer.RateLimit = loaded.HTTPServer.RateLimit
+	next.HTTPServer.RateLimitBurst = loaded.HTTPServer.RateLimitBurst
+
+	for _, key := range changedKeys(reflect.ValueOf(*current), reflect.ValueOf(*loaded), "") {
+		if slices.Contains(Reloadable, key) {
+			applied = append(applied, key)
+		} else {
+			needRestart = append(needRestart, key)
+		}
+	}
+
+	l.active.Store(&next)
+	return applied, needRestart, nil
+}
+
+// changedKeys returns the config keys, from the yaml tags and joined with
+// dots, of the fields that differ between the structs a and b.
+func changedKeys(a, b reflect.Value, prefix string) []string {
+	var keys []string
+	for i := range a.NumField() {
+		field := a.Type().Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if !field.IsExported() || name == "" {
+			continue
+		}
+
+		key := prefix + name
+		if field.Type.Kind() == reflect.Struct {
+			keys = append(keys, changedKeys(a.Field(i), b.Field(i), key+".")...)
+		} else if !reflect.DeepEqual(a.Field(i).Interface(), b.Field(i).Interface()) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
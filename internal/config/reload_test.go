@@ -0,0 +1,62 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestLiveReload(t *testing.T) {
+	path := writeConfig(t, "config.yaml", "env: dev\nstorage_path: $DIR/students.db\n")
+	storagePath := filepath.Join(filepath.Dir(path), "students.db")
+	rewrite := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte("env: dev\nstorage_path: "+storagePath+"\n"+content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	live := NewLive(cfg)
+
+	rewrite("log_level: debug\nread_only: true\nhttp_server:\n  address: \":9090\"\n  max_concurrent_requests: 5\n  rate_limit: 20\n")
+	applied, needRestart, err := live.Reload()
+	if err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if want := []string{"http_server.max_concurrent_requests", "http_server.rate_limit", "read_only", "log_level"}; !sameKeys(applied, want) {
+		t.Errorf("applied = %q, want %q", applied, want)
+	}
+	if want := []string{"http_server.address"}; !sameKeys(needRestart, want) {
+		t.Errorf("needRestart = %q, want %q", needRestart, want)
+	}
+
+	got := live.Config()
+	if got.LogLevel != "debug" || !got.ReadOnly || got.HTTPServer.MaxConcurrentRequests != 5 || got.HTTPServer.RateLimit != 20 {
+		t.Errorf("reloadable settings not applied: log_level %q, read_only %v, max_concurrent_requests %d, rate_limit %g",
+			got.LogLevel, got.ReadOnly, got.HTTPServer.MaxConcurrentRequests, got.HTTPServer.RateLimit)
+	}
+	if got.HTTPServer.Addr != cfg.HTTPServer.Addr {
+		t.Errorf("address = %q, want %q kept until a restart", got.HTTPServer.Addr, cfg.HTTPServer.Addr)
+	}
+	if cfg.ReadOnly {
+		t.Error("Reload modified the previous config instead of swapping in a copy")
+	}
+
+	// An invalid file leaves the active config in place
+	rewrite("log_level: loud\n")
+	if _, _, err := live.Reload(); err == nil {
+		t.Fatal("Reload of an invalid config succeeded")
+	}
+	if live.Config() != got {
+		t.Error("active config replaced after a failed reload")
+	}
+}
+
+func sameKeys(got, want []string) bool {
+	return len(got) == len(want) && !slices.ContainsFunc(want, func(k string) bool { return !slices.Contains(got, k) })
+}
@@ -0,0 +1,51 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/gourav224/student-api/internal/storage"
+	"github.com/gourav224/student-api/internal/utils/response"
+)
+
+//
+// ──────────────────────────────── DATABASE STATS ────────────────────────────────
+//
+
+// dbStats is the JSON form of sql.DBStats.
+type dbStats struct {
+	MaxOpenConnections int     `json:"max_open_connections"`
+	OpenConnections    int     `json:"open_connections"`
+	InUse              int     `json:"in_use"`
+	Idle               int     `json:"idle"`
+	WaitCount          int64   `json:"wait_count"`
+	WaitDurationMs     float64 `json:"wait_duration_ms"`
+	MaxIdleClosed      int64   `json:"max_idle_closed"`
+	MaxIdleTimeClosed  int64   `json:"max_idle_time_closed"`
+	MaxLifetimeClosed  int64   `json:"max_lifetime_closed"`
+}
+
+// DBStats returns an HTTP handler that reports the state of the database
+// connection pool, e.g. GET /api/admin/db/stats, to diagnose pool exhaustion.
+// A growing wait_count or wait_duration_ms means requests are queueing for a
+// connection. The counters are cumulative since startup.
+func DBStats(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		stats := store.Stats()
+
+		response.WriteJson(w, http.StatusOK, map[string]any{
+			"status":  "success",
+			"message": "database stats fetched successfully",
+			"data": dbStats{
+				MaxOpenConnections: stats.MaxOpenConnections,
+				OpenConnections:    stats.OpenConnections,
+				InUse:              stats.InUse,
+				Idle:               stats.Idle,
+				WaitCount:          stats.WaitCount,
+				WaitDurationMs:     float64(stats.WaitDuration.Microseconds()) / 1000,
+				MaxIdleClosed:      stats.MaxIdleClosed,
+				MaxIdleTimeClosed:  stats.MaxIdleTimeClosed,
+				MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+			},
+		})
+	}
+}
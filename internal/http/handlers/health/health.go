@@ -0,0 +1,56 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gourav224/student-api/internal/logging"
+	"github.com/gourav224/student-api/internal/storage"
+	"github.com/gourav224/student-api/internal/utils/response"
+)
+
+// readyTimeout bounds how long a readiness probe waits on the database.
+const readyTimeout = 2 * time.Second
+
+//
+// ──────────────────────────────── LIVENESS ────────────────────────────────
+//
+
+// Live returns an HTTP handler reporting that the process is up and serving.
+// It does not touch any dependency, so it only fails if the server is wedged.
+func Live() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		response.WriteJson(w, http.StatusOK, map[string]any{
+			"status":  "success",
+			"message": "alive",
+		})
+	}
+}
+
+//
+// ──────────────────────────────── READINESS ────────────────────────────────
+//
+
+// Ready returns an HTTP handler reporting whether the service can handle
+// traffic, i.e. whether storage answers a ping within readyTimeout.
+// Responds 503 Service Unavailable when it doesn't.
+func Ready(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+		defer cancel()
+
+		if err := store.Ping(ctx); err != nil {
+			logging.FromContext(r.Context()).Warn("readiness check failed", slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusServiceUnavailable, response.GeneralError(errors.New("storage is unavailable")))
+			return
+		}
+
+		response.WriteJson(w, http.StatusOK, map[string]any{
+			"status":  "success",
+			"message": "ready",
+		})
+	}
+}
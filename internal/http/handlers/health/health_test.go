@@ -0,0 +1,50 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gourav224/student-api/internal/storage"
+)
+
+// pingStore is a Storage whose Ping returns err; nothing else is called.
+type pingStore struct {
+	storage.Storage
+	err error
+}
+
+func (s pingStore) Ping(ctx context.Context) error {
+	return s.err
+}
+
+func TestReady(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"storage up", nil, http.StatusOK},
+		{"storage down", errors.New("connection refused"), http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			Ready(pingStore{err: tt.err})(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+		})
+	}
+}
+
+func TestLive(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Live()(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200", rec.Code)
+	}
+}
@@ -0,0 +1,130 @@
+package student
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/gourav224/student-api/internal/avatar"
+	"github.com/gourav224/student-api/internal/logging"
+	"github.com/gourav224/student-api/internal/storage"
+	"github.com/gourav224/student-api/internal/utils/request"
+	"github.com/gourav224/student-api/internal/utils/response"
+)
+
+// avatarFormField is the multipart field carrying the uploaded image.
+const avatarFormField = "avatar"
+
+//
+// ──────────────────────────────── UPLOAD AVATAR ────────────────────────────────
+//
+
+// UploadAvatar returns an HTTP handler that sets a student's profile picture,
+// e.g. POST /api/students/1/avatar.
+//
+// The body must be multipart/form-data with the image in the "avatar" field.
+// The image is streamed to disk, its type is sniffed from the content (JPEG,
+// PNG, GIF or WebP), and uploads over the configured size limit get 413, as
+// do bodies that are larger than that by more than request.MaxBodySize of
+// other form fields. On success the student's avatar_url points at the new
+// file, previous avatar files are removed, and the updated student is
+// returned. Uploads for the same student are handled one at a time.
+func UploadAvatar(store storage.Storage, avatars *avatar.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+
+		intId, err := parseId(r.PathValue("id"))
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		logging.FromContext(r.Context()).Info("Uploading student avatar", slog.Int64("id", intId))
+
+		exists, err := store.Exists(r.Context(), intId)
+		if err == nil && !exists {
+			err = storage.ErrNotFound
+		}
+		if err != nil {
+			writeStorageError(w, err, intId)
+			return
+		}
+
+		// Leave room for other form fields, but don't read an endless body
+		bodyLimit := avatars.MaxBytes() + request.MaxBodySize
+		r.Body = http.MaxBytesReader(w, r.Body, bodyLimit)
+		mr, err := r.MultipartReader()
+		if err != nil {
+			response.WriteJson(w, http.StatusUnsupportedMediaType, response.GeneralError(errors.New("content type must be multipart/form-data")))
+			return
+		}
+
+		// Find the image part, skipping any other form fields
+		var part io.Reader
+		for part == nil {
+			p, err := mr.NextPart()
+			if tooLarge(err) {
+				response.WriteJson(w, http.StatusRequestEntityTooLarge, response.GeneralError(fmt.Errorf("request body exceeds %d bytes", bodyLimit)))
+				return
+			}
+			if errors.Is(err, io.EOF) {
+				response.WriteJson(w, http.StatusBadRequest, response.GeneralError(fmt.Errorf("missing %q form field", avatarFormField)))
+				return
+			}
+			if err != nil {
+				response.WriteJson(w, http.StatusBadRequest, response.GeneralError(fmt.Errorf("invalid multipart body: %w", err)))
+				return
+			}
+			if p.FormName() == avatarFormField {
+				part = p
+			}
+		}
+
+		unlock := avatars.Lock(intId)
+		defer unlock()
+
+		url, err := avatars.Save(intId, part)
+		switch {
+		case tooLarge(err):
+			response.WriteJson(w, http.StatusRequestEntityTooLarge, response.GeneralError(fmt.Errorf("request body exceeds %d bytes", bodyLimit)))
+			return
+		case errors.Is(err, avatar.ErrUnsupportedType):
+			response.WriteJson(w, http.StatusUnsupportedMediaType, response.GeneralError(err))
+			return
+		case errors.Is(err, avatar.ErrTooLarge):
+			response.WriteJson(w, http.StatusRequestEntityTooLarge, response.GeneralError(fmt.Errorf("%w: at most %d bytes", err, avatars.MaxBytes())))
+			return
+		case err != nil:
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		student, err := store.Update(r.Context(), intId, 0, map[string]any{"avatar_url": url})
+		if err != nil {
+			if derr := avatars.Discard(url); derr != nil {
+				logging.FromContext(r.Context()).Warn("failed to discard avatar", slog.String("url", url), slog.String("error", derr.Error()))
+			}
+			writeStorageError(w, err, intId)
+			return
+		}
+
+		// The new avatar is saved, so older files of this student are obsolete
+		removeAvatars(r.Context(), avatars, intId, url)
+
+		response.WriteJson(w, http.StatusOK, map[string]any{
+			"status":  "success",
+			"message": "avatar uploaded successfully",
+			"data":    student,
+		})
+	}
+}
+
+// removeAvatars deletes the avatar files of student id other than keep.
+// Failures only leave stray files behind, so they are logged, not returned.
+func removeAvatars(ctx context.Context, avatars *avatar.Store, id int64, keep string) {
+	if err := avatars.Remove(id, keep); err != nil {
+		logging.FromContext(ctx).Warn("failed to remove avatar files", slog.Int64("id", id), slog.String("error", err.Error()))
+	}
+}
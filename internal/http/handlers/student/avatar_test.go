@@ -0,0 +1,82 @@
+package student
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gourav224/student-api/internal/avatar"
+	"github.com/gourav224/student-api/internal/storage"
+	"github.com/gourav224/student-api/internal/utils/request"
+)
+
+// pngHeader is enough of a PNG file for its type to be detected.
+const pngHeader = "\x89PNG\r\n\x1a\n"
+
+// uploadAvatar sends fields as a multipart form, in order, to the
+// UploadAvatar handler for student id.
+func uploadAvatar(store storage.Storage, avatars *avatar.Store, id int64, fields ...[2]string) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for _, f := range fields {
+		mw.WriteField(f[0], f[1])
+	}
+	mw.Close()
+
+	target := fmt.Sprintf("/students/%d/avatar", id)
+	return serve(UploadAvatar(store, avatars), "POST /students/{id}/avatar", http.MethodPost, target, buf.String(),
+		map[string]string{"Content-Type": mw.FormDataContentType()})
+}
+
+func TestUploadAvatarBodyLimit(t *testing.T) {
+	store := newTestStore(t)
+	id := mustCreate(t, store, "Ada", "ada@example.com", 20)
+	avatars := newTestAvatars(t)
+
+	// The image is fine, but the form field before it pushes the body over the limit
+	padding := strings.Repeat("x", int(avatars.MaxBytes()+request.MaxBodySize))
+	rec := uploadAvatar(store, avatars, id, [2]string{"note", padding}, [2]string{avatarFormField, pngHeader})
+	expectError(t, rec, http.StatusRequestEntityTooLarge, "PAYLOAD_TOO_LARGE")
+
+	rec = uploadAvatar(store, avatars, id, [2]string{"note", "hello"}, [2]string{avatarFormField, pngHeader})
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200 with a small extra field; body %s", rec.Code, rec.Body)
+	}
+}
+
+func TestConcurrentAvatarUploads(t *testing.T) {
+	store := newTestStore(t)
+	id := mustCreate(t, store, "Ada", "ada@example.com", 20)
+	avatars := newTestAvatars(t)
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			if rec := uploadAvatar(store, avatars, id, [2]string{avatarFormField, pngHeader}); rec.Code != http.StatusOK {
+				t.Errorf("status = %d, want 200; body %s", rec.Code, rec.Body)
+			}
+		})
+	}
+	wg.Wait()
+
+	// Whichever upload came last, the stored URL must point at a file that
+	// the others did not remove
+	st, err := store.GetStudentById(context.Background(), id)
+	if err != nil {
+		t.Fatalf("GetStudentById: %v", err)
+	}
+	if st.AvatarURL == nil {
+		t.Fatal("avatar_url is not set")
+	}
+	rec := httptest.NewRecorder()
+	avatars.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, *st.AvatarURL, nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("GET %s: status = %d, want 200", *st.AvatarURL, rec.Code)
+	}
+}
@@ -0,0 +1,313 @@
+package student
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gourav224/student-api/internal/logging"
+	"github.com/gourav224/student-api/internal/storage"
+	"github.com/gourav224/student-api/internal/types"
+	"github.com/gourav224/student-api/internal/utils/request"
+	"github.com/gourav224/student-api/internal/utils/response"
+)
+
+// maxBulkRows caps how many students a single bulk request may contain.
+const maxBulkRows = 1000
+
+// errTooManyRows rejects bulk requests with more than maxBulkRows rows.
+var errTooManyRows = fmt.Errorf("too many students: at most %d per request", maxBulkRows)
+
+// errDryRun is returned from the bulk transaction to force a rollback in dry-run mode.
+var errDryRun = errors.New("dry run")
+
+// bulkRowResult reports the outcome of a single row of a bulk request.
+// Row numbers are 1-based positions in the submitted batch.
+type bulkRowResult struct {
+	Row   int    `json:"row"`
+	Id    int64  `json:"id,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// bulkReport summarizes a bulk request.
+type bulkReport struct {
+	DryRun    bool            `json:"dry_run"`
+	Succeeded int             `json:"succeeded"`
+	Failed    int             `json:"failed"`
+	Results   []bulkRowResult `json:"results"`
+}
+
+//
+// ──────────────────────────────── BULK CREATE STUDENTS ────────────────────────────────
+//
+
+// BulkCreate returns an HTTP handler that creates many students atomically.
+//
+// It expects a JSON array of student objects. Every row is validated and
+// inserted inside one transaction: if any row fails, nothing is persisted and
+// the response (422) lists which rows failed and why.
+//
+// With ?dry_run=true the same checks run, including uniqueness against the
+// database and within the batch, but the transaction is always rolled back.
+// The response (200) reports which rows would succeed or fail.
+func BulkCreate(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+
+		dryRun, err := parseDryRun(r)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+
+		// Rows are validated one by one in createBulk, so only decode here
+		var students []types.Student
+		if err := request.Decode(r, &students); err != nil {
+			writeRequestError(w, err)
+			return
+		}
+
+		rows := make([]bulkRow, len(students))
+		for i, st := range students {
+			rows[i] = bulkRow{Row: i + 1, Student: st}
+		}
+
+		createBulk(w, r, store, rows, dryRun)
+	}
+}
+
+// bulkRow is one student of a bulk request along with its position in the
+// input, which callers may number differently (e.g. CSV line numbers).
+// A non-nil ParseErr marks a row that couldn't be decoded; it is reported
+// as failed without being inserted.
+type bulkRow struct {
+	Row      int
+	Student  types.Student
+	ParseErr error
+}
+
+// parseDryRun reads the optional "dry_run" query parameter.
+func parseDryRun(r *http.Request) (bool, error) {
+	v := r.URL.Query().Get("dry_run")
+	if v == "" {
+		return false, nil
+	}
+
+	dryRun, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, errors.New("dry_run must be a boolean")
+	}
+	return dryRun, nil
+}
+
+// createBulk validates and inserts rows in a single transaction and writes the
+// resulting report. It is the shared path behind every bulk import format.
+func createBulk(w http.ResponseWriter, r *http.Request, store storage.Storage, rows []bulkRow, dryRun bool) {
+	if len(rows) == 0 {
+		response.WriteJson(w, http.StatusBadRequest, response.GeneralError(errors.New("no students to create")))
+		return
+	}
+	if len(rows) > maxBulkRows {
+		response.WriteJson(w, http.StatusRequestEntityTooLarge, response.GeneralError(errTooManyRows))
+		return
+	}
+
+	report := bulkReport{DryRun: dryRun, Results: make([]bulkRowResult, len(rows))}
+
+	err := store.WithTx(r.Context(), func(tx storage.Storage) error {
+		for i, row := range rows {
+			result := bulkRowResult{Row: row.Row}
+
+			if row.ParseErr != nil {
+				result.Error = row.ParseErr.Error()
+			} else if err := request.Validate(row.Student); err != nil {
+				result.Error = response.ValidationError(err.(validator.ValidationErrors)).Error
+			} else if id, err := tx.CreateStudent(r.Context(), row.Student.Name, row.Student.Email, row.Student.Age); err != nil {
+				result.Error = err.Error()
+			} else {
+				result.Id = id
+			}
+
+			if result.Error != "" {
+				report.Failed++
+			} else {
+				report.Succeeded++
+			}
+			report.Results[i] = result
+		}
+
+		// Keep nothing unless every row succeeded for real
+		if dryRun || report.Failed > 0 {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		writeStorageError(w, err, 0)
+		return
+	}
+
+	// Ids assigned inside a rolled-back transaction are meaningless
+	if dryRun || report.Failed > 0 {
+		for i := range report.Results {
+			report.Results[i].Id = 0
+		}
+	}
+
+	switch {
+	case dryRun:
+		logging.FromContext(r.Context()).Info("Bulk create dry run", slog.Int("valid", report.Succeeded), slog.Int("invalid", report.Failed))
+		response.WriteJson(w, http.StatusOK, map[string]any{
+			"status":  "success",
+			"message": "dry run completed, no students were created",
+			"data":    report,
+		})
+	case report.Failed > 0:
+		response.WriteJson(w, http.StatusUnprocessableEntity, map[string]any{
+			"status":  "error",
+			"code":    response.CodeValidationFailed,
+			"message": "some students are invalid, no students were created",
+			"data":    report,
+		})
+	default:
+		logging.FromContext(r.Context()).Info("Students created in bulk", slog.Int("count", report.Succeeded))
+		response.WriteJson(w, http.StatusCreated, map[string]any{
+			"status":  "success",
+			"message": "students created successfully",
+			"data":    report,
+		})
+	}
+}
+
+//
+// ──────────────────────────────── CSV IMPORT ────────────────────────────────
+//
+
+// csvColumns are the columns an import file must provide, in any order.
+var csvColumns = []string{"name", "email", "age"}
+
+// ImportCSV returns an HTTP handler that creates students from a CSV upload.
+//
+// The body must be sent as text/csv and start with a header row naming the
+// "name", "email" and "age" columns. Rows go through the same transactional
+// path as BulkCreate (including ?dry_run=true), and each row result is
+// numbered by its line in the file so users can fix their data. Files over
+// request.MaxBodySize or with more than maxBulkRows rows are rejected with
+// 413 as soon as the limit is reached, without reading the rest.
+func ImportCSV(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+
+		mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil || mediaType != "text/csv" {
+			response.WriteJson(w, http.StatusUnsupportedMediaType, response.GeneralError(errors.New("content type must be text/csv")))
+			return
+		}
+
+		dryRun, err := parseDryRun(r)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+
+		reader := csv.NewReader(http.MaxBytesReader(w, r.Body, request.MaxBodySize))
+		reader.TrimLeadingSpace = true
+
+		header, err := reader.Read()
+		if tooLarge(err) {
+			response.WriteJson(w, http.StatusRequestEntityTooLarge, response.GeneralError(request.ErrBodyTooLarge))
+			return
+		}
+		if errors.Is(err, io.EOF) {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(errors.New("empty CSV file")))
+			return
+		}
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(fmt.Errorf("invalid CSV header: %w", err)))
+			return
+		}
+
+		index, err := csvHeaderIndex(header)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+
+		var rows []bulkRow
+		for {
+			record, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if tooLarge(err) {
+				response.WriteJson(w, http.StatusRequestEntityTooLarge, response.GeneralError(request.ErrBodyTooLarge))
+				return
+			}
+
+			line, _ := reader.FieldPos(0)
+			row := bulkRow{Row: line}
+
+			var parseErr *csv.ParseError
+			switch {
+			case errors.As(err, &parseErr) && errors.Is(parseErr.Err, csv.ErrFieldCount):
+				row.ParseErr = fmt.Errorf("expected %d fields, got %d", len(header), len(record))
+			case err != nil:
+				// Malformed quoting etc. leaves the reader in an unknown state
+				response.WriteJson(w, http.StatusBadRequest, response.GeneralError(fmt.Errorf("invalid CSV: %w", err)))
+				return
+			default:
+				row.Student.Name = record[index["name"]]
+				row.Student.Email = record[index["email"]]
+				if age, err := strconv.Atoi(record[index["age"]]); err != nil {
+					row.ParseErr = fmt.Errorf("field 'age' must be an integer, got %q", record[index["age"]])
+				} else {
+					row.Student.Age = age
+				}
+			}
+
+			rows = append(rows, row)
+			if len(rows) > maxBulkRows {
+				response.WriteJson(w, http.StatusRequestEntityTooLarge, response.GeneralError(errTooManyRows))
+				return
+			}
+		}
+
+		createBulk(w, r, store, rows, dryRun)
+	}
+}
+
+// tooLarge reports whether err comes from reading past a body size limit.
+func tooLarge(err error) bool {
+	var maxErr *http.MaxBytesError
+	return errors.As(err, &maxErr)
+}
+
+// csvHeaderIndex maps each required column to its position in the header row.
+func csvHeaderIndex(header []string) (map[string]int, error) {
+	index := make(map[string]int, len(header))
+	for i, col := range header {
+		col = strings.ToLower(strings.TrimSpace(col))
+		if !slices.Contains(csvColumns, col) {
+			return nil, fmt.Errorf("unknown CSV column %q (expected: %s)", col, strings.Join(csvColumns, ","))
+		}
+		if _, dup := index[col]; dup {
+			return nil, fmt.Errorf("duplicate CSV column %q", col)
+		}
+		index[col] = i
+	}
+
+	for _, col := range csvColumns {
+		if _, ok := index[col]; !ok {
+			return nil, fmt.Errorf("missing CSV column %q (expected: %s)", col, strings.Join(csvColumns, ","))
+		}
+	}
+	return index, nil
+}
@@ -0,0 +1,243 @@
+package student
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gourav224/student-api/internal/storage"
+	"github.com/gourav224/student-api/internal/utils/request"
+)
+
+// importCSV uploads body as text/csv to the ImportCSV handler.
+func importCSV(store storage.Storage, query, body string) *httptest.ResponseRecorder {
+	return serve(ImportCSV(store), "POST /students/import", http.MethodPost, "/students/import"+query, body,
+		map[string]string{"Content-Type": "text/csv"})
+}
+
+// bulkResults returns the per-row results of a bulk report response.
+func bulkResults(t *testing.T, body map[string]any) []map[string]any {
+	t.Helper()
+	data, ok := body["data"].(map[string]any)
+	if !ok {
+		t.Fatalf("body = %v, want a bulk report", body)
+	}
+	var results []map[string]any
+	for _, r := range data["results"].([]any) {
+		results = append(results, r.(map[string]any))
+	}
+	return results
+}
+
+func TestImportCSV(t *testing.T) {
+	store := newTestStore(t)
+
+	rec := importCSV(store, "", "age,name,email\n20,Ada,ada@example.com\n21,Bob,bob@example.com\n")
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201; body %s", rec.Code, rec.Body)
+	}
+	results := bulkResults(t, decode(t, rec))
+	if len(results) != 2 || results[0]["row"] != 2.0 || results[1]["row"] != 3.0 {
+		t.Errorf("results = %v, want rows 2 and 3", results)
+	}
+	if n := countStudents(t, store); n != 2 {
+		t.Errorf("stored %d students, want 2", n)
+	}
+}
+
+func TestImportCSVHeader(t *testing.T) {
+	tests := []struct {
+		name, body, want string
+	}{
+		{"empty", "", "empty CSV file"},
+		{"missing column", "name,email\nAda,ada@example.com\n", `missing CSV column "age"`},
+		{"duplicate column", "name,email,age,email\n", `duplicate CSV column "email"`},
+		{"unknown column", "name,email,age,grade\n", `unknown CSV column "grade"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := expectError(t, importCSV(newTestStore(t), "", tt.body), http.StatusBadRequest, "BAD_REQUEST")
+			if msg, _ := body["error"].(string); !strings.Contains(msg, tt.want) {
+				t.Errorf("error = %q, want it to mention %s", msg, tt.want)
+			}
+		})
+	}
+}
+
+func TestImportCSVRowErrors(t *testing.T) {
+	store := newTestStore(t)
+
+	csv := "name,email,age\n" +
+		"Ada,ada@example.com,20\n" +
+		"Bob,bob@example.com\n" +
+		"Cy,cy@example.com,twenty\n"
+	body := expectError(t, importCSV(store, "", csv), http.StatusUnprocessableEntity, "VALIDATION_FAILED")
+
+	results := bulkResults(t, body)
+	want := []struct {
+		row float64
+		err string
+	}{
+		{2, ""},
+		{3, "expected 3 fields, got 2"},
+		{4, `field 'age' must be an integer, got "twenty"`},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("results = %v, want %d rows", results, len(want))
+	}
+	for i, w := range want {
+		got := results[i]
+		if msg, _ := got["error"].(string); got["row"] != w.row || msg != w.err {
+			t.Errorf("result %d = %v, want row %v error %q", i, got, w.row, w.err)
+		}
+		if got["id"] != nil {
+			t.Errorf("result %d has id %v from a rolled back import", i, got["id"])
+		}
+	}
+	if n := countStudents(t, store); n != 0 {
+		t.Errorf("stored %d students, want the whole import rolled back", n)
+	}
+}
+
+func TestImportCSVDryRun(t *testing.T) {
+	store := newTestStore(t)
+
+	rec := importCSV(store, "?dry_run=true", "name,email,age\nAda,ada@example.com,20\nBob,not-an-email,21\n")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body %s", rec.Code, rec.Body)
+	}
+	body := decode(t, rec)
+	data := body["data"].(map[string]any)
+	if data["dry_run"] != true || data["succeeded"] != 1.0 || data["failed"] != 1.0 {
+		t.Errorf("report = %v, want a dry run with 1 valid and 1 invalid row", data)
+	}
+	if results := bulkResults(t, body); results[1]["row"] != 3.0 || results[1]["error"] == nil {
+		t.Errorf("results = %v, want line 3 reported as invalid", results)
+	}
+	if n := countStudents(t, store); n != 0 {
+		t.Errorf("dry run stored %d students", n)
+	}
+}
+
+func TestImportCSVLimits(t *testing.T) {
+	var rows strings.Builder
+	rows.WriteString("name,email,age\n")
+	for i := range maxBulkRows + 1 {
+		fmt.Fprintf(&rows, "Student,s%d@example.com,20\n", i)
+	}
+	// Two rows are enough to pass the size limit without reaching the row limit
+	huge := "name,email,age\n" + strings.Repeat("Ada,"+strings.Repeat("a", request.MaxBodySize/2)+"@example.com,20\n", 2)
+
+	tests := []struct {
+		name, body string
+	}{
+		{"too many rows", rows.String()},
+		{"too large", huge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestStore(t)
+			expectError(t, importCSV(store, "", tt.body), http.StatusRequestEntityTooLarge, "PAYLOAD_TOO_LARGE")
+			if n := countStudents(t, store); n != 0 {
+				t.Errorf("stored %d students, want none", n)
+			}
+		})
+	}
+}
+
+func TestImportCSVContentType(t *testing.T) {
+	rec := serve(ImportCSV(newTestStore(t)), "POST /students/import", http.MethodPost, "/students/import",
+		`[{"name":"Ada"}]`, nil)
+	expectError(t, rec, http.StatusUnsupportedMediaType, "UNSUPPORTED_MEDIA_TYPE")
+}
+
+// bulkCreate posts body as JSON to the BulkCreate handler.
+func bulkCreate(store storage.Storage, query, body string) *httptest.ResponseRecorder {
+	return serve(BulkCreate(store), "POST /students/bulk", http.MethodPost, "/students/bulk"+query, body, nil)
+}
+
+func TestBulkCreate(t *testing.T) {
+	store := newTestStore(t)
+
+	rec := bulkCreate(store, "", `[{"name":"Ada","email":"ada@example.com","age":20},{"name":"Bob","email":"bob@example.com","age":21}]`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201; body %s", rec.Code, rec.Body)
+	}
+	for i, r := range bulkResults(t, decode(t, rec)) {
+		if r["row"] != float64(i+1) || r["id"] == nil || r["error"] != nil {
+			t.Errorf("result %d = %v, want row %d created", i, r, i+1)
+		}
+	}
+	if n := countStudents(t, store); n != 2 {
+		t.Errorf("stored %d students, want 2", n)
+	}
+}
+
+func TestBulkCreateRollsBack(t *testing.T) {
+	store := newTestStore(t)
+	mustCreate(t, store, "Existing", "taken@example.com", 30)
+
+	tests := []struct {
+		name, body string
+	}{
+		{"invalid row", `[{"name":"Ada","email":"ada@example.com","age":20},{"name":"Bob","email":"not-an-email","age":21}]`},
+		{"duplicate in database", `[{"name":"Ada","email":"ada@example.com","age":20},{"name":"Cy","email":"taken@example.com","age":22}]`},
+		{"duplicate in batch", `[{"name":"Ada","email":"ada@example.com","age":20},{"name":"Ada","email":"ada@example.com","age":20}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := expectError(t, bulkCreate(store, "", tt.body), http.StatusUnprocessableEntity, "VALIDATION_FAILED")
+			data := body["data"].(map[string]any)
+			if data["succeeded"] != 1.0 || data["failed"] != 1.0 {
+				t.Errorf("report = %v, want 1 succeeded and 1 failed", data)
+			}
+			results := bulkResults(t, body)
+			if results[0]["id"] != nil || results[1]["error"] == nil {
+				t.Errorf("results = %v, want row 2 failed and no ids", results)
+			}
+			// The valid first row must not have been kept
+			if n := countStudents(t, store); n != 1 {
+				t.Errorf("stored %d students, want only the existing one", n)
+			}
+		})
+	}
+}
+
+func TestBulkCreateDryRun(t *testing.T) {
+	store := newTestStore(t)
+
+	rec := bulkCreate(store, "?dry_run=true", `[{"name":"Ada","email":"ada@example.com","age":20},{"name":"Bob","email":"bob@example.com","age":-1}]`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body %s", rec.Code, rec.Body)
+	}
+	body := decode(t, rec)
+	data := body["data"].(map[string]any)
+	if data["dry_run"] != true || data["succeeded"] != 1.0 || data["failed"] != 1.0 {
+		t.Errorf("report = %v, want a dry run with 1 valid and 1 invalid row", data)
+	}
+	results := bulkResults(t, body)
+	if results[0]["error"] != nil || results[0]["id"] != nil || results[1]["error"] == nil {
+		t.Errorf("results = %v, want row 1 valid without an id and row 2 invalid", results)
+	}
+	if n := countStudents(t, store); n != 0 {
+		t.Errorf("dry run stored %d students", n)
+	}
+}
+
+func TestBulkCreateLimits(t *testing.T) {
+	store := newTestStore(t)
+
+	expectError(t, bulkCreate(store, "", `[]`), http.StatusBadRequest, "BAD_REQUEST")
+	expectError(t, bulkCreate(store, "?dry_run=maybe", `[]`), http.StatusBadRequest, "BAD_REQUEST")
+
+	students := make([]string, maxBulkRows+1)
+	for i := range students {
+		students[i] = fmt.Sprintf(`{"name":"Student","email":"s%d@example.com","age":20}`, i)
+	}
+	expectError(t, bulkCreate(store, "", "["+strings.Join(students, ",")+"]"), http.StatusRequestEntityTooLarge, "PAYLOAD_TOO_LARGE")
+	if n := countStudents(t, store); n != 0 {
+		t.Errorf("stored %d students, want none", n)
+	}
+}
@@ -0,0 +1,64 @@
+package student
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gourav224/student-api/internal/storage"
+	"github.com/gourav224/student-api/internal/utils/request"
+)
+
+func TestWriteStorageErrorCodes(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{fmt.Errorf("%w: id 7", storage.ErrNotFound), http.StatusNotFound, "NOT_FOUND"},
+		{storage.ErrDuplicateEmail, http.StatusConflict, "DUPLICATE_EMAIL"},
+		{storage.ErrNoFieldsToUpdate, http.StatusBadRequest, "NO_FIELDS_TO_UPDATE"},
+		{storage.ErrQuotaExceeded, http.StatusForbidden, "QUOTA_EXCEEDED"},
+		{storage.ErrAgeOutOfRange, http.StatusConflict, "AGE_OUT_OF_RANGE"},
+		{errors.New("disk on fire"), http.StatusInternalServerError, "INTERNAL_ERROR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			writeStorageError(rec, tt.err, 7)
+			expectError(t, rec, tt.status, tt.code)
+		})
+	}
+}
+
+func TestWriteRequestErrorCodes(t *testing.T) {
+	var validationErrs validator.ValidationErrors
+	errors.As(request.Validate(struct {
+		Age int `validate:"gte=1"`
+	}{}), &validationErrs)
+
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{"validation", validationErrs, http.StatusBadRequest, "VALIDATION_FAILED"},
+		{"too large", request.ErrBodyTooLarge, http.StatusRequestEntityTooLarge, "PAYLOAD_TOO_LARGE"},
+		{"empty", request.ErrEmptyBody, http.StatusBadRequest, "EMPTY_BODY"},
+		{"invalid json", &request.DecodeError{Err: errors.New("unexpected EOF")}, http.StatusBadRequest, "INVALID_JSON"},
+		{"other", errors.New("bad input"), http.StatusBadRequest, "BAD_REQUEST"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			writeRequestError(rec, tt.err)
+			expectError(t, rec, tt.status, tt.code)
+		})
+	}
+}
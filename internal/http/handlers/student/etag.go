@@ -0,0 +1,89 @@
+package student
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gourav224/student-api/internal/types"
+	"github.com/gourav224/student-api/internal/utils/response"
+)
+
+// studentETag returns the strong ETag of a student as written to w, derived
+// from its version, so any change to the record yields a different tag.
+// Strong tags must differ between representations, so those other than the
+// default JSON get the response.Variant after the version, e.g. "3-jsonapi".
+// It is what conditional updates send back in If-Match; see parseVersionETag.
+func studentETag(w http.ResponseWriter, st types.Student) string {
+	tag := strconv.FormatInt(st.Version, 10)
+	if variant := response.Variant(w); variant != "" {
+		tag += "-" + variant
+	}
+	return `"` + tag + `"`
+}
+
+// parseVersionETag returns the version encoded in an ETag from studentETag.
+// Weak tags are rejected, as If-Match requires strong comparison. The
+// representation after the version is ignored: If-Match guards the
+// student's state, which every representation of a version shares.
+func parseVersionETag(etag string) (int64, bool) {
+	raw, ok := strings.CutPrefix(etag, `"`)
+	if !ok {
+		return 0, false
+	}
+	raw, ok = strings.CutSuffix(raw, `"`)
+	if !ok {
+		return 0, false
+	}
+	raw, _, _ = strings.Cut(raw, "-")
+	version, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || version < 1 {
+		return 0, false
+	}
+	return version, true
+}
+
+// etagMatches reports whether an If-None-Match header value matches etag.
+// The header may be "*" or a comma-separated list of (possibly weak) tags.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// errModifiedSince is returned when a student changed after the time given in
+// If-Unmodified-Since; writeStorageError answers it with 412.
+var errModifiedSince = errors.New("student has been modified since If-Unmodified-Since")
+
+// lastModified returns the Last-Modified header value of a student, its
+// updated_at in HTTP date format.
+func lastModified(st types.Student) string {
+	return st.UpdatedAt.UTC().Format(http.TimeFormat)
+}
+
+// unmodifiedSince returns the time in the If-Unmodified-Since header of r.
+// As RFC 9110 requires, the header is ignored when it isn't a valid HTTP date
+// and when If-Match is sent, which is the more precise condition.
+func unmodifiedSince(r *http.Request) (time.Time, bool) {
+	header := r.Header.Get("If-Unmodified-Since")
+	if header == "" || r.Header.Get("If-Match") != "" {
+		return time.Time{}, false
+	}
+	since, err := http.ParseTime(header)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return since, true
+}
+
+// modifiedSince reports whether st changed after since. HTTP dates have
+// whole seconds, so updated_at is truncated to compare them.
+func modifiedSince(st types.Student, since time.Time) bool {
+	return st.UpdatedAt.Truncate(time.Second).After(since)
+}
@@ -0,0 +1,209 @@
+package student
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetByIdETag(t *testing.T) {
+	store := newTestStore(t)
+	id := mustCreate(t, store, "Jane Doe", "jane@example.com", 20)
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		var header map[string]string
+		if ifNoneMatch != "" {
+			header = map[string]string{"If-None-Match": ifNoneMatch}
+		}
+		return serve(GetById(store), "GET /students/{id}", http.MethodGet, "/students/1", "", header)
+	}
+
+	// A fresh fetch carries the ETag
+	rec := get("")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	etag := rec.Header().Get("ETag")
+	if len(etag) < 3 || etag[0] != '"' || etag[len(etag)-1] != '"' {
+		t.Fatalf("ETag = %q, want a quoted tag", etag)
+	}
+
+	// Sending it back gets 304 without a body
+	rec = get(etag)
+	if rec.Code != http.StatusNotModified {
+		t.Fatalf("status = %d, want 304", rec.Code)
+	}
+	if rec.Body.Len() != 0 {
+		t.Errorf("304 response has a body: %q", rec.Body)
+	}
+	if rec.Header().Get("ETag") != etag {
+		t.Errorf("304 ETag = %q, want %q", rec.Header().Get("ETag"), etag)
+	}
+
+	// Once the student changes, the old tag no longer matches
+	if _, err := store.Update(context.Background(), id, 0, map[string]any{"age": 21}); err != nil {
+		t.Fatal(err)
+	}
+	rec = get(etag)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status after update = %d, want 200", rec.Code)
+	}
+	if got := rec.Header().Get("ETag"); got == etag || got == "" {
+		t.Errorf("ETag after update = %q, want a new tag", got)
+	}
+}
+
+func TestETagMatches(t *testing.T) {
+	tests := []struct {
+		ifNoneMatch string
+		want        bool
+	}{
+		{`"1"`, true},
+		{`W/"1"`, true},
+		{`*`, true},
+		{`"2", "1"`, true},
+		{`"2"`, false},
+		{`1`, false},
+		{``, false},
+	}
+
+	for _, tt := range tests {
+		if got := etagMatches(tt.ifNoneMatch, `"1"`); got != tt.want {
+			t.Errorf("etagMatches(%q) = %v, want %v", tt.ifNoneMatch, got, tt.want)
+		}
+	}
+}
+
+func TestUpdateStaleVersion(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		header map[string]string
+	}{
+		{"if-match", `{"age":30}`, map[string]string{"If-Match": `"1"`}},
+		{"version field", `{"age":30,"version":1}`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestStore(t)
+			mustCreate(t, store, "Jane Doe", "jane@example.com", 20)
+			update := func(body string, header map[string]string) *httptest.ResponseRecorder {
+				return serve(UpdateById(store), "PATCH /students/{id}", http.MethodPatch, "/students/1", body, header)
+			}
+
+			// Two clients read version 1; the first update wins
+			rec := update(tt.body, tt.header)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("first update: status = %d, want 200; body %s", rec.Code, rec.Body)
+			}
+			if got := rec.Header().Get("ETag"); got != `"2"` {
+				t.Errorf("ETag = %q, want %q", got, `"2"`)
+			}
+
+			// The second, still at version 1, is rejected and changes nothing
+			rec = update(strings.Replace(tt.body, "30", "40", 1), tt.header)
+			expectError(t, rec, http.StatusConflict, "VERSION_CONFLICT")
+			student, err := store.GetStudentById(context.Background(), 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if student.Age != 30 || student.Version != 2 {
+				t.Errorf("student = age %d, version %d; want the first update's 30, 2", student.Age, student.Version)
+			}
+		})
+	}
+}
+
+func TestUpdateInvalidIfMatch(t *testing.T) {
+	store := newTestStore(t)
+	mustCreate(t, store, "Jane Doe", "jane@example.com", 20)
+
+	tests := []struct {
+		name   string
+		body   string
+		header map[string]string
+		status int
+	}{
+		{"any", `{"age":30}`, map[string]string{"If-Match": "*"}, http.StatusOK},
+		{"weak tag", `{"age":30}`, map[string]string{"If-Match": `W/"2"`}, http.StatusBadRequest},
+		{"tag list", `{"age":30}`, map[string]string{"If-Match": `"1", "2"`}, http.StatusBadRequest},
+		{"disagreeing version", `{"age":30,"version":1}`, map[string]string{"If-Match": `"2"`}, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(UpdateById(store), "PATCH /students/{id}", http.MethodPatch, "/students/1", tt.body, tt.header)
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d; body %s", rec.Code, tt.status, rec.Body)
+			}
+		})
+	}
+}
+
+func TestIfUnmodifiedSince(t *testing.T) {
+	stale := "Mon, 01 Jan 2001 00:00:00 GMT"
+	tests := []struct {
+		name   string
+		header func(lastModified string) map[string]string
+		status int
+	}{
+		{"stale", func(string) map[string]string {
+			return map[string]string{"If-Unmodified-Since": stale}
+		}, http.StatusPreconditionFailed},
+		{"last modified", func(lm string) map[string]string {
+			return map[string]string{"If-Unmodified-Since": lm}
+		}, http.StatusOK},
+		{"later", func(string) map[string]string {
+			return map[string]string{"If-Unmodified-Since": time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)}
+		}, http.StatusOK},
+		{"invalid date ignored", func(string) map[string]string {
+			return map[string]string{"If-Unmodified-Since": "yesterday"}
+		}, http.StatusOK},
+		{"if-match takes precedence", func(string) map[string]string {
+			return map[string]string{"If-Unmodified-Since": stale, "If-Match": `"1"`}
+		}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestStore(t)
+			mustCreate(t, store, "Jane Doe", "jane@example.com", 20)
+			mustCreate(t, store, "John Doe", "john@example.com", 21)
+			lm := serve(GetById(store), "GET /students/{id}", http.MethodGet, "/students/1", "", nil).Header().Get("Last-Modified")
+			if lm == "" {
+				t.Fatal("GET sent no Last-Modified")
+			}
+
+			update := serve(UpdateById(store), "PATCH /students/{id}", http.MethodPatch, "/students/1", `{"age":30}`, tt.header(lm))
+			del := serve(DeleteById(store, newTestAvatars(t)), "DELETE /students/{id}", http.MethodDelete, "/students/2", "", tt.header(lm))
+
+			for op, rec := range map[string]*httptest.ResponseRecorder{"update": update, "delete": del} {
+				if rec.Code != tt.status {
+					t.Errorf("%s: status = %d, want %d; body %s", op, rec.Code, tt.status, rec.Body)
+				}
+			}
+			if tt.status == http.StatusPreconditionFailed {
+				expectError(t, update, http.StatusPreconditionFailed, "PRECONDITION_FAILED")
+				if st, _ := store.GetStudentById(context.Background(), 1); st.Age != 20 {
+					t.Errorf("age = %d after a refused update, want 20", st.Age)
+				}
+				if n := countStudents(t, store); n != 2 {
+					t.Errorf("%d students after a refused delete, want 2", n)
+				}
+			}
+		})
+	}
+}
+
+func TestIfUnmodifiedSinceMissingStudent(t *testing.T) {
+	store := newTestStore(t)
+	header := map[string]string{"If-Unmodified-Since": time.Now().UTC().Format(http.TimeFormat)}
+
+	rec := serve(UpdateById(store), "PATCH /students/{id}", http.MethodPatch, "/students/9", `{"age":30}`, header)
+	expectError(t, rec, http.StatusNotFound, "NOT_FOUND")
+	rec = serve(DeleteById(store, newTestAvatars(t)), "DELETE /students/{id}", http.MethodDelete, "/students/9", "", header)
+	expectError(t, rec, http.StatusNotFound, "NOT_FOUND")
+}
@@ -0,0 +1,77 @@
+package student
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestExportJSONLines(t *testing.T) {
+	store := newTestStore(t)
+	mustCreate(t, store, "Jane Doe", "jane@example.com", 20)
+	mustCreate(t, store, "John Doe", "john@example.com", 21)
+
+	tests := []struct {
+		name  string
+		query string
+		want  []map[string]any
+	}{
+		{"all fields", "?format=jsonl", []map[string]any{
+			{"id": 1.0, "name": "Jane Doe", "email": "jane@example.com", "age": 20.0},
+			{"id": 2.0, "name": "John Doe", "email": "john@example.com", "age": 21.0},
+		}},
+		{"selected fields", "?format=jsonl&fields=id,name", []map[string]any{
+			{"id": 1.0, "name": "Jane Doe"},
+			{"id": 2.0, "name": "John Doe"},
+		}},
+		{"id not selected", "?format=jsonl&fields=name", []map[string]any{
+			{"id": 1.0, "name": "Jane Doe"},
+			{"id": 2.0, "name": "John Doe"},
+		}},
+		{"after cursor", "?format=jsonl&fields=id&after_id=1", []map[string]any{
+			{"id": 2.0},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(Export(store), "GET /students/export", http.MethodGet, "/students/export"+tt.query, "", nil)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200; body %s", rec.Code, rec.Body)
+			}
+			if got := rec.Header().Get("Content-Type"); got != "application/x-ndjson" {
+				t.Errorf("Content-Type = %q, want application/x-ndjson", got)
+			}
+
+			var lines []map[string]any
+			scanner := bufio.NewScanner(rec.Body)
+			for scanner.Scan() {
+				var line map[string]any
+				if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+					t.Fatalf("line %q is not a JSON object: %v", scanner.Text(), err)
+				}
+				// Server-set fields are only checked for presence when selected
+				if len(line) > 4 {
+					for _, key := range []string{"created_at", "updated_at", "version"} {
+						if _, ok := line[key]; !ok {
+							t.Errorf("line %v has no %s", line, key)
+						}
+						delete(line, key)
+					}
+				}
+				lines = append(lines, line)
+			}
+			if !reflect.DeepEqual(lines, tt.want) {
+				t.Errorf("lines = %v, want %v", lines, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportUnknownFormat(t *testing.T) {
+	store := newTestStore(t)
+	rec := serve(Export(store), "GET /students/export", http.MethodGet, "/students/export?format=xml", "", nil)
+	expectError(t, rec, http.StatusBadRequest, "BAD_REQUEST")
+}
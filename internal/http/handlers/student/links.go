@@ -0,0 +1,57 @@
+package student
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// listLinks builds the value of the list endpoint's Link header (RFC 8288)
+// for the page described by meta, with links relative to path, the list's
+// URL path. The other parameters of query, such as filters and fields, are
+// kept in every link, and limit is set to the effective page size.
+//
+// "first" and, unless the list is empty, "last" are always present. "prev"
+// is present when the page number is known and greater than 1, and points
+// to the last page at most. "next" is present when nextCursor is non-nil: by
+// page number when the request used "page", and by after_id cursor
+// otherwise.
+func listLinks(path string, query url.Values, meta pageMeta, nextCursor *int64, byPage bool) string {
+	base := url.Values{}
+	for k, v := range query {
+		if k != "page" && k != "after_id" {
+			base[k] = v
+		}
+	}
+	base.Set("limit", strconv.Itoa(meta.PerPage))
+
+	link := func(param string, value int64, rel string) string {
+		q := url.Values{}
+		for k, v := range base {
+			q[k] = v
+		}
+		q.Set(param, strconv.FormatInt(value, 10))
+		return "<" + path + "?" + q.Encode() + `>; rel="` + rel + `"`
+	}
+
+	var links []string
+	if nextCursor != nil {
+		if byPage {
+			links = append(links, link("page", int64(*meta.Page)+1, "next"))
+		} else {
+			links = append(links, link("after_id", *nextCursor, "next"))
+		}
+	}
+	if meta.Page != nil {
+		// Past the end, prev leads back to the last page rather than further
+		// past it
+		if prev := min(int64(*meta.Page)-1, meta.TotalPages); prev >= 1 {
+			links = append(links, link("page", prev, "prev"))
+		}
+	}
+	links = append(links, link("page", 1, "first"))
+	if meta.TotalPages > 0 {
+		links = append(links, link("page", meta.TotalPages, "last"))
+	}
+	return strings.Join(links, ", ")
+}
@@ -0,0 +1,57 @@
+package student
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestListLinkHeader(t *testing.T) {
+	empty := newTestStore(t)
+	store := newTestStore(t)
+	for i := range 5 {
+		mustCreate(t, store, "Student", fmt.Sprintf("s%d@example.com", i), 20)
+	}
+
+	tests := []struct {
+		name  string
+		empty bool
+		query string
+		want  string
+	}{
+		{"first page by cursor", false, "?limit=2",
+			`</api/students?after_id=2&limit=2>; rel="next", </api/students?limit=2&page=1>; rel="first", </api/students?limit=2&page=3>; rel="last"`},
+		{"next cursor", false, "?after_id=2&limit=2",
+			`</api/students?after_id=4&limit=2>; rel="next", </api/students?limit=2&page=1>; rel="first", </api/students?limit=2&page=3>; rel="last"`},
+		{"first page", false, "?page=1&limit=2",
+			`</api/students?limit=2&page=2>; rel="next", </api/students?limit=2&page=1>; rel="first", </api/students?limit=2&page=3>; rel="last"`},
+		{"middle page", false, "?page=2&limit=2",
+			`</api/students?limit=2&page=3>; rel="next", </api/students?limit=2&page=1>; rel="prev", </api/students?limit=2&page=1>; rel="first", </api/students?limit=2&page=3>; rel="last"`},
+		{"last page", false, "?page=3&limit=2",
+			`</api/students?limit=2&page=2>; rel="prev", </api/students?limit=2&page=1>; rel="first", </api/students?limit=2&page=3>; rel="last"`},
+		{"past the last page", false, "?page=9&limit=2",
+			`</api/students?limit=2&page=3>; rel="prev", </api/students?limit=2&page=1>; rel="first", </api/students?limit=2&page=3>; rel="last"`},
+		{"other parameters kept", false, "?page=2&limit=2&fields=id,name",
+			`</api/students?fields=id%2Cname&limit=2&page=3>; rel="next", </api/students?fields=id%2Cname&limit=2&page=1>; rel="prev", </api/students?fields=id%2Cname&limit=2&page=1>; rel="first", </api/students?fields=id%2Cname&limit=2&page=3>; rel="last"`},
+		{"empty", true, "?limit=2",
+			`</api/students?limit=2&page=1>; rel="first"`},
+		{"empty past the first page", true, "?page=3&limit=2",
+			`</api/students?limit=2&page=1>; rel="first"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := store
+			if tt.empty {
+				s = empty
+			}
+			rec := serve(GetList(s, "/api", 100), "GET /students", http.MethodGet, "/students"+tt.query, "", nil)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200; body %s", rec.Code, rec.Body)
+			}
+			if got := rec.Header().Get("Link"); got != tt.want {
+				t.Errorf("Link =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
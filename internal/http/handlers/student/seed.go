@@ -0,0 +1,55 @@
+package student
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gourav224/student-api/internal/logging"
+	"github.com/gourav224/student-api/internal/storage"
+	"github.com/gourav224/student-api/internal/utils/response"
+)
+
+// Bounds of the "count" parameter of Seed.
+const (
+	defaultSeedCount = 100
+	maxSeedCount     = 10000
+)
+
+//
+// ──────────────────────────────── SEED STUDENTS ────────────────────────────────
+//
+
+// Seed returns an HTTP handler that fills the database with generated
+// students for demos and local testing, e.g. POST /api/seed?count=500.
+//
+// "count" defaults to 100 and may be at most 10000. The students are created
+// by storage.SeedStudents in one transaction, so a failure keeps none of them.
+// The route is meant for non-production environments only; callers must not
+// register it in prod.
+func Seed(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		count := defaultSeedCount
+		if v := r.URL.Query().Get("count"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > maxSeedCount {
+				response.WriteJson(w, http.StatusBadRequest, response.GeneralError(fmt.Errorf("count must be an integer between 1 and %d", maxSeedCount)))
+				return
+			}
+			count = n
+		}
+		logging.FromContext(r.Context()).Warn("Seeding students", slog.Int("count", count))
+
+		if err := storage.SeedStudents(r.Context(), store, count); err != nil {
+			writeStorageError(w, err, 0)
+			return
+		}
+
+		response.WriteJson(w, http.StatusCreated, map[string]any{
+			"status":  "success",
+			"message": "students seeded successfully",
+			"data":    count,
+		})
+	}
+}
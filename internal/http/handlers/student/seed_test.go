@@ -0,0 +1,91 @@
+package student
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/gourav224/student-api/internal/config"
+	"github.com/gourav224/student-api/internal/storage"
+	"github.com/gourav224/student-api/internal/storage/sqlite"
+)
+
+// seed serves POST target with Seed over store.
+func seed(store storage.Storage, target string) int {
+	return serve(Seed(store), "POST /seed", http.MethodPost, target, "", nil).Code
+}
+
+func TestSeed(t *testing.T) {
+	store := newTestStore(t)
+
+	if code := seed(store, "/seed?count=3"); code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201", code)
+	}
+	students, err := store.GetStudents(context.Background(), storage.ListOptions{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var emails []string
+	for _, st := range students {
+		emails = append(emails, st.Email)
+	}
+	want := []string{"alan.kernighan.1@example.com", "barbara.knuth.2@example.com", "dennis.liskov.3@example.com"}
+	if !slices.Equal(emails, want) {
+		t.Errorf("seeded emails = %v, want %v", emails, want)
+	}
+
+	// Seeding again continues the numbering, even after a deletion
+	if _, err := store.Delete(context.Background(), students[0].Id, 0); err != nil {
+		t.Fatal(err)
+	}
+	if code := seed(store, "/seed?count=2"); code != http.StatusCreated {
+		t.Fatalf("second seed: status = %d, want 201", code)
+	}
+	if n := countStudents(t, store); n != 4 {
+		t.Errorf("%d students after seeding twice, want 4", n)
+	}
+
+	// Without a count the default is used
+	if code := seed(store, "/seed"); code != http.StatusCreated {
+		t.Fatalf("default seed: status = %d, want 201", code)
+	}
+	if n := countStudents(t, store); n != 4+defaultSeedCount {
+		t.Errorf("%d students after the default seed, want %d", n, 4+defaultSeedCount)
+	}
+}
+
+func TestSeedRejectsInvalidCount(t *testing.T) {
+	store := newTestStore(t)
+
+	for _, count := range []string{"0", "-1", "abc", "10001"} {
+		t.Run(count, func(t *testing.T) {
+			rec := serve(Seed(store), "POST /seed", http.MethodPost, "/seed?count="+count, "", nil)
+			expectError(t, rec, http.StatusBadRequest, "BAD_REQUEST")
+		})
+	}
+	if n := countStudents(t, store); n != 0 {
+		t.Errorf("%d students seeded, want none", n)
+	}
+}
+
+func TestSeedKeepsNothingOnFailure(t *testing.T) {
+	store, err := sqlite.New(&config.Config{
+		StoragePath:            filepath.Join(t.TempDir(), "students.db"),
+		StorageTable:           "students",
+		StorageConnectAttempts: 1,
+		MaxStudents:            5,
+		AutoMigrate:            true,
+	})
+	if err != nil {
+		t.Fatalf("sqlite.New: %v", err)
+	}
+	defer store.Close()
+
+	rec := serve(Seed(store), "POST /seed", http.MethodPost, "/seed?count=6", "", nil)
+	expectError(t, rec, http.StatusForbidden, "QUOTA_EXCEEDED")
+	if n := countStudents(t, store); n != 0 {
+		t.Errorf("%d students kept after a failed seed, want none", n)
+	}
+}
@@ -0,0 +1,36 @@
+package student
+
+import (
+	"net/http"
+
+	"github.com/gourav224/student-api/internal/logging"
+	"github.com/gourav224/student-api/internal/storage"
+	"github.com/gourav224/student-api/internal/utils/response"
+)
+
+//
+// ──────────────────────────────── AGE DISTRIBUTION ────────────────────────────────
+//
+
+// AgeStats returns an HTTP handler reporting how many students there are of
+// each age, e.g. GET /api/students/stats/age.
+//
+// The data is an object keyed by age, e.g. {"20": 3, "21": 5}; ages with no
+// students are omitted.
+func AgeStats(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logging.FromContext(r.Context()).Info("Fetching student age distribution")
+
+		distribution, err := store.AgeDistribution(r.Context())
+		if err != nil {
+			writeStorageError(w, err, 0)
+			return
+		}
+
+		response.WriteJson(w, http.StatusOK, map[string]any{
+			"status":  "success",
+			"message": "age distribution fetched successfully",
+			"data":    distribution,
+		})
+	}
+}
@@ -4,14 +4,20 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"log/slog"
+	"math"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/gourav224/student-api/internal/avatar"
+	"github.com/gourav224/student-api/internal/logging"
 	"github.com/gourav224/student-api/internal/storage"
 	"github.com/gourav224/student-api/internal/types"
+	"github.com/gourav224/student-api/internal/utils/request"
 	"github.com/gourav224/student-api/internal/utils/response"
 )
 
@@ -22,45 +28,44 @@ import (
 // New returns an HTTP handler for creating a new student.
 //
 // It expects a JSON body containing "name", "email", and "age".
-// Validates input using go-playground/validator,
-// inserts the student into storage, and returns the generated ID.
-func New(storage storage.Storage) http.HandlerFunc {
+// Unknown fields are rejected with 400 Bad Request.
+// Decodes and validates input with request.DecodeAndValidate,
+// inserts the student into storage, and returns the created student.
+// An email that is already taken gets 409 Conflict, and 403 Forbidden is
+// returned once the configured max_students quota is reached.
+// The Location header points at the new resource under apiPrefix,
+// e.g. /api/students/7.
+func New(store storage.Storage, apiPrefix string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		defer r.Body.Close()
 
 		var student types.Student
-
-		// Decode the JSON request body
-		err := json.NewDecoder(r.Body).Decode(&student)
-		if errors.Is(err, io.EOF) {
-			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(errors.New("empty request body")))
+		if err := request.DecodeAndValidate(r, &student); err != nil {
+			writeRequestError(w, err)
 			return
 		}
+
+		// Create new student
+		lastId, err := store.CreateStudent(r.Context(), student.Name, student.Email, student.Age)
 		if err != nil {
-			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(fmt.Errorf("invalid JSON: %w", err)))
+			writeStorageError(w, err, 0)
 			return
 		}
 
-		// Validate input fields
-		validate := validator.New()
-		if err := validate.Struct(student); err != nil {
-			response.WriteJson(w, http.StatusBadRequest, response.ValidationError(err.(validator.ValidationErrors)))
-			return
-		}
+		logging.FromContext(r.Context()).Info("Student created successfully", slog.String("id", fmt.Sprint(lastId)))
 
-		// Create new student
-		lastId, err := storage.CreateStudent(student.Name, student.Email, student.Age)
+		// Read back the stored record so clients see any server-set fields
+		created, err := store.GetStudentById(r.Context(), lastId)
 		if err != nil {
-			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			writeStorageError(w, err, lastId)
 			return
 		}
 
-		slog.Info("Student created successfully", slog.String("id", fmt.Sprint(lastId)))
-
+		w.Header().Set("Location", fmt.Sprintf("%s/students/%d", apiPrefix, lastId))
 		response.WriteJson(w, http.StatusCreated, map[string]any{
 			"status":  "success",
 			"message": "student created successfully",
-			"data":    lastId,
+			"data":    created,
 		})
 	}
 }
@@ -72,20 +77,33 @@ func New(storage storage.Storage) http.HandlerFunc {
 // GetById returns an HTTP handler that fetches a student by their ID.
 //
 // The URL must include the {id} path parameter, e.g. GET /api/students/1.
-func GetById(storage storage.Storage) http.HandlerFunc {
+// Unknown ids get 404 Not Found. The response carries an ETag, derived from
+// the student's version and specific to the representation (see
+// studentETag), and a Last-Modified header; a request whose
+// If-None-Match header matches the ETag gets 304 Not Modified with no body.
+func GetById(store storage.Storage) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id := r.PathValue("id")
-		slog.Info("Fetching student by ID", slog.String("id", id))
+		logging.FromContext(r.Context()).Info("Fetching student by ID", slog.String("id", id))
 
-		intId, err := strconv.ParseInt(id, 10, 64)
+		intId, err := parseId(id)
 		if err != nil {
-			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(errors.New("invalid student ID")))
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
 			return
 		}
 
-		student, err := storage.GetStudentById(intId)
+		student, err := store.GetStudentById(r.Context(), intId)
 		if err != nil {
-			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			writeStorageError(w, err, intId)
+			return
+		}
+
+		etag := studentETag(w, student)
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", lastModified(student))
+
+		if inm := r.Header.Get("If-None-Match"); inm != "" && etagMatches(inm, etag) {
+			w.WriteHeader(http.StatusNotModified)
 			return
 		}
 
@@ -97,18 +115,355 @@ func GetById(storage storage.Storage) http.HandlerFunc {
 	}
 }
 
+// expectedVersion returns the version a conditional update expects, taken
+// from the If-Match header or the "version" body field, or 0 for an
+// unconditional update. "If-Match: *" only requires the student to exist,
+// which every update does. Sending both with different versions is an error.
+func expectedVersion(r *http.Request, body types.StudentUpdate) (int64, error) {
+	var version int64
+	if body.Version.Set {
+		if body.Version.Null {
+			return 0, errors.New("field \"version\" cannot be null")
+		}
+		version = int64(body.Version.Value)
+	}
+
+	ifMatch := strings.TrimSpace(r.Header.Get("If-Match"))
+	if ifMatch == "" || ifMatch == "*" {
+		return version, nil
+	}
+	matchVersion, ok := parseVersionETag(ifMatch)
+	if !ok {
+		return 0, fmt.Errorf("If-Match %q must be \"*\" or a single ETag returned by this API", ifMatch)
+	}
+	if version != 0 && version != matchVersion {
+		return 0, fmt.Errorf("If-Match version %d and field \"version\" %d disagree", matchVersion, version)
+	}
+	return matchVersion, nil
+}
+
+// parseId parses a student id path parameter. Ids start at 1, so zero and
+// negative values are rejected before they reach the database.
+func parseId(raw string) (int64, error) {
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || id < 1 {
+		return 0, errors.New("invalid student ID: must be a positive integer")
+	}
+	return id, nil
+}
+
 //
 // ──────────────────────────────── GET ALL STUDENTS ────────────────────────────────
 //
 
-// GetList returns an HTTP handler that retrieves all students.
-func GetList(storage storage.Storage) http.HandlerFunc {
+// defaultPageSize is the list page size used when the request sets no limit.
+const defaultPageSize = 20
+
+// pageMeta is the "meta" block of a list response.
+type pageMeta struct {
+	// Page is the 1-based page number, or nil when the request used an
+	// after_id cursor, whose position in the page sequence isn't known.
+	Page       *int  `json:"page"`
+	PerPage    int   `json:"per_page"`
+	Total      int64 `json:"total"`
+	TotalPages int64 `json:"total_pages"`
+	// UnfilteredTotal counts all students, ignoring the filters that Total
+	// applies.
+	UnfilteredTotal int64 `json:"unfiltered_total"`
+}
+
+// GetList returns an HTTP handler that retrieves students ordered by id.
+//
+// Supports keyset pagination via the optional "after_id" and "limit" query
+// parameters, e.g. GET /api/students?after_id=100&limit=20 returns the next
+// 20 students with id > 100. The limit defaults to 20 and is clamped to
+// maxPageSize; the effective value is returned as "limit", alongside
+// "next_cursor": the after_id for the following page, or null on the last page.
+// The cursor is also sent in an X-Next-Cursor header when there is a next page.
+//
+// Alternatively, "page" (1-based, e.g. ?page=3&limit=20) selects a page by
+// position; it can't be combined with "after_id". Either way the response
+// carries a "meta" block with page, per_page, total and total_pages, and the
+// total is also sent in an X-Total-Count header. A Link header points at the
+// next, previous, first and last pages, where they apply (see listLinks);
+// its URLs start with apiPrefix.
+//
+// An optional "fields" parameter (e.g. ?fields=name,email) restricts the
+// response to those fields and the id, which is always included; unknown
+// field names are rejected with 400 Bad Request.
+//
+// "created_after" and "created_before" (RFC 3339 timestamps or YYYY-MM-DD
+// dates, taken as UTC midnight) restrict the list to students created in
+// that range, e.g. ?created_after=2024-01-01&created_before=2024-02-01 for
+// January; the lower bound is inclusive and the upper exclusive. The total
+// in "meta" counts only matching students, while "unfiltered_total" counts
+// all of them.
+func GetList(store storage.Storage, apiPrefix string, maxPageSize int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logging.FromContext(r.Context()).Info("Fetching all students")
+
+		opts, err := parseListOptions(r)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+
+		page, err := parsePage(r)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		if page > 0 && opts.AfterId > 0 {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(errors.New("page and after_id cannot be combined")))
+			return
+		}
+
+		// Apply the default page size and cap oversized requests
+		if opts.Limit == 0 {
+			opts.Limit = defaultPageSize
+		}
+		limit := min(opts.Limit, maxPageSize)
+		if page > 0 {
+			// Reject pages whose offset would overflow
+			if page-1 > math.MaxInt/limit {
+				response.WriteJson(w, http.StatusBadRequest, response.GeneralError(errors.New("page is too large")))
+				return
+			}
+			opts.Offset = (page - 1) * limit
+		}
+
+		// Fetch one extra row to learn whether another page exists
+		opts.Limit = limit + 1
+
+		students, err := store.GetStudents(r.Context(), opts)
+		if err != nil {
+			writeStorageError(w, err, 0)
+			return
+		}
+
+		total, err := store.CountStudents(r.Context(), opts.Filter)
+		if err != nil {
+			writeStorageError(w, err, 0)
+			return
+		}
+
+		// A second count is only needed when a filter narrows the first
+		unfiltered := total
+		if opts.Filter != (storage.StudentFilter{}) {
+			unfiltered, err = store.CountStudents(r.Context(), storage.StudentFilter{})
+			if err != nil {
+				writeStorageError(w, err, 0)
+				return
+			}
+		}
+
+		meta := pageMeta{PerPage: limit, Total: total, TotalPages: (total + int64(limit) - 1) / int64(limit), UnfilteredTotal: unfiltered}
+		switch {
+		case page > 0:
+			meta.Page = &page
+		case opts.AfterId == 0:
+			first := 1
+			meta.Page = &first
+		}
+		w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+
+		var nextCursor *int64
+		if len(students) > limit {
+			students = students[:limit]
+			nextCursor = &students[limit-1].Id
+
+			// Also as a header, so raw responses without the envelope can page
+			w.Header().Set("X-Next-Cursor", strconv.FormatInt(*nextCursor, 10))
+		}
+		w.Header().Set("Link", listLinks(apiPrefix+"/students", r.URL.Query(), meta, nextCursor, page > 0))
+
+		body := map[string]any{
+			"status":      "success",
+			"message":     "students fetched successfully",
+			"limit":       limit,
+			"next_cursor": nextCursor,
+			"meta":        meta,
+		}
+
+		if len(opts.Fields) > 0 {
+			projected, err := projectFields(students, opts.Fields)
+			if err != nil {
+				response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+				return
+			}
+			body["data"] = projected
+		} else if students == nil {
+			// An empty page is still a list, not null
+			body["data"] = []types.Student{}
+		} else {
+			body["data"] = students
+		}
+
+		response.WriteJson(w, http.StatusOK, body)
+	}
+}
+
+// parsePage reads the optional 1-based "page" query parameter of the list
+// endpoint, returning 0 when it is absent.
+func parsePage(r *http.Request) (int, error) {
+	v := r.URL.Query().Get("page")
+	if v == "" {
+		return 0, nil
+	}
+
+	page, err := strconv.Atoi(v)
+	if err != nil || page < 1 {
+		return 0, errors.New("page must be a positive integer")
+	}
+	return page, nil
+}
+
+// parseListOptions reads the list query parameters shared by the list and
+// export endpoints: "after_id", "limit", "fields", "created_after" and
+// "created_before".
+func parseListOptions(r *http.Request) (storage.ListOptions, error) {
+	var opts storage.ListOptions
+	query := r.URL.Query()
+
+	if v := query.Get("after_id"); v != "" {
+		afterId, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || afterId < 0 {
+			return opts, errors.New("after_id must be a non-negative integer")
+		}
+		opts.AfterId = afterId
+	}
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return opts, errors.New("limit must be a positive integer")
+		}
+		opts.Limit = limit
+	}
+
+	if v := query.Get("fields"); v != "" {
+		fields, err := parseFields(v)
+		if err != nil {
+			return opts, err
+		}
+		opts.Fields = fields
+	}
+
+	if v := query.Get("created_after"); v != "" {
+		t, err := parseTime(v)
+		if err != nil {
+			return opts, errors.New("created_after must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+		}
+		opts.Filter.CreatedAfter = &t
+	}
+
+	if v := query.Get("created_before"); v != "" {
+		t, err := parseTime(v)
+		if err != nil {
+			return opts, errors.New("created_before must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+		}
+		opts.Filter.CreatedBefore = &t
+	}
+
+	return opts, nil
+}
+
+// parseTime parses an RFC 3339 timestamp, or a YYYY-MM-DD date as midnight UTC.
+func parseTime(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, v)
+}
+
+// parseFields parses a comma-separated "fields" query value, checking each
+// name against storage.StudentColumns and dropping duplicates. The id is
+// always included, first, so clients can tell the students apart.
+func parseFields(raw string) ([]string, error) {
+	fields := []string{"id"}
+	named := false
+	for _, f := range strings.Split(raw, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		if !slices.Contains(storage.StudentColumns, f) {
+			return nil, fmt.Errorf("unknown field %q (allowed: %s)", f, strings.Join(storage.StudentColumns, ", "))
+		}
+		named = true
+		if !slices.Contains(fields, f) {
+			fields = append(fields, f)
+		}
+	}
+
+	if !named {
+		return nil, errors.New("fields must name at least one field")
+	}
+	return fields, nil
+}
+
+// projectFields converts students to JSON objects holding only the given fields,
+// so non-requested fields are omitted from the response entirely.
+func projectFields(students []types.Student, fields []string) ([]map[string]any, error) {
+	projected := make([]map[string]any, 0, len(students))
+	for _, st := range students {
+		b, err := json.Marshal(st)
+		if err != nil {
+			return nil, err
+		}
+
+		var all map[string]json.RawMessage
+		if err := json.Unmarshal(b, &all); err != nil {
+			return nil, err
+		}
+
+		obj := make(map[string]any, len(fields))
+		for _, f := range fields {
+			obj[f] = all[f]
+		}
+		projected = append(projected, obj)
+	}
+	return projected, nil
+}
+
+//
+// ──────────────────────────────── SEARCH STUDENTS ────────────────────────────────
+//
+
+// defaultSearchLimit and maxSearchLimit bound how many matches Search returns.
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// Search returns an HTTP handler that finds students whose name or email
+// contains the "q" query parameter, e.g. GET /api/students/search?q=john.
+//
+// An optional "limit" caps the number of results (default 20, max 100).
+// An empty q is rejected with 400 Bad Request.
+func Search(store storage.Storage) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		slog.Info("Fetching all students")
+		q := strings.TrimSpace(r.URL.Query().Get("q"))
+		logging.FromContext(r.Context()).Info("Searching students", slog.String("q", q))
 
-		students, err := storage.GetStudents()
+		if q == "" {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(errors.New("query parameter 'q' is required")))
+			return
+		}
+
+		limit := defaultSearchLimit
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > maxSearchLimit {
+				response.WriteJson(w, http.StatusBadRequest, response.GeneralError(fmt.Errorf("limit must be an integer between 1 and %d", maxSearchLimit)))
+				return
+			}
+			limit = n
+		}
+
+		students, err := store.SearchStudents(r.Context(), q, limit)
 		if err != nil {
-			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			writeStorageError(w, err, 0)
 			return
 		}
 
@@ -127,42 +482,92 @@ func GetList(storage storage.Storage) http.HandlerFunc {
 // UpdateById returns an HTTP handler that updates one or more fields of a student.
 //
 // Accepts a partial JSON body (PATCH). Only allowed fields ("name", "email", "age")
-// are included in the update map. Unallowed fields are ignored.
+// may be present; any other key, or a value of the wrong type, is rejected
+// with 400 Bad Request. Fields that are sent are validated like on create.
+// A body that sets none of the allowed fields is also a 400.
+//
+// An absent key leaves the field unchanged, while an explicit null clears it;
+// null is only accepted for columns listed in storage.NullableColumns.
+//
+// To avoid overwriting someone else's change, a client may send the version it
+// last saw, either as the ETag in an If-Match header or as the "version"
+// field. The update then only applies if the student is still at that
+// version; otherwise it is rejected with 409 Conflict. Alternatively, an
+// If-Unmodified-Since header makes the update apply only if the student's
+// updated_at isn't later, and otherwise fail with 412 Precondition Failed.
+// The response carries the new ETag and Last-Modified.
 // Example: PATCH /api/students/1
-func UpdateById(storage storage.Storage) http.HandlerFunc {
+func UpdateById(store storage.Storage) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id := r.PathValue("id")
-		slog.Info("Updating student by ID", slog.String("id", id))
+		logging.FromContext(r.Context()).Info("Updating student by ID", slog.String("id", id))
+
+		intId, err := parseId(id)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+
+		defer r.Body.Close()
 
-		intId, err := strconv.ParseInt(id, 10, 64)
+		// Decode into typed optional fields so mistyped values are rejected
+		// and absent keys are told apart from explicit nulls
+		var body types.StudentUpdate
+		if err := request.DecodeAndValidate(r, &body); err != nil {
+			writeRequestError(w, err)
+			return
+		}
+
+		version, err := expectedVersion(r, body)
 		if err != nil {
-			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(errors.New("invalid student ID")))
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
 			return
 		}
 
-		var body map[string]any
-		json.NewDecoder(r.Body).Decode(&body)
+		updates := body.Fields()
+		if len(updates) == 0 {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(errors.New("no fields to update (allowed: name, email, age)")).WithCode(response.CodeNoFieldsToUpdate))
+			return
+		}
 
-		// Only allow certain fields to be updated
-		allowed := map[string]bool{
-			"name":  true,
-			"email": true,
-			"age":   true,
+		// An explicit null clears a field, which only nullable columns allow
+		for k, v := range updates {
+			if v == nil && !slices.Contains(storage.NullableColumns, k) {
+				response.WriteJson(w, http.StatusBadRequest, response.GeneralError(fmt.Errorf("field %q cannot be null", k)))
+				return
+			}
 		}
 
-		updates := map[string]any{}
-		for k, v := range body {
-			if allowed[k] {
-				updates[k] = v
+		// If-Unmodified-Since is checked against the current record, whose
+		// version then guards the update against changes in between
+		since, checkDate := unmodifiedSince(r)
+		dateOnly := checkDate && version == 0
+		if checkDate {
+			current, err := store.GetStudentById(r.Context(), intId)
+			if err != nil {
+				writeStorageError(w, err, intId)
+				return
+			}
+			if modifiedSince(current, since) {
+				writeStorageError(w, errModifiedSince, intId)
+				return
+			}
+			if dateOnly {
+				version = current.Version
 			}
 		}
 
-		student, err := storage.Update(intId, updates)
+		student, err := store.Update(r.Context(), intId, version, updates)
+		if dateOnly && errors.Is(err, storage.ErrVersionConflict) {
+			err = errModifiedSince
+		}
 		if err != nil {
-			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			writeStorageError(w, err, intId)
 			return
 		}
 
+		w.Header().Set("ETag", studentETag(w, student))
+		w.Header().Set("Last-Modified", lastModified(student))
 		response.WriteJson(w, http.StatusOK, map[string]any{
 			"status":  "success",
 			"message": "student updated successfully",
@@ -171,6 +576,147 @@ func UpdateById(storage storage.Storage) http.HandlerFunc {
 	}
 }
 
+//
+// ──────────────────────────────── UPDATE STUDENTS BY FILTER ────────────────────────────────
+//
+
+// UpdateWhere returns an HTTP handler that applies the same changes to every
+// student matching a filter, e.g.
+// PATCH /api/students?min_age=0&max_age=17&confirm=true with {"age": 18}.
+//
+// The filter is given by the inclusive "min_age" and "max_age" query
+// parameters, at least one of which is required. Because a single request can
+// change many rows, "confirm=true" must be sent as well. The body is a partial
+// update like for UpdateById, except that "email" can't be set on many
+// students at once since it must be unique. Returns how many rows changed.
+func UpdateWhere(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+
+		filter, err := parseStudentFilter(r)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		if r.URL.Query().Get("confirm") != "true" {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(errors.New("updating many students requires confirm=true")))
+			return
+		}
+
+		var body types.StudentUpdate
+		if err := request.DecodeAndValidate(r, &body); err != nil {
+			writeRequestError(w, err)
+			return
+		}
+
+		if body.Version.Set {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(errors.New("field \"version\" only applies to updates of a single student")))
+			return
+		}
+
+		updates := body.Fields()
+		if _, ok := updates["email"]; ok {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(errors.New("field \"email\" must be unique and can't be set on many students")))
+			return
+		}
+		if len(updates) == 0 {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(errors.New("no fields to update (allowed: name, age)")).WithCode(response.CodeNoFieldsToUpdate))
+			return
+		}
+		for k, v := range updates {
+			if v == nil && !slices.Contains(storage.NullableColumns, k) {
+				response.WriteJson(w, http.StatusBadRequest, response.GeneralError(fmt.Errorf("field %q cannot be null", k)))
+				return
+			}
+		}
+
+		logging.FromContext(r.Context()).Warn("Updating students by filter", slog.String("min_age", r.URL.Query().Get("min_age")), slog.String("max_age", r.URL.Query().Get("max_age")))
+
+		rowsUpdated, err := store.UpdateWhere(r.Context(), filter, updates)
+		if err != nil {
+			writeStorageError(w, err, 0)
+			return
+		}
+
+		response.WriteJson(w, http.StatusOK, map[string]any{
+			"status":  "success",
+			"message": "students updated successfully",
+			"data":    rowsUpdated,
+		})
+	}
+}
+
+//
+// ──────────────────────────────── INCREMENT AGES ────────────────────────────────
+//
+
+// maxAgeIncrement bounds the "by" parameter of IncrementAges in both directions.
+const maxAgeIncrement = 10
+
+// IncrementAges returns an HTTP handler that adds the same amount to every
+// student's age, e.g. POST /api/students/age/increment?by=1 at the end of a
+// school year. "by" defaults to 1 and must be a non-zero integer between -10
+// and 10, so a mistaken run can be undone. If any age would leave the valid
+// range, nothing changes and the response is 409 Conflict. Returns how many
+// students were updated.
+func IncrementAges(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		by := 1
+		if v := r.URL.Query().Get("by"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n == 0 || n < -maxAgeIncrement || n > maxAgeIncrement {
+				response.WriteJson(w, http.StatusBadRequest, response.GeneralError(fmt.Errorf("by must be a non-zero integer between %d and %d", -maxAgeIncrement, maxAgeIncrement)))
+				return
+			}
+			by = n
+		}
+		logging.FromContext(r.Context()).Warn("Incrementing all ages", slog.Int("by", by))
+
+		rowsUpdated, err := store.IncrementAllAges(r.Context(), by)
+		if err != nil {
+			writeStorageError(w, err, 0)
+			return
+		}
+
+		response.WriteJson(w, http.StatusOK, map[string]any{
+			"status":  "success",
+			"message": "ages incremented successfully",
+			"data":    rowsUpdated,
+		})
+	}
+}
+
+// parseStudentFilter reads the "min_age" and "max_age" query parameters of
+// UpdateWhere. At least one must be set, so a filter never matches everyone
+// by accident.
+func parseStudentFilter(r *http.Request) (storage.StudentFilter, error) {
+	var filter storage.StudentFilter
+	query := r.URL.Query()
+
+	for _, bound := range []struct {
+		name string
+		dst  **int
+	}{{"min_age", &filter.MinAge}, {"max_age", &filter.MaxAge}} {
+		v := query.Get(bound.name)
+		if v == "" {
+			continue
+		}
+		age, err := strconv.Atoi(v)
+		if err != nil || age < 0 {
+			return filter, fmt.Errorf("%s must be a non-negative integer", bound.name)
+		}
+		*bound.dst = &age
+	}
+
+	switch {
+	case filter.MinAge == nil && filter.MaxAge == nil:
+		return filter, errors.New("at least one of min_age and max_age is required")
+	case filter.MinAge != nil && filter.MaxAge != nil && *filter.MinAge > *filter.MaxAge:
+		return filter, errors.New("min_age must not be greater than max_age")
+	}
+	return filter, nil
+}
+
 //
 // ──────────────────────────────── DELETE STUDENT BY ID ────────────────────────────────
 //
@@ -178,23 +724,48 @@ func UpdateById(storage storage.Storage) http.HandlerFunc {
 // DeleteById returns an HTTP handler that deletes a student by their ID.
 //
 // The URL must include the {id} path parameter, e.g. DELETE /api/students/1.
-// Returns how many rows were deleted (0 or 1).
-func DeleteById(storage storage.Storage) http.HandlerFunc {
+// The delete is soft: the student disappears from the API but can be brought
+// back with Restore. The student's avatar files are removed for good.
+// Returns how many rows were deleted, or 404 if the student doesn't exist.
+// With an If-Unmodified-Since header, a student whose updated_at is later is
+// kept and the response is 412 Precondition Failed.
+func DeleteById(store storage.Storage, avatars *avatar.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id := r.PathValue("id")
-		slog.Info("Deleting student by ID", slog.String("id", id))
+		logging.FromContext(r.Context()).Info("Deleting student by ID", slog.String("id", id))
 
-		intId, err := strconv.ParseInt(id, 10, 64)
+		intId, err := parseId(id)
 		if err != nil {
-			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(errors.New("invalid student ID")))
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
 			return
 		}
 
-		rowsDeleted, err := storage.Delete(intId)
+		// If-Unmodified-Since is checked against the current record, whose
+		// version then guards the delete against changes in between
+		var version int64
+		since, checkDate := unmodifiedSince(r)
+		if checkDate {
+			current, err := store.GetStudentById(r.Context(), intId)
+			if err != nil {
+				writeStorageError(w, err, intId)
+				return
+			}
+			if modifiedSince(current, since) {
+				writeStorageError(w, errModifiedSince, intId)
+				return
+			}
+			version = current.Version
+		}
+
+		rowsDeleted, err := store.Delete(r.Context(), intId, version)
+		if checkDate && errors.Is(err, storage.ErrVersionConflict) {
+			err = errModifiedSince
+		}
 		if err != nil {
-			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			writeStorageError(w, err, intId)
 			return
 		}
+		removeAvatars(r.Context(), avatars, intId, "")
 
 		response.WriteJson(w, http.StatusOK, map[string]any{
 			"status":  "success",
@@ -203,3 +774,201 @@ func DeleteById(storage storage.Storage) http.HandlerFunc {
 		})
 	}
 }
+
+//
+// ──────────────────────────────── RESTORE STUDENT ────────────────────────────────
+//
+
+// Restore returns an HTTP handler that brings back a soft-deleted student.
+//
+// The URL must include the {id} path parameter, e.g.
+// POST /api/students/1/restore. The response carries the restored record,
+// without the avatar removed by the delete, and its new ETag. Ids with no
+// deleted student, including students that were never deleted, get 404; a
+// student whose email has since been taken by another gets 409 Conflict.
+func Restore(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		logging.FromContext(r.Context()).Info("Restoring student by ID", slog.String("id", id))
+
+		intId, err := parseId(id)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+
+		student, err := store.Restore(r.Context(), intId)
+		if errors.Is(err, storage.ErrNotFound) {
+			response.WriteJson(w, http.StatusNotFound, response.GeneralError(fmt.Errorf("no deleted student with id %d", intId)))
+			return
+		}
+		if err != nil {
+			writeStorageError(w, err, intId)
+			return
+		}
+
+		w.Header().Set("ETag", studentETag(w, student))
+		w.Header().Set("Last-Modified", lastModified(student))
+		response.WriteJson(w, http.StatusOK, map[string]any{
+			"status":  "success",
+			"message": "student restored successfully",
+			"data":    student,
+		})
+	}
+}
+
+//
+// ──────────────────────────────── DELETE STUDENTS BY IDS ────────────────────────────────
+//
+
+// maxDeleteIds caps how many ids a single batch delete may name.
+const maxDeleteIds = 1000
+
+// DeleteMany returns an HTTP handler that deletes several students at once,
+// e.g. DELETE /api/students?ids=1,2,3.
+//
+// Every id must be a positive integer, otherwise nothing is deleted and the
+// response is 400 Bad Request. Students are soft-deleted, as by DeleteById.
+// Ids that don't exist are ignored; the response reports how many rows were
+// deleted. Avatar files of the ids are removed.
+//
+// Requests without an "ids" parameter are passed to withoutIds, which lets
+// the dataset reset share the route; a nil withoutIds rejects them with 400.
+func DeleteMany(store storage.Storage, avatars *avatar.Store, withoutIds http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !r.URL.Query().Has("ids") {
+			if withoutIds != nil {
+				withoutIds.ServeHTTP(w, r)
+				return
+			}
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(errors.New("query parameter 'ids' is required")))
+			return
+		}
+
+		ids, err := parseIds(r.URL.Query().Get("ids"))
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		logging.FromContext(r.Context()).Info("Deleting students by IDs", slog.Int("count", len(ids)))
+
+		rowsDeleted, err := store.DeleteMany(r.Context(), ids)
+		if err != nil {
+			writeStorageError(w, err, 0)
+			return
+		}
+		for _, id := range ids {
+			removeAvatars(r.Context(), avatars, id, "")
+		}
+
+		response.WriteJson(w, http.StatusOK, map[string]any{
+			"status":  "success",
+			"message": "students deleted successfully",
+			"data":    rowsDeleted,
+		})
+	}
+}
+
+// parseIds parses a comma-separated list of student ids, dropping duplicates.
+// The list is rejected before parsing if it names more than maxDeleteIds ids,
+// duplicates included.
+func parseIds(raw string) ([]int64, error) {
+	if strings.Count(raw, ",") >= maxDeleteIds {
+		return nil, fmt.Errorf("too many ids: at most %d per request", maxDeleteIds)
+	}
+
+	var ids []int64
+	seen := make(map[int64]struct{})
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		id, err := strconv.ParseInt(part, 10, 64)
+		if err != nil || id < 1 {
+			return nil, fmt.Errorf("invalid student ID %q in ids", part)
+		}
+		if _, ok := seen[id]; !ok {
+			seen[id] = struct{}{}
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+//
+// ──────────────────────────────── DELETE ALL STUDENTS ────────────────────────────────
+//
+
+// DeleteAll returns an HTTP handler that removes every student.
+//
+// Unlike the other deletes it is permanent and also purges soft-deleted
+// students. Avatar files are removed as well.
+// Intended for resetting test environments; callers must guard it with
+// admin auth and avoid registering it in production.
+// Returns how many rows were deleted.
+func DeleteAll(store storage.Storage, avatars *avatar.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logging.FromContext(r.Context()).Warn("Deleting all students")
+
+		rowsDeleted, err := store.DeleteAll(r.Context())
+		if err != nil {
+			writeStorageError(w, err, 0)
+			return
+		}
+		if err := avatars.RemoveAll(); err != nil {
+			logging.FromContext(r.Context()).Warn("failed to remove avatar files", slog.String("error", err.Error()))
+		}
+
+		response.WriteJson(w, http.StatusOK, map[string]any{
+			"status":  "success",
+			"message": "all students deleted successfully",
+			"data":    rowsDeleted,
+		})
+	}
+}
+
+// writeRequestError maps an error from the request package to a response:
+// 413 for an oversized body, 400 with per-field details for validation
+// failures, and 400 for anything else.
+func writeRequestError(w http.ResponseWriter, err error) {
+	var validationErrs validator.ValidationErrors
+	switch {
+	case errors.As(err, &validationErrs):
+		response.WriteJson(w, http.StatusBadRequest, response.ValidationError(validationErrs))
+	case errors.Is(err, request.ErrBodyTooLarge):
+		response.WriteJson(w, http.StatusRequestEntityTooLarge, response.GeneralError(err))
+	case errors.Is(err, request.ErrEmptyBody):
+		response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err).WithCode(response.CodeEmptyBody))
+	case errors.As(err, new(*request.DecodeError)):
+		response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err).WithCode(response.CodeInvalidJSON))
+	default:
+		response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+	}
+}
+
+// writeStorageError maps an error from the storage layer to a response using
+// its sentinel errors: 404 for storage.ErrNotFound (naming student id),
+// 409 for storage.ErrDuplicateEmail and storage.ErrVersionConflict, 400 for
+// storage.ErrNoFieldsToUpdate, 403 for storage.ErrQuotaExceeded, 409 for
+// storage.ErrAgeOutOfRange, 503 for storage.ErrTimeout, and 500 for anything
+// else. Operations that aren't about a single student pass a zero id.
+func writeStorageError(w http.ResponseWriter, err error, id int64) {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		response.WriteJson(w, http.StatusNotFound, response.GeneralError(fmt.Errorf("student with id %d not found", id)))
+	case errors.Is(err, storage.ErrDuplicateEmail):
+		response.WriteJson(w, http.StatusConflict, response.GeneralError(storage.ErrDuplicateEmail).WithCode(response.CodeDuplicateEmail))
+	case errors.Is(err, errModifiedSince):
+		response.WriteJson(w, http.StatusPreconditionFailed, response.GeneralError(fmt.Errorf("student with id %d has been modified since If-Unmodified-Since; fetch it and retry", id)).WithCode(response.CodePreconditionFailed))
+	case errors.Is(err, storage.ErrVersionConflict):
+		response.WriteJson(w, http.StatusConflict, response.GeneralError(fmt.Errorf("student with id %d has been modified since the given version; fetch it and retry", id)).WithCode(response.CodeVersionConflict))
+	case errors.Is(err, storage.ErrNoFieldsToUpdate):
+		response.WriteJson(w, http.StatusBadRequest, response.GeneralError(storage.ErrNoFieldsToUpdate).WithCode(response.CodeNoFieldsToUpdate))
+	case errors.Is(err, storage.ErrQuotaExceeded):
+		response.WriteJson(w, http.StatusForbidden, response.GeneralError(err).WithCode(response.CodeQuotaExceeded))
+	case errors.Is(err, storage.ErrAgeOutOfRange):
+		response.WriteJson(w, http.StatusConflict, response.GeneralError(err).WithCode(response.CodeAgeOutOfRange))
+	case errors.Is(err, storage.ErrTimeout):
+		response.WriteJson(w, http.StatusServiceUnavailable, response.GeneralError(storage.ErrTimeout).WithCode(response.CodeTimeout))
+	default:
+		response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+	}
+}
@@ -0,0 +1,925 @@
+package student
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gourav224/student-api/internal/avatar"
+	"github.com/gourav224/student-api/internal/config"
+	"github.com/gourav224/student-api/internal/storage"
+	"github.com/gourav224/student-api/internal/storage/sqlite"
+	"github.com/gourav224/student-api/internal/types"
+	"github.com/gourav224/student-api/internal/utils/request"
+)
+
+// newTestStore opens a SQLite store in a temporary directory, closed and
+// removed when t ends.
+func newTestStore(t *testing.T) storage.Storage {
+	t.Helper()
+	store, err := sqlite.New(&config.Config{
+		StoragePath: filepath.Join(t.TempDir(), "students.db"),
+		AutoMigrate: true,
+	})
+	if err != nil {
+		t.Fatalf("sqlite.New: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// newTestAvatars returns an avatar store in a temporary directory, with
+// avatar URLs under /avatars/.
+func newTestAvatars(t *testing.T) *avatar.Store {
+	t.Helper()
+	avatars, err := avatar.New(t.TempDir(), "/avatars/", 1<<20)
+	if err != nil {
+		t.Fatalf("avatar.New: %v", err)
+	}
+	return avatars
+}
+
+// mustCreate adds a student directly to store and returns its id.
+func mustCreate(t *testing.T, store storage.Storage, name, email string, age int) int64 {
+	t.Helper()
+	id, err := store.CreateStudent(context.Background(), name, email, age)
+	if err != nil {
+		t.Fatalf("CreateStudent: %v", err)
+	}
+	return id
+}
+
+// serve sends a request to h, registered under pattern so that path values
+// are set, and returns the recorded response. A non-empty body is sent as
+// JSON; header entries are set after that and may override it.
+func serve(h http.Handler, pattern, method, target, body string, header map[string]string) *httptest.ResponseRecorder {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, target, reader)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(pattern, h)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+// decode returns the JSON object in the body of rec.
+func decode(t *testing.T, rec *httptest.ResponseRecorder) map[string]any {
+	t.Helper()
+	var body map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON response %q: %v", rec.Body, err)
+	}
+	return body
+}
+
+// expectError checks that rec is an error response with status and code.
+func expectError(t *testing.T, rec *httptest.ResponseRecorder, status int, code string) map[string]any {
+	t.Helper()
+	if rec.Code != status {
+		t.Fatalf("status = %d, want %d; body %s", rec.Code, status, rec.Body)
+	}
+	body := decode(t, rec)
+	if body["status"] != "error" {
+		t.Errorf("body = %v, want an error", body)
+	}
+	if body["code"] != code {
+		t.Errorf("code = %v, want %s", body["code"], code)
+	}
+	return body
+}
+
+// countStudents returns how many students store holds.
+func countStudents(t *testing.T, store storage.Storage) int {
+	t.Helper()
+	students, err := store.GetStudents(context.Background(), storage.ListOptions{})
+	if err != nil {
+		t.Fatalf("GetStudents: %v", err)
+	}
+	return len(students)
+}
+
+func TestCreateRejectsUnknownFields(t *testing.T) {
+	store := newTestStore(t)
+
+	rec := serve(New(store, "/api"), "POST /students", http.MethodPost, "/students",
+		`{"naem":"Jane Doe","email":"jane@example.com","age":20}`, nil)
+
+	body := expectError(t, rec, http.StatusBadRequest, "INVALID_JSON")
+	if msg, _ := body["error"].(string); !strings.Contains(msg, "naem") {
+		t.Errorf("error %q should name the unknown field", msg)
+	}
+	if n := countStudents(t, store); n != 0 {
+		t.Errorf("%d students created, want none", n)
+	}
+}
+
+func TestUpdateRejectsUnknownFields(t *testing.T) {
+	store := newTestStore(t)
+	id := mustCreate(t, store, "Jane Doe", "jane@example.com", 20)
+
+	rec := serve(UpdateById(store), "PATCH /students/{id}", http.MethodPatch, "/students/1", `{"agee":21}`, nil)
+
+	body := expectError(t, rec, http.StatusBadRequest, "INVALID_JSON")
+	if msg, _ := body["error"].(string); !strings.Contains(msg, "agee") {
+		t.Errorf("error %q should name the unknown field", msg)
+	}
+	student, err := store.GetStudentById(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if student.Age != 20 {
+		t.Errorf("student changed to %+v", student)
+	}
+}
+
+func TestUpdateMissingStudent(t *testing.T) {
+	store := newTestStore(t)
+	mustCreate(t, store, "Jane Doe", "jane@example.com", 20)
+
+	rec := serve(UpdateById(store), "PATCH /students/{id}", http.MethodPatch, "/students/42", `{"age":21}`, nil)
+
+	body := expectError(t, rec, http.StatusNotFound, "NOT_FOUND")
+	if body["error"] != "student with id 42 not found" {
+		t.Errorf("error = %q, want it to name the missing id", body["error"])
+	}
+}
+
+func TestMissingStudentIsNotFound(t *testing.T) {
+	store := newTestStore(t)
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		pattern string
+		method  string
+	}{
+		{"get", GetById(store), "GET /students/{id}", http.MethodGet},
+		{"delete", DeleteById(store, newTestAvatars(t)), "DELETE /students/{id}", http.MethodDelete},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(tt.handler, tt.pattern, tt.method, "/students/42", "", nil)
+
+			body := expectError(t, rec, http.StatusNotFound, "NOT_FOUND")
+			if body["error"] != "student with id 42 not found" {
+				t.Errorf("error = %q, want it to name the missing id", body["error"])
+			}
+		})
+	}
+}
+
+func TestCreateDuplicateEmail(t *testing.T) {
+	store := newTestStore(t)
+	mustCreate(t, store, "Jane Doe", "jane@example.com", 20)
+
+	rec := serve(New(store, ""), "POST /students", http.MethodPost, "/students", `{"name":"Jane Roe","email":"jane@example.com","age":22}`, nil)
+
+	body := expectError(t, rec, http.StatusConflict, "DUPLICATE_EMAIL")
+	if body["error"] != storage.ErrDuplicateEmail.Error() {
+		t.Errorf("error = %q, want %q", body["error"], storage.ErrDuplicateEmail)
+	}
+	if n := countStudents(t, store); n != 1 {
+		t.Errorf("store holds %d students, want 1", n)
+	}
+}
+
+func TestCreateReturnsStudent(t *testing.T) {
+	store := newTestStore(t)
+
+	rec := serve(New(store, "/api"), "POST /students", http.MethodPost, "/students",
+		`{"name":"Jane Doe","email":"jane@example.com","age":20}`, nil)
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201; body %s", rec.Code, rec.Body)
+	}
+	var body struct {
+		Status string        `json:"status"`
+		Data   types.Student `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	got := body.Data
+	if got.Id == 0 || got.Name != "Jane Doe" || got.Email != "jane@example.com" || got.Age != 20 {
+		t.Errorf("created student = %+v, want the input with an id", got)
+	}
+
+	stored, err := store.GetStudentById(context.Background(), got.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(stored, got) {
+		t.Errorf("response %+v differs from the stored student %+v", got, stored)
+	}
+}
+
+func TestUpdateWithoutAllowedFields(t *testing.T) {
+	store := newTestStore(t)
+	mustCreate(t, store, "Jane Doe", "jane@example.com", 20)
+
+	tests := []struct {
+		name    string
+		body    string
+		code    string
+		message string
+	}{
+		{"empty object", `{}`, "NO_FIELDS_TO_UPDATE", "no fields to update (allowed: name, email, age)"},
+		{"server-set field", `{"id":7}`, "INVALID_JSON", `json: unknown field "id"`},
+		{"unknown field", `{"grade":"A"}`, "INVALID_JSON", `json: unknown field "grade"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(UpdateById(store), "PATCH /students/{id}", http.MethodPatch, "/students/1", tt.body, nil)
+			body := expectError(t, rec, http.StatusBadRequest, tt.code)
+			if msg, _ := body["error"].(string); !strings.Contains(msg, tt.message) {
+				t.Errorf("error = %q, want it to contain %q", msg, tt.message)
+			}
+		})
+	}
+}
+
+func TestUpdateRejectsNullForRequiredFields(t *testing.T) {
+	store := newTestStore(t)
+	mustCreate(t, store, "Jane Doe", "jane@example.com", 20)
+
+	for _, field := range []string{"name", "email", "age"} {
+		t.Run(field, func(t *testing.T) {
+			rec := serve(UpdateById(store), "PATCH /students/{id}", http.MethodPatch, "/students/1", `{"`+field+`":null}`, nil)
+			body := expectError(t, rec, http.StatusBadRequest, "BAD_REQUEST")
+			if want := `field "` + field + `" cannot be null`; body["error"] != want {
+				t.Errorf("error = %q, want %q", body["error"], want)
+			}
+		})
+	}
+}
+
+// listStudents serves GET target with GetList over store.
+func listStudents(t *testing.T, store storage.Storage, maxPageSize int, target string) map[string]any {
+	t.Helper()
+	rec := serve(GetList(store, "/api", maxPageSize), "GET /students", http.MethodGet, target, "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET %s: status = %d, want 200; body %s", target, rec.Code, rec.Body)
+	}
+	return decode(t, rec)
+}
+
+func TestListPageSize(t *testing.T) {
+	store := newTestStore(t)
+	for i := range 30 {
+		mustCreate(t, store, "Student", fmt.Sprintf("s%d@example.com", i), 20)
+	}
+
+	tests := []struct {
+		name        string
+		maxPageSize int
+		query       string
+		want        int
+	}{
+		{"default", 100, "", defaultPageSize},
+		{"default above the max", 5, "", 5},
+		{"requested", 100, "?limit=3", 3},
+		{"requested above the max", 5, "?limit=1000", 5},
+		{"requested at the max", 5, "?limit=5", 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := listStudents(t, store, tt.maxPageSize, "/students"+tt.query)
+			if got := len(body["data"].([]any)); got != tt.want {
+				t.Errorf("got %d students, want %d", got, tt.want)
+			}
+			if body["limit"] != float64(tt.want) {
+				t.Errorf("limit = %v, want the effective %d", body["limit"], tt.want)
+			}
+		})
+	}
+}
+
+func TestListRejectsInvalidLimit(t *testing.T) {
+	store := newTestStore(t)
+	for _, limit := range []string{"0", "-1", "ten"} {
+		rec := serve(GetList(store, "/api", 100), "GET /students", http.MethodGet, "/students?limit="+limit, "", nil)
+		expectError(t, rec, http.StatusBadRequest, "BAD_REQUEST")
+	}
+}
+
+func TestRejectsNonPositiveIds(t *testing.T) {
+	store := newTestStore(t)
+	mustCreate(t, store, "Jane Doe", "jane@example.com", 20)
+
+	handlers := []struct {
+		name    string
+		h       http.Handler
+		pattern string
+		method  string
+		body    string
+	}{
+		{"get", GetById(store), "GET /students/{id}", http.MethodGet, ""},
+		{"update", UpdateById(store), "PATCH /students/{id}", http.MethodPatch, `{"age":21}`},
+		{"delete", DeleteById(store, newTestAvatars(t)), "DELETE /students/{id}", http.MethodDelete, ""},
+		{"avatar", UploadAvatar(store, newTestAvatars(t)), "POST /students/{id}/avatar", http.MethodPost, ""},
+	}
+
+	for _, hh := range handlers {
+		for _, id := range []string{"0", "-1", "abc"} {
+			t.Run(hh.name+" "+id, func(t *testing.T) {
+				_, path, _ := strings.Cut(hh.pattern, " ")
+				rec := serve(hh.h, hh.pattern, hh.method, strings.Replace(path, "{id}", id, 1), hh.body, nil)
+				body := expectError(t, rec, http.StatusBadRequest, "BAD_REQUEST")
+				if msg, _ := body["error"].(string); !strings.Contains(msg, "positive integer") {
+					t.Errorf("error %q should explain the id bounds", msg)
+				}
+			})
+		}
+	}
+	if n := countStudents(t, store); n != 1 {
+		t.Errorf("%d students left, want the student untouched", n)
+	}
+}
+
+func TestCreateSetsLocation(t *testing.T) {
+	for _, prefix := range []string{"/api", "/v2/api", ""} {
+		t.Run("prefix "+prefix, func(t *testing.T) {
+			store := newTestStore(t)
+			mustCreate(t, store, "Jane Doe", "jane@example.com", 20)
+
+			rec := serve(New(store, prefix), "POST /students", http.MethodPost, "/students",
+				`{"name":"John Doe","email":"john@example.com","age":21}`, nil)
+
+			if rec.Code != http.StatusCreated {
+				t.Fatalf("status = %d, want 201", rec.Code)
+			}
+			if got, want := rec.Header().Get("Location"), prefix+"/students/2"; got != want {
+				t.Errorf("Location = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestAgeNumbers(t *testing.T) {
+	tests := []struct {
+		age    string
+		status int
+	}{
+		{"21", http.StatusOK},
+		{"22.0", http.StatusBadRequest},
+		{"2.3e1", http.StatusBadRequest},
+		{"23.5", http.StatusBadRequest},
+		{"1e-1", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.age, func(t *testing.T) {
+			store := newTestStore(t)
+			mustCreate(t, store, "Jane Doe", "jane@example.com", 20)
+
+			// Create and update accept and reject the same numbers
+			create := serve(New(store, "/api"), "POST /students", http.MethodPost, "/students",
+				`{"name":"John Doe","email":"john@example.com","age":`+tt.age+`}`, nil)
+			update := serve(UpdateById(store), "PATCH /students/{id}", http.MethodPatch, "/students/1", `{"age":`+tt.age+`}`, nil)
+			for _, rec := range []*httptest.ResponseRecorder{create, update} {
+				if tt.status == http.StatusOK {
+					if rec.Code != http.StatusOK && rec.Code != http.StatusCreated {
+						t.Errorf("status = %d, want success; body %s", rec.Code, rec.Body)
+					}
+					continue
+				}
+				body := expectError(t, rec, http.StatusBadRequest, "INVALID_JSON")
+				if msg, _ := body["error"].(string); !strings.Contains(msg, `"age"`) {
+					t.Errorf("error %q should name the age field", msg)
+				}
+			}
+
+			want := 20
+			if tt.status == http.StatusOK {
+				want = 21
+			}
+			if st, _ := store.GetStudentById(context.Background(), 1); st.Age != want {
+				t.Errorf("stored age = %d, want %d", st.Age, want)
+			}
+		})
+	}
+}
+
+func TestCreateQuotaExceeded(t *testing.T) {
+	store, err := sqlite.New(&config.Config{
+		StoragePath: filepath.Join(t.TempDir(), "students.db"),
+		MaxStudents: 2,
+		AutoMigrate: true,
+	})
+	if err != nil {
+		t.Fatalf("sqlite.New: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	create := func(email string) *httptest.ResponseRecorder {
+		return serve(New(store, "/api"), "POST /students", http.MethodPost, "/students",
+			fmt.Sprintf(`{"name":"Jane Doe","email":%q,"age":20}`, email), nil)
+	}
+
+	for i := range 2 {
+		if rec := create(fmt.Sprintf("jane%d@example.com", i)); rec.Code != http.StatusCreated {
+			t.Fatalf("create %d under the quota: status = %d, want 201; body %s", i+1, rec.Code, rec.Body)
+		}
+	}
+	body := expectError(t, create("jane2@example.com"), http.StatusForbidden, "QUOTA_EXCEEDED")
+	if msg, _ := body["error"].(string); !strings.Contains(msg, "at most 2 students") {
+		t.Errorf("error %q should state the quota", msg)
+	}
+}
+
+func TestCreateRejectsNonObjectBodies(t *testing.T) {
+	store := newTestStore(t)
+
+	for _, body := range []string{`[1,2,3]`, `"hello"`, `42`} {
+		t.Run(body, func(t *testing.T) {
+			rec := serve(New(store, "/api"), "POST /students", http.MethodPost, "/students", body, nil)
+
+			resp := expectError(t, rec, http.StatusBadRequest, "INVALID_JSON")
+			if msg, _ := resp["error"].(string); !strings.HasPrefix(msg, "request body must be a JSON object") {
+				t.Errorf("error = %q, want it to ask for a JSON object", msg)
+			}
+		})
+	}
+}
+
+func TestConcurrentCreatesWithSameEmail(t *testing.T) {
+	store := newTestStore(t)
+	const n = 8
+
+	codes := make(chan int, n)
+	var wg sync.WaitGroup
+	for range n {
+		wg.Go(func() {
+			codes <- serve(New(store, "/api"), "POST /students", http.MethodPost, "/students",
+				`{"name":"Jane Doe","email":"jane@example.com","age":20}`, nil).Code
+		})
+	}
+	wg.Wait()
+	close(codes)
+
+	statuses := map[int]int{}
+	for code := range codes {
+		statuses[code]++
+	}
+	if want := map[int]int{http.StatusCreated: 1, http.StatusConflict: n - 1}; !reflect.DeepEqual(statuses, want) {
+		t.Errorf("statuses = %v, want one 201 and the rest 409", statuses)
+	}
+}
+
+func TestDeleteById(t *testing.T) {
+	store := newTestStore(t)
+	id := mustCreate(t, store, "Jane Doe", "jane@example.com", 20)
+	deleteById := func(target string) *httptest.ResponseRecorder {
+		return serve(DeleteById(store, newTestAvatars(t)), "DELETE /students/{id}", http.MethodDelete, target, "", nil)
+	}
+
+	rec := deleteById(fmt.Sprintf("/students/%d", id))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body %s", rec.Code, rec.Body)
+	}
+	if body := decode(t, rec); body["data"] != float64(1) {
+		t.Errorf("data = %v, want 1 row deleted", body["data"])
+	}
+	if n := countStudents(t, store); n != 0 {
+		t.Errorf("%d students left, want none", n)
+	}
+
+	// Deleting it again, or an id that never existed, is a 404
+	for _, target := range []string{fmt.Sprintf("/students/%d", id), "/students/999"} {
+		body := expectError(t, deleteById(target), http.StatusNotFound, "NOT_FOUND")
+		if msg, _ := body["error"].(string); !strings.Contains(msg, "not found") {
+			t.Errorf("error = %q, want it to say the student was not found", msg)
+		}
+	}
+}
+
+func TestDeleteManyIdLimit(t *testing.T) {
+	store := newTestStore(t)
+	id := mustCreate(t, store, "Jane Doe", "jane@example.com", 20)
+	deleteMany := func(ids []string) *httptest.ResponseRecorder {
+		return serve(DeleteMany(store, newTestAvatars(t), nil), "DELETE /students", http.MethodDelete,
+			"/students?ids="+strings.Join(ids, ","), "", nil)
+	}
+
+	// Duplicates count towards the limit, and an over-long list is rejected
+	// before its ids are parsed
+	long := slices.Repeat([]string{strconv.FormatInt(id, 10)}, 50*maxDeleteIds)
+	for _, ids := range [][]string{long, append(slices.Clone(long[:maxDeleteIds]), "abc")} {
+		body := expectError(t, deleteMany(ids), http.StatusBadRequest, "BAD_REQUEST")
+		if msg, _ := body["error"].(string); !strings.Contains(msg, "too many ids") {
+			t.Errorf("error = %q, want it to say there are too many ids", msg)
+		}
+	}
+	if n := countStudents(t, store); n != 1 {
+		t.Errorf("%d students left, want the rejected deletes to keep 1", n)
+	}
+
+	ids := make([]string, maxDeleteIds)
+	for i := range ids {
+		ids[i] = strconv.FormatInt(id+int64(i), 10)
+	}
+	rec := deleteMany(ids)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body %s", rec.Code, rec.Body)
+	}
+	if body := decode(t, rec); body["data"] != float64(1) {
+		t.Errorf("data = %v, want 1 row deleted", body["data"])
+	}
+}
+
+func TestListMeta(t *testing.T) {
+	empty := newTestStore(t)
+	store := newTestStore(t)
+	for i := range 5 {
+		mustCreate(t, store, "Student", fmt.Sprintf("s%d@example.com", i), 20)
+	}
+
+	tests := []struct {
+		name  string
+		store storage.Storage
+		query string
+		n     int
+		meta  map[string]any
+	}{
+		{"empty", empty, "?limit=2", 0,
+			map[string]any{"page": 1.0, "per_page": 2.0, "total": 0.0, "total_pages": 0.0, "unfiltered_total": 0.0}},
+		{"empty beyond the first page", empty, "?page=3&limit=2", 0,
+			map[string]any{"page": 3.0, "per_page": 2.0, "total": 0.0, "total_pages": 0.0, "unfiltered_total": 0.0}},
+		{"first page", store, "?limit=2", 2,
+			map[string]any{"page": 1.0, "per_page": 2.0, "total": 5.0, "total_pages": 3.0, "unfiltered_total": 5.0}},
+		{"partial last page", store, "?page=3&limit=2", 1,
+			map[string]any{"page": 3.0, "per_page": 2.0, "total": 5.0, "total_pages": 3.0, "unfiltered_total": 5.0}},
+		{"past the last page", store, "?page=4&limit=2", 0,
+			map[string]any{"page": 4.0, "per_page": 2.0, "total": 5.0, "total_pages": 3.0, "unfiltered_total": 5.0}},
+		{"exactly one full page", store, "?limit=5", 5,
+			map[string]any{"page": 1.0, "per_page": 5.0, "total": 5.0, "total_pages": 1.0, "unfiltered_total": 5.0}},
+		{"cursor", store, "?after_id=3&limit=2", 2,
+			map[string]any{"page": nil, "per_page": 2.0, "total": 5.0, "total_pages": 3.0, "unfiltered_total": 5.0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := listStudents(t, tt.store, 100, "/students"+tt.query)
+			if got := len(body["data"].([]any)); got != tt.n {
+				t.Errorf("got %d students, want %d", got, tt.n)
+			}
+			if !reflect.DeepEqual(body["meta"], tt.meta) {
+				t.Errorf("meta = %v, want %v", body["meta"], tt.meta)
+			}
+		})
+	}
+}
+
+func TestListPageValidation(t *testing.T) {
+	store := newTestStore(t)
+	targets := []string{
+		"/students?page=0",
+		"/students?page=abc",
+		"/students?page=2&after_id=1",
+		"/students?page=9223372036854775807&limit=10", // offset overflows
+	}
+	for _, target := range targets {
+		rec := serve(GetList(store, "/api", 100), "GET /students", http.MethodGet, target, "", nil)
+		expectError(t, rec, http.StatusBadRequest, "BAD_REQUEST")
+	}
+}
+
+func TestListCreatedRange(t *testing.T) {
+	store := newTestStore(t)
+	mustCreate(t, store, "Jane Doe", "jane@example.com", 20)
+	mustCreate(t, store, "John Doe", "john@example.com", 21)
+	tomorrow := time.Now().UTC().AddDate(0, 0, 1).Format(time.DateOnly)
+
+	tests := []struct {
+		query string
+		n     int
+	}{
+		{"?created_after=2000-01-01", 2},
+		{"?created_after=2000-01-01T00:00:00Z&created_before=" + tomorrow, 2},
+		{"?created_before=2000-01-01", 0},
+		{"?created_after=" + tomorrow, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			body := listStudents(t, store, 100, "/students"+tt.query)
+			if got := len(body["data"].([]any)); got != tt.n {
+				t.Errorf("got %d students, want %d", got, tt.n)
+			}
+			if total := body["meta"].(map[string]any)["total"]; total != float64(tt.n) {
+				t.Errorf("meta.total = %v, want %d", total, tt.n)
+			}
+		})
+	}
+
+	for _, query := range []string{"?created_after=yesterday", "?created_before=2024-13-01", "?created_after=2024-01-01T00:00:00"} {
+		rec := serve(GetList(store, "/api", 100), "GET /students", http.MethodGet, "/students"+query, "", nil)
+		expectError(t, rec, http.StatusBadRequest, "BAD_REQUEST")
+	}
+}
+
+func TestCreateRejectsDisallowedEmailDomain(t *testing.T) {
+	request.SetAllowedEmailDomains([]string{"school.edu"})
+	t.Cleanup(func() { request.SetAllowedEmailDomains(nil) })
+	store := newTestStore(t)
+	create := func(email string) *httptest.ResponseRecorder {
+		return serve(New(store, "/api"), "POST /students", http.MethodPost, "/students",
+			fmt.Sprintf(`{"name":"Jane Doe","email":%q,"age":20}`, email), nil)
+	}
+
+	if rec := create("jane@school.edu"); rec.Code != http.StatusCreated {
+		t.Fatalf("allowed domain: status = %d, want 201; body %s", rec.Code, rec.Body)
+	}
+	body := expectError(t, create("jane@gmail.com"), http.StatusBadRequest, "VALIDATION_FAILED")
+	if msg, _ := body["error"].(string); !strings.Contains(msg, "allowed domain") {
+		t.Errorf("error = %q, want it to mention the allowed domains", msg)
+	}
+	if n := countStudents(t, store); n != 1 {
+		t.Errorf("%d students stored, want only the allowed one", n)
+	}
+}
+
+func TestListFilteredTotals(t *testing.T) {
+	store := newTestStore(t)
+	for i := range 5 {
+		mustCreate(t, store, "Student", fmt.Sprintf("s%d@example.com", i), 20)
+	}
+	// Backdate two students to January 2024
+	january := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	if _, err := store.(*sqlite.Sqlite).Db.Exec("UPDATE students SET created_at = ? WHERE id <= 2", january); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		n          int
+		total      float64
+		unfiltered float64
+	}{
+		{"no filter", "", 5, 5, 5},
+		{"january", "?created_after=2024-01-01&created_before=2024-02-01", 2, 2, 5},
+		{"since february", "?created_after=2024-02-01", 3, 3, 5},
+		{"none match", "?created_before=2023-01-01", 0, 0, 5},
+		{"filtered page", "?created_after=2024-02-01&limit=2", 2, 3, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(GetList(store, "/api", 100), "GET /students", http.MethodGet, "/students"+tt.query, "", nil)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200; body %s", rec.Code, rec.Body)
+			}
+			body := decode(t, rec)
+			meta := body["meta"].(map[string]any)
+			if got := len(body["data"].([]any)); got != tt.n {
+				t.Errorf("got %d students, want %d", got, tt.n)
+			}
+			if meta["total"] != tt.total || meta["unfiltered_total"] != tt.unfiltered {
+				t.Errorf("total, unfiltered_total = %v, %v; want %v, %v", meta["total"], meta["unfiltered_total"], tt.total, tt.unfiltered)
+			}
+			if got := rec.Header().Get("X-Total-Count"); got != fmt.Sprint(tt.total) {
+				t.Errorf("X-Total-Count = %s, want the filtered %v", got, tt.total)
+			}
+		})
+	}
+}
+
+func TestHugeAge(t *testing.T) {
+	store := newTestStore(t)
+	mustCreate(t, store, "Jane Doe", "jane@example.com", 20)
+
+	tests := []struct {
+		age  string
+		code string
+	}{
+		{"99999999999999999999", "INVALID_JSON"},
+		{"-99999999999999999999", "INVALID_JSON"},
+		{"1e30", "INVALID_JSON"},
+		{"9223372036854775807", "VALIDATION_FAILED"},
+		{"121", "VALIDATION_FAILED"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.age, func(t *testing.T) {
+			rec := serve(New(store, "/api"), "POST /students", http.MethodPost, "/students",
+				`{"name":"John Doe","email":"john@example.com","age":`+tt.age+`}`, nil)
+			expectError(t, rec, http.StatusBadRequest, tt.code)
+
+			rec = serve(UpdateById(store), "PATCH /students/{id}", http.MethodPatch, "/students/1", `{"age":`+tt.age+`}`, nil)
+			expectError(t, rec, http.StatusBadRequest, tt.code)
+		})
+	}
+
+	if n := countStudents(t, store); n != 1 {
+		t.Errorf("%d students stored, want only the original", n)
+	}
+	if st, _ := store.GetStudentById(context.Background(), 1); st.Age != 20 {
+		t.Errorf("age = %d, want the original 20", st.Age)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	store := newTestStore(t)
+	mustCreate(t, store, "John Doe", "john@example.com", 20)
+	mustCreate(t, store, "Jane Doe", "jane@example.com", 21)
+	mustCreate(t, store, "Bob Stone", "bob@example.com", 22)
+
+	search := func(query string) *httptest.ResponseRecorder {
+		return serve(Search(store), "GET /students/search", http.MethodGet, "/students/search"+query, "", nil)
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"?q=doe", []string{"John Doe", "Jane Doe"}},
+		{"?q=+stone+", []string{"Bob Stone"}},
+		{"?q=doe&limit=1", []string{"John Doe"}},
+		{"?q=nobody", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			rec := search(tt.query)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200; body %s", rec.Code, rec.Body)
+			}
+			data, _ := decode(t, rec)["data"].([]any)
+			var names []string
+			for _, st := range data {
+				names = append(names, st.(map[string]any)["name"].(string))
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("found %v, want %v", names, tt.want)
+			}
+		})
+	}
+
+	for _, query := range []string{"", "?q=", "?q=+++", "?q=doe&limit=0", "?q=doe&limit=101", "?q=doe&limit=ten"} {
+		t.Run("invalid "+query, func(t *testing.T) {
+			expectError(t, search(query), http.StatusBadRequest, "BAD_REQUEST")
+		})
+	}
+}
+
+func TestRestore(t *testing.T) {
+	store := newTestStore(t)
+	id := mustCreate(t, store, "Jane Doe", "jane@example.com", 20)
+	target := fmt.Sprintf("/students/%d", id)
+	restore := func(target string) *httptest.ResponseRecorder {
+		return serve(Restore(store), "POST /students/{id}/restore", http.MethodPost, target, "", nil)
+	}
+	get := func() *httptest.ResponseRecorder {
+		return serve(GetById(store), "GET /students/{id}", http.MethodGet, target, "", nil)
+	}
+
+	// A student that isn't deleted has nothing to restore
+	expectError(t, restore(target+"/restore"), http.StatusNotFound, "NOT_FOUND")
+
+	serve(DeleteById(store, newTestAvatars(t)), "DELETE /students/{id}", http.MethodDelete, target, "", nil)
+	if n := countStudents(t, store); n != 0 {
+		t.Fatalf("%d students listed after the delete, want none", n)
+	}
+
+	rec := restore(target + "/restore")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body %s", rec.Code, rec.Body)
+	}
+	data := decode(t, rec)["data"].(map[string]any)
+	// Created at version 1, then deleted and restored
+	if data["email"] != "jane@example.com" || data["version"] != 3.0 {
+		t.Errorf("data = %v, want jane at version 3", data)
+	}
+	if etag := rec.Header().Get("ETag"); etag != `"3"` {
+		t.Errorf("ETag = %q, want the restored version", etag)
+	}
+
+	// The restored student is served again, with the same ETag
+	got := get()
+	if got.Code != http.StatusOK {
+		t.Fatalf("GET after restore = %d, want 200; body %s", got.Code, got.Body)
+	}
+	if !reflect.DeepEqual(decode(t, got)["data"], data) {
+		t.Errorf("GET after restore = %v, want %v", decode(t, got)["data"], data)
+	}
+	if got.Header().Get("ETag") != rec.Header().Get("ETag") {
+		t.Errorf("ETag = %q after restore, %q on GET; want them equal", rec.Header().Get("ETag"), got.Header().Get("ETag"))
+	}
+
+	expectError(t, restore(target+"/restore"), http.StatusNotFound, "NOT_FOUND")
+	expectError(t, restore("/students/999/restore"), http.StatusNotFound, "NOT_FOUND")
+	expectError(t, restore("/students/abc/restore"), http.StatusBadRequest, "BAD_REQUEST")
+
+	// Once a new student takes the email, the deleted one can't come back
+	serve(DeleteById(store, newTestAvatars(t)), "DELETE /students/{id}", http.MethodDelete, target, "", nil)
+	mustCreate(t, store, "Jane Again", "jane@example.com", 20)
+	expectError(t, restore(target+"/restore"), http.StatusConflict, "DUPLICATE_EMAIL")
+}
+
+func TestListFields(t *testing.T) {
+	store := newTestStore(t)
+	mustCreate(t, store, "Jane Doe", "jane@example.com", 20)
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"?fields=name,email", []string{"email", "id", "name"}},
+		{"?fields=id", []string{"id"}},
+		{"?fields=age,+age,,name", []string{"age", "id", "name"}},
+		{"?fields=version,created_at", []string{"created_at", "id", "version"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			body := listStudents(t, store, 100, "/students"+tt.query)
+			student := body["data"].([]any)[0].(map[string]any)
+			keys := slices.Sorted(maps.Keys(student))
+			if !slices.Equal(keys, tt.want) {
+				t.Errorf("fields = %v, want %v", keys, tt.want)
+			}
+			// The id is returned whether or not it was asked for
+			if student["id"] != 1.0 {
+				t.Errorf("id = %v, want 1", student["id"])
+			}
+		})
+	}
+
+	for _, query := range []string{"?fields=password", "?fields=name,deleted_at", "?fields=,", "?fields=Name"} {
+		t.Run("invalid "+query, func(t *testing.T) {
+			rec := serve(GetList(store, "/api", 100), "GET /students", http.MethodGet, "/students"+query, "", nil)
+			expectError(t, rec, http.StatusBadRequest, "BAD_REQUEST")
+		})
+	}
+}
+
+func TestListKeysetPagination(t *testing.T) {
+	store := newTestStore(t)
+	for i := range 5 {
+		mustCreate(t, store, "Student", fmt.Sprintf("s%d@example.com", i), 20)
+	}
+	// A deleted student leaves a gap in the ids that the cursor skips over
+	rec := serve(DeleteById(store, newTestAvatars(t)), "DELETE /students/{id}", http.MethodDelete, "/students/3", "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("DELETE status = %d, want 200", rec.Code)
+	}
+
+	// Follow next_cursor from the start until it runs out
+	var ids []float64
+	var pages int
+	target := "/students?limit=2"
+	for {
+		body := listStudents(t, store, 100, target)
+		for _, st := range body["data"].([]any) {
+			ids = append(ids, st.(map[string]any)["id"].(float64))
+		}
+		if pages++; pages > 5 {
+			t.Fatal("next_cursor never ran out")
+		}
+		cursor, ok := body["next_cursor"].(float64)
+		if !ok {
+			if body["next_cursor"] != nil {
+				t.Errorf("next_cursor = %v, want null on the last page", body["next_cursor"])
+			}
+			break
+		}
+		target = fmt.Sprintf("/students?limit=2&after_id=%d", int64(cursor))
+	}
+	if want := []float64{1, 2, 4, 5}; !slices.Equal(ids, want) || pages != 2 {
+		t.Errorf("paged through ids %v in %d pages, want %v in 2", ids, pages, want)
+	}
+
+	// A cursor past the last student gives an empty last page
+	body := listStudents(t, store, 100, "/students?after_id=5")
+	if data := body["data"].([]any); len(data) != 0 || body["next_cursor"] != nil {
+		t.Errorf("after the last student: data %v, next_cursor %v; want an empty last page", data, body["next_cursor"])
+	}
+
+	for _, cursor := range []string{"-1", "abc", "1.5", "99999999999999999999"} {
+		t.Run("invalid "+cursor, func(t *testing.T) {
+			rec := serve(GetList(store, "/api", 100), "GET /students", http.MethodGet, "/students?after_id="+cursor, "", nil)
+			expectError(t, rec, http.StatusBadRequest, "BAD_REQUEST")
+		})
+	}
+}
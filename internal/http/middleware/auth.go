@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gourav224/student-api/internal/utils/response"
+)
+
+// AdminAuth returns middleware that only lets requests through when they carry
+// "Authorization: Bearer <token>" matching the configured admin token.
+//
+// An empty token disables the protected routes entirely (403 Forbidden),
+// so forgetting to configure a token never leaves them open.
+func AdminAuth(token string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token == "" {
+				response.WriteJson(w, http.StatusForbidden, response.GeneralError(errors.New("admin endpoints are disabled: no admin token configured")))
+				return
+			}
+
+			provided, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+				response.WriteJson(w, http.StatusUnauthorized, response.GeneralError(errors.New("missing or invalid admin token")))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
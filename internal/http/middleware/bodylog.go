@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/gourav224/student-api/internal/logging"
+	"github.com/gourav224/student-api/internal/utils/request"
+)
+
+// LogBody returns middleware that logs JSON request bodies at debug level for
+// troubleshooting, with every "email" redacted (see logging.RedactEmail).
+//
+// It only does anything when enabled is true, which callers should tie to the
+// environment not being prod, and when the request logger has debug enabled.
+// The body is read up front and then restored, so the handler decodes it as
+// usual; bodies over request.MaxBodySize are passed through unlogged, and
+// bodies that aren't valid JSON are logged by size only.
+func LogBody(enabled bool) Middleware {
+	if !enabled {
+		return func(next http.Handler) http.Handler { return next }
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			logger := logging.FromContext(r.Context())
+			if !logger.Enabled(r.Context(), slog.LevelDebug) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, request.MaxBodySize+1))
+
+			// Put back what was read, followed by anything left unread, so the
+			// handler sees the body (and its size limit) unchanged
+			r.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+
+			var decoded any
+			switch {
+			case err != nil:
+				logger.Debug("request body unreadable", slog.String("error", err.Error()))
+			case len(body) > request.MaxBodySize:
+				logger.Debug("request body too large to log")
+			case json.Unmarshal(body, &decoded) != nil:
+				logger.Debug("request body is not valid JSON", slog.Int("bytes", len(body)))
+			default:
+				redacted, _ := json.Marshal(logging.RedactJSON(decoded))
+				logger.Debug("request body", slog.String("body", string(redacted)))
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gourav224/student-api/internal/logging"
+)
+
+func TestLogBody(t *testing.T) {
+	const body = `{"name":"Jane Doe","email":"jane@example.com","age":20}`
+
+	tests := []struct {
+		name    string
+		enabled bool
+		level   slog.Level
+		body    string
+		wantLog string
+	}{
+		{name: "redacts email", enabled: true, level: slog.LevelDebug, body: body, wantLog: `j***@example.com`},
+		{name: "invalid JSON", enabled: true, level: slog.LevelDebug, body: `{"name":`, wantLog: "request body is not valid JSON"},
+		{name: "disabled", enabled: false, level: slog.LevelDebug, body: body},
+		{name: "info level", enabled: true, level: slog.LevelInfo, body: body},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: tt.level}))
+
+			var got []byte
+			h := LogBody(tt.enabled)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got, _ = io.ReadAll(r.Body)
+			}))
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			req = req.WithContext(logging.NewContext(req.Context(), logger))
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			if string(got) != tt.body {
+				t.Errorf("handler read body %q, want %q", got, tt.body)
+			}
+			if strings.Contains(logs.String(), "jane@example.com") {
+				t.Errorf("logs contain the unredacted email: %s", logs.String())
+			}
+			switch {
+			case tt.wantLog == "" && logs.Len() > 0:
+				t.Errorf("logged %q, want nothing", logs.String())
+			case !strings.Contains(logs.String(), tt.wantLog):
+				t.Errorf("logs = %q, want them to contain %q", logs.String(), tt.wantLog)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import "net/http"
+
+// Middleware wraps an http.Handler with extra behavior.
+type Middleware func(http.Handler) http.Handler
+
+// Chain wraps h with mws so that the first middleware is the outermost:
+// Chain(h, a, b) handles a request as a(b(h)).
+//
+// Recommended order for the global stack, outermost first, as used by
+// cmd/student-api:
+//
+//   - InFlight            counts every request, so shutdown sees all of them
+//   - StripTrailingSlash  normalizes the path before anything logs or routes it
+//   - Tracing             starts the request span (only when enabled)
+//   - RealIP              resolves the client IP before anything uses it
+//   - RequestLogger       puts the request-scoped logger in the context
+//   - ResponseTime        times everything below, including recovered panics
+//   - Recover             turns panics anywhere below into a JSON 500
+//   - CORS                answers preflights before any real work is done
+//   - ReadOnly            rejects writes before they reach a handler
+//   - Gzip                compresses whatever the inner layers write
+//   - Timeout             sets the request deadline seen by handlers
+//   - PrettyJSON          only marks the writer, so its place is not critical
+//   - RawResponse         likewise only marks the writer
+//   - JSONAPI             likewise only marks the writer
+//   - RouteErrors         turns ServeMux's plain-text 404 and 405 into JSON
+//
+// Below these, the router wraps the API routes in NameSpan, RateLimit,
+// ConcurrencyLimit and Versioning, and single handlers in per-route
+// middleware such as AdminAuth and Idempotency.
+func Chain(h http.Handler, mws ...Middleware) http.Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
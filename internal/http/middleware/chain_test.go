@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// tag returns middleware that appends name to the X-Trace request header on
+// the way in and to the response's X-Trace header on the way out.
+func tag(name string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.Header.Add("X-Trace", name)
+			next.ServeHTTP(w, r)
+			w.Header().Add("X-Trace", name)
+		})
+	}
+}
+
+func TestChainOrder(t *testing.T) {
+	var seen []string
+	h := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.Header.Values("X-Trace")
+	}), tag("a"), tag("b"), tag("c"))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if got := strings.Join(seen, ","); got != "a,b,c" {
+		t.Errorf("request passed through %s, want the first middleware outermost (a,b,c)", got)
+	}
+	if got := strings.Join(rec.Header().Values("X-Trace"), ","); got != "c,b,a" {
+		t.Errorf("response passed back through %s, want c,b,a", got)
+	}
+}
+
+func TestChainEmpty(t *testing.T) {
+	called := false
+	h := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if !called {
+		t.Error("Chain without middleware didn't call the handler")
+	}
+}
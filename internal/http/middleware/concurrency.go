@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/gourav224/student-api/internal/utils/response"
+)
+
+// concurrencyRetryAfter is the Retry-After value, in seconds, sent with
+// requests rejected by ConcurrencyLimit.
+const concurrencyRetryAfter = 1
+
+// ConcurrencyLimit returns middleware that lets at most limit() requests run
+// at once, to protect the database from bursts. Requests over the limit are
+// not queued but rejected right away with 503 Service Unavailable and a
+// Retry-After header, so clients back off instead of piling up.
+// A zero limit disables it. limit is called on every request, so it can be
+// changed at runtime; lowering it lets the requests already running finish.
+func ConcurrencyLimit(limit func() int) Middleware {
+	return func(next http.Handler) http.Handler {
+		// Requests are counted even while the limit is off, so the count
+		// is right when it is turned on
+		var running atomic.Int64
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Only admitted requests are counted: a rejected one must not
+			// take a slot, even briefly, or it could turn others away
+			maxRunning := int64(limit())
+			for {
+				n := running.Load()
+				if maxRunning > 0 && n >= maxRunning {
+					w.Header().Set("Retry-After", strconv.Itoa(concurrencyRetryAfter))
+					response.WriteJson(w, http.StatusServiceUnavailable, response.GeneralError(errors.New("server is busy, retry later")).WithCode(response.CodeServerBusy))
+					return
+				}
+				if running.CompareAndSwap(n, n+1) {
+					break
+				}
+			}
+			defer running.Add(-1)
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestConcurrencyLimit(t *testing.T) {
+	const limit, n = 3, 10
+
+	// Admitted requests block until released, so all n are in flight at once
+	admitted := make(chan struct{}, n)
+	release := make(chan struct{})
+	h := ConcurrencyLimit(func() int { return limit })(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		admitted <- struct{}{}
+		<-release
+	}))
+
+	recs := make(chan *httptest.ResponseRecorder, n)
+	var wg sync.WaitGroup
+	for range limit {
+		wg.Go(func() {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			recs <- rec
+		})
+	}
+	for range limit {
+		<-admitted
+	}
+
+	// Everything over the limit is rejected right away, not queued
+	for range n - limit {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Fatalf("request over the limit: status = %d, want 503", rec.Code)
+		}
+		if got := rec.Header().Get("Retry-After"); got != "1" {
+			t.Errorf("Retry-After = %q, want 1", got)
+		}
+		var body map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body["status"] != "error" {
+			t.Errorf("body = %s, want an error", rec.Body)
+		}
+	}
+
+	close(release)
+	wg.Wait()
+	close(recs)
+	for rec := range recs {
+		if rec.Code != http.StatusOK {
+			t.Errorf("admitted request: status = %d, want 200", rec.Code)
+		}
+	}
+
+	// With the load gone, requests are admitted again
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("request after the burst: status = %d, want 200", rec.Code)
+	}
+}
+
+func TestConcurrencyLimitDisabled(t *testing.T) {
+	h := ConcurrencyLimit(func() int { return 0 })(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200 with the limit off", rec.Code)
+	}
+}
+
+func TestConcurrencyLimitCountsOnlyAdmitted(t *testing.T) {
+	// A second request arrives while the first is being checked against the
+	// limit. Neither is admitted yet, so neither may turn the other away.
+	var h http.Handler
+	arrived := false
+	h = ConcurrencyLimit(func() int {
+		if !arrived {
+			arrived = true
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			if rec.Code != http.StatusOK {
+				t.Errorf("request arriving during the check: status = %d, want 200", rec.Code)
+			}
+		}
+		return 1
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200", rec.Code)
+	}
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+
+	"github.com/gourav224/student-api/internal/utils/response"
+)
+
+// RequireJSON is middleware that rejects POST, PATCH and PUT requests whose
+// Content-Type isn't application/json with 415 Unsupported Media Type.
+// Parameters such as "; charset=utf-8" are allowed. Apply it only to routes
+// that take a JSON body; uploads like CSV import declare their own type.
+func RequireJSON(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost, http.MethodPatch, http.MethodPut:
+			mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if err != nil || mediaType != "application/json" {
+				response.WriteJson(w, http.StatusUnsupportedMediaType, response.GeneralError(errors.New("content type must be application/json")))
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequireJSON(t *testing.T) {
+	tests := []struct {
+		method      string
+		contentType string
+		status      int
+	}{
+		{http.MethodPost, "application/json", http.StatusOK},
+		{http.MethodPost, "application/json; charset=utf-8", http.StatusOK},
+		{http.MethodPatch, "Application/JSON", http.StatusOK},
+		{http.MethodPost, "text/plain", http.StatusUnsupportedMediaType},
+		{http.MethodPost, "application/x-www-form-urlencoded", http.StatusUnsupportedMediaType},
+		{http.MethodPatch, "", http.StatusUnsupportedMediaType},
+		{http.MethodPut, "application/jsonx", http.StatusUnsupportedMediaType},
+		{http.MethodPost, "application/json; charset", http.StatusUnsupportedMediaType},
+		{http.MethodGet, "", http.StatusOK},
+		{http.MethodDelete, "text/plain", http.StatusOK},
+	}
+
+	h := RequireJSON(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.contentType, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/students", strings.NewReader(`{}`))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if tt.status == http.StatusUnsupportedMediaType && !strings.Contains(rec.Body.String(), "content type must be application/json") {
+				t.Errorf("body = %s, want the content type error", rec.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gourav224/student-api/internal/utils/response"
+)
+
+// CORSOptions configures the CORS middleware.
+type CORSOptions struct {
+	// AllowedOrigins lists the origins that may call the API, e.g.
+	// "https://app.example.com". "*" allows any origin.
+	AllowedOrigins []string
+	// AllowCredentials lets browsers send cookies and auth headers with
+	// cross-origin requests. It is ignored when AllowedOrigins contains "*".
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight response.
+	// Zero leaves the browser default.
+	MaxAge time.Duration
+}
+
+// Methods and headers announced in preflight responses.
+const (
+	corsAllowMethods  = "GET, POST, PATCH, DELETE"
+	corsAllowHeaders  = "Content-Type, Authorization, Idempotency-Key, If-None-Match, If-Match, If-Unmodified-Since"
+	corsExposeHeaders = "ETag, Location, Idempotent-Replayed, X-Response-Time, X-Request-ID, X-Next-Cursor, X-Total-Count, Retry-After, Link"
+)
+
+// CORS is middleware that adds Cross-Origin Resource Sharing headers for
+// requests from allowed origins and answers preflight requests itself.
+//
+// Preflights from origins that aren't allowed get 403 Forbidden; other
+// requests from them are served without CORS headers, so browsers block them.
+func CORS(opts CORSOptions) Middleware {
+	anyOrigin := slices.Contains(opts.AllowedOrigins, "*")
+	// Echoing any origin with credentials would let every site act as the
+	// user, so a "*" origin never sends them (config.Validate rejects the mix)
+	allowCredentials := opts.AllowCredentials && !anyOrigin
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Add("Vary", "Origin")
+
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+			allowed := anyOrigin || slices.Contains(opts.AllowedOrigins, origin)
+
+			if !allowed {
+				if preflight {
+					response.WriteJson(w, http.StatusForbidden, response.GeneralError(errors.New("origin not allowed")))
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if anyOrigin {
+				h.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				h.Set("Access-Control-Allow-Origin", origin)
+			}
+			if allowCredentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if !preflight {
+				h.Set("Access-Control-Expose-Headers", corsExposeHeaders)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+			h.Set("Access-Control-Allow-Methods", corsAllowMethods)
+			h.Set("Access-Control-Allow-Headers", corsAllowHeaders)
+			if opts.MaxAge > 0 {
+				h.Set("Access-Control-Max-Age", strconv.Itoa(int(opts.MaxAge.Seconds())))
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
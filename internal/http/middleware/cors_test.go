@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// corsRequest sends a request from origin through CORS(opts), as a preflight
+// for a PATCH when preflight is true, and returns the response.
+func corsRequest(opts CORSOptions, origin string, preflight bool) *httptest.ResponseRecorder {
+	h := CORS(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/students", nil)
+	if preflight {
+		req = httptest.NewRequest(http.MethodOptions, "/api/students/1", nil)
+		req.Header.Set("Access-Control-Request-Method", http.MethodPatch)
+		req.Header.Set("Access-Control-Request-Headers", "content-type")
+	}
+	req.Header.Set("Origin", origin)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCORSPreflightWithCredentials(t *testing.T) {
+	opts := CORSOptions{
+		AllowedOrigins:   []string{"https://app.example.com"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}
+
+	rec := corsRequest(opts, "https://app.example.com", true)
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want 204", rec.Code)
+	}
+	want := map[string]string{
+		"Access-Control-Allow-Origin":      "https://app.example.com",
+		"Access-Control-Allow-Credentials": "true",
+		"Access-Control-Max-Age":           "600",
+		"Access-Control-Allow-Methods":     corsAllowMethods,
+		"Access-Control-Allow-Headers":     corsAllowHeaders,
+	}
+	for k, v := range want {
+		if got := rec.Header().Get(k); got != v {
+			t.Errorf("%s = %q, want %q", k, got, v)
+		}
+	}
+	if vary := strings.Join(rec.Header().Values("Vary"), ", "); vary != "Origin, Access-Control-Request-Method, Access-Control-Request-Headers" {
+		t.Errorf("Vary = %q", vary)
+	}
+}
+
+func TestCORSPreflightWithoutCredentials(t *testing.T) {
+	rec := corsRequest(CORSOptions{AllowedOrigins: []string{"*"}}, "https://any.example.com", true)
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want 204", rec.Code)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want *", got)
+	}
+	for _, k := range []string{"Access-Control-Allow-Credentials", "Access-Control-Max-Age"} {
+		if got := rec.Header().Get(k); got != "" {
+			t.Errorf("%s = %q, want it unset", k, got)
+		}
+	}
+}
+
+func TestCORSWildcardIgnoresCredentials(t *testing.T) {
+	rec := corsRequest(CORSOptions{AllowedOrigins: []string{"*"}, AllowCredentials: true}, "https://app.example.com", false)
+
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want *", got)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Errorf("Access-Control-Allow-Credentials = %q, want it unset for a wildcard origin", got)
+	}
+}
+
+func TestCORSDisallowedOrigin(t *testing.T) {
+	opts := CORSOptions{AllowedOrigins: []string{"https://app.example.com"}, AllowCredentials: true}
+
+	if rec := corsRequest(opts, "https://evil.example.com", true); rec.Code != http.StatusForbidden {
+		t.Errorf("preflight status = %d, want 403", rec.Code)
+	}
+
+	rec := corsRequest(opts, "https://evil.example.com", false)
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want the request served", rec.Code)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want none for a disallowed origin", got)
+	}
+}
@@ -0,0 +1,237 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultGzipMinSize is the smallest response body, in bytes, worth compressing.
+// Below this the gzip framing overhead outweighs the savings.
+const DefaultGzipMinSize = 1024
+
+var gzipWriterPool = sync.Pool{
+	New: func() any { return gzip.NewWriter(nil) },
+}
+
+// Gzip returns middleware that compresses response bodies with gzip when the
+// client advertises support for it in Accept-Encoding.
+//
+// Bodies smaller than minSize bytes are sent uncompressed, as are responses
+// that already set Content-Encoding or carry an already-compressed content type.
+// Wrap the whole router to apply it globally.
+//
+// A compressed response is a different representation, so its strong ETag
+// gets a "-gzip" mark, e.g. "3" becomes "3-gzip". The mark is removed again
+// from If-None-Match before the handler compares tags, and put back on the
+// ETag of the resulting 304.
+func Gzip(minSize int) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			if !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			gw := &gzipResponseWriter{ResponseWriter: w, minSize: minSize}
+			defer gw.finish()
+
+			if inm, ok := unmarkGzipETags(r.Header.Get("If-None-Match")); ok {
+				r = r.Clone(r.Context())
+				r.Header.Set("If-None-Match", inm)
+				gw.markedETags = true
+			}
+
+			next.ServeHTTP(gw, r)
+		})
+	}
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip.
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		if !strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			continue
+		}
+		// "gzip;q=0" explicitly refuses gzip
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if q, err := strconv.ParseFloat(v, 64); err == nil && q == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// gzipResponseWriter buffers the start of the body until it knows whether the
+// response is large enough to compress, then either streams it through a gzip
+// writer or passes it through untouched.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	minSize int
+
+	status  int
+	buf     []byte
+	decided bool
+	gz      *gzip.Writer
+
+	// markedETags records that If-None-Match named compressed representations
+	markedETags bool
+}
+
+func (g *gzipResponseWriter) WriteHeader(status int) {
+	if g.status == 0 {
+		g.status = status
+	}
+}
+
+func (g *gzipResponseWriter) Write(b []byte) (int, error) {
+	if g.status == 0 {
+		g.status = http.StatusOK
+	}
+
+	if g.decided {
+		if g.gz != nil {
+			return g.gz.Write(b)
+		}
+		return g.ResponseWriter.Write(b)
+	}
+
+	g.buf = append(g.buf, b...)
+	if len(g.buf) >= g.minSize {
+		if err := g.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// Flush sends any buffered data to the client, compressing it if allowed,
+// so streaming handlers keep working behind this middleware.
+func (g *gzipResponseWriter) Flush() {
+	if !g.decided {
+		if g.status == 0 {
+			g.status = http.StatusOK
+		}
+		g.decide(true)
+	}
+	if g.gz != nil {
+		g.gz.Flush()
+	}
+	if f, ok := g.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (g *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return g.ResponseWriter
+}
+
+// decide commits the headers and writes out the buffered body, switching to
+// gzip when allowed is true and the response is eligible for compression.
+func (g *gzipResponseWriter) decide(allowed bool) error {
+	g.decided = true
+	h := g.ResponseWriter.Header()
+
+	// A 304 confirms the tag the client sent, compressed as it was
+	if g.status == http.StatusNotModified && g.markedETags {
+		markGzipETag(h)
+	}
+
+	if allowed && compressible(g.status, h) {
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Content-Length")
+		markGzipETag(h)
+
+		g.gz = gzipWriterPool.Get().(*gzip.Writer)
+		g.gz.Reset(g.ResponseWriter)
+	}
+
+	g.ResponseWriter.WriteHeader(g.status)
+
+	if len(g.buf) == 0 {
+		return nil
+	}
+
+	var err error
+	if g.gz != nil {
+		_, err = g.gz.Write(g.buf)
+	} else {
+		_, err = g.ResponseWriter.Write(g.buf)
+	}
+	g.buf = nil
+	return err
+}
+
+// finish flushes whatever the handler left behind once it returns.
+// Bodies that never reached minSize are written uncompressed.
+func (g *gzipResponseWriter) finish() {
+	if !g.decided {
+		if g.status == 0 {
+			// Handler wrote nothing; let net/http send its default response
+			return
+		}
+		g.decide(false)
+	}
+
+	if g.gz != nil {
+		g.gz.Close()
+		gzipWriterPool.Put(g.gz)
+		g.gz = nil
+	}
+}
+
+// gzipETagMark is appended to the ETags of compressed responses.
+const gzipETagMark = "-gzip"
+
+// markGzipETag marks the ETag in h, if any, as that of a compressed
+// representation.
+func markGzipETag(h http.Header) {
+	etag := h.Get("ETag")
+	if len(etag) < 2 || !strings.HasSuffix(etag, `"`) || strings.HasSuffix(etag, gzipETagMark+`"`) {
+		return
+	}
+	h.Set("ETag", strings.TrimSuffix(etag, `"`)+gzipETagMark+`"`)
+}
+
+// unmarkGzipETags removes the mark of markGzipETag from the tags of an
+// If-None-Match header value, and reports whether there were any.
+func unmarkGzipETags(header string) (string, bool) {
+	if !strings.Contains(header, gzipETagMark+`"`) {
+		return header, false
+	}
+	tags := strings.Split(header, ",")
+	for i, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if trimmed, ok := strings.CutSuffix(tag, gzipETagMark+`"`); ok {
+			tag = trimmed + `"`
+		}
+		tags[i] = tag
+	}
+	return strings.Join(tags, ", "), true
+}
+
+// compressible reports whether a response with this status and headers should be gzipped.
+func compressible(status int, h http.Header) bool {
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified {
+		return false
+	}
+	if h.Get("Content-Encoding") != "" {
+		return false
+	}
+
+	contentType := h.Get("Content-Type")
+	for _, prefix := range []string{"image/", "video/", "audio/", "application/zip", "application/gzip", "application/x-gzip"} {
+		if strings.HasPrefix(contentType, prefix) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gourav224/student-api/internal/utils/response"
+)
+
+// largeList is a JSON list response well over DefaultGzipMinSize.
+func largeList() []map[string]any {
+	students := make([]map[string]any, 200)
+	for i := range students {
+		students[i] = map[string]any{"id": i + 1, "name": "Student", "email": "student@example.com", "age": 20}
+	}
+	return students
+}
+
+func TestGzipCompressesLargeJSON(t *testing.T) {
+	h := Gzip(DefaultGzipMinSize)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response.WriteJson(w, http.StatusOK, map[string]any{"status": "success", "data": largeList()})
+	}))
+
+	plain := httptest.NewRecorder()
+	h.ServeHTTP(plain, httptest.NewRequest(http.MethodGet, "/students", nil))
+
+	req := httptest.NewRequest(http.MethodGet, "/students", nil)
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", got)
+	}
+	if got := rec.Header().Get("Vary"); got != "Accept-Encoding" {
+		t.Errorf("Vary = %q, want Accept-Encoding", got)
+	}
+	if rec.Body.Len() >= plain.Body.Len() {
+		t.Errorf("compressed body is %d bytes, not smaller than the %d plain ones", rec.Body.Len(), plain.Body.Len())
+	}
+
+	zr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decompressed, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(decompressed) != plain.Body.String() {
+		t.Error("decompressed body differs from the uncompressed response")
+	}
+}
+
+func TestGzipSkips(t *testing.T) {
+	big := strings.Repeat("x", 2*DefaultGzipMinSize)
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		contentType    string
+		body           string
+	}{
+		{"no accept-encoding", "", "application/json", big},
+		{"gzip refused", "gzip;q=0, identity", "application/json", big},
+		{"other encoding", "br", "application/json", big},
+		{"tiny body", "gzip", "application/json", `{"status":"success"}`},
+		{"already compressed", "gzip", "image/png", big},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := Gzip(DefaultGzipMinSize)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				io.WriteString(w, tt.body)
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if got := rec.Header().Get("Content-Encoding"); got != "" {
+				t.Errorf("Content-Encoding = %q, want none", got)
+			}
+			if rec.Body.String() != tt.body {
+				t.Errorf("body was altered")
+			}
+		})
+	}
+}
+
+func TestGzipETag(t *testing.T) {
+	big := strings.Repeat("x", 2*DefaultGzipMinSize)
+	h := Gzip(DefaultGzipMinSize)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"3"`)
+		if r.Header.Get("If-None-Match") == `"3"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		io.WriteString(w, r.URL.Query().Get("body"))
+	}))
+	serve := func(target, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		name, target, ifNoneMatch string
+		status                    int
+		etag                      string
+	}{
+		{"compressed", "/?body=" + big, "", http.StatusOK, `"3-gzip"`},
+		{"too small to compress", "/?body=tiny", "", http.StatusOK, `"3"`},
+		{"compressed tag matches", "/?body=" + big, `"3-gzip"`, http.StatusNotModified, `"3-gzip"`},
+		{"identity tag matches", "/?body=" + big, `"3"`, http.StatusNotModified, `"3"`},
+		{"stale compressed tag", "/?body=" + big, `"2-gzip"`, http.StatusOK, `"3-gzip"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(tt.target, tt.ifNoneMatch)
+			if rec.Code != tt.status || rec.Header().Get("ETag") != tt.etag {
+				t.Errorf("response = %d with ETag %q, want %d with %q", rec.Code, rec.Header().Get("ETag"), tt.status, tt.etag)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gourav224/student-api/internal/idempotency"
+	"github.com/gourav224/student-api/internal/utils/request"
+	"github.com/gourav224/student-api/internal/utils/response"
+)
+
+// maxIdempotencyKeyLen bounds the accepted Idempotency-Key header length.
+const maxIdempotencyKeyLen = 255
+
+// replayedHeaders are the recorded response headers sent again on a replay.
+// Per-request headers such as X-Request-ID and X-Response-Time are left to
+// the retry's own middleware, so its logs can be correlated.
+var replayedHeaders = []string{"Content-Type", "Location", "ETag", "Last-Modified"}
+
+// Idempotency returns middleware that makes a handler safe to retry.
+//
+// Requests carrying an "Idempotency-Key" header run at most once per key:
+// the first response is recorded in store and replayed verbatim (with an
+// "Idempotent-Replayed: true" header) to later requests with the same key.
+// Only the headers in replayedHeaders are replayed. Reusing a key with a
+// different body is rejected with 422, and a retry that arrives while the
+// original is still running gets 409. Server errors (5xx) are not recorded,
+// so the client may retry them. Bodies over request.MaxBodySize get 413.
+// Requests without the header pass through unchanged.
+func Idempotency(store *idempotency.Store) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get("Idempotency-Key")
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLen {
+				response.WriteJson(w, http.StatusBadRequest, response.GeneralError(errors.New("Idempotency-Key header is too long")))
+				return
+			}
+
+			// Buffer the body so it can be fingerprinted and still read by the handler
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, request.MaxBodySize))
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				response.WriteJson(w, http.StatusRequestEntityTooLarge, response.GeneralError(request.ErrBodyTooLarge))
+				return
+			}
+			if err != nil {
+				response.WriteJson(w, http.StatusBadRequest, response.GeneralError(errors.New("failed to read request body")))
+				return
+			}
+			r.Body.Close()
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			sum := sha256.Sum256(append([]byte(r.Method+" "+r.URL.Path+"\n"), body...))
+			fingerprint := hex.EncodeToString(sum[:])
+
+			state, recorded, sameRequest := store.Reserve(key, fingerprint)
+			if !sameRequest {
+				response.WriteJson(w, http.StatusUnprocessableEntity, response.GeneralError(errors.New("Idempotency-Key was already used with a different request")).WithCode(response.CodeIdempotencyKeyReused))
+				return
+			}
+
+			switch state {
+			case idempotency.InFlight:
+				response.WriteJson(w, http.StatusConflict, response.GeneralError(errors.New("a request with this Idempotency-Key is still being processed")).WithCode(response.CodeIdempotencyKeyInUse))
+				return
+			case idempotency.Done:
+				for _, k := range replayedHeaders {
+					if v := recorded.Header.Values(k); len(v) > 0 {
+						w.Header()[k] = v
+					}
+				}
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(recorded.Status)
+				w.Write(recorded.Body)
+				return
+			}
+
+			rec := &recordingWriter{ResponseWriter: w}
+			defer func() {
+				if rec.status == 0 || rec.status >= http.StatusInternalServerError {
+					store.Release(key)
+					return
+				}
+				store.Complete(key, idempotency.Response{
+					Status: rec.status,
+					Header: rec.header,
+					Body:   rec.body.Bytes(),
+				})
+			}()
+
+			next.ServeHTTP(rec, r)
+		})
+	}
+}
+
+// recordingWriter passes a response through while keeping a copy of its
+// status, headers and body.
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (rw *recordingWriter) WriteHeader(status int) {
+	if rw.status == 0 {
+		rw.status = status
+		rw.header = rw.ResponseWriter.Header().Clone()
+	}
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *recordingWriter) Write(b []byte) (int, error) {
+	if rw.status == 0 {
+		rw.WriteHeader(http.StatusOK)
+	}
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (rw *recordingWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
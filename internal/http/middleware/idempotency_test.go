@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gourav224/student-api/internal/idempotency"
+	"github.com/gourav224/student-api/internal/utils/request"
+)
+
+// sendWithKey serves a POST with the given Idempotency-Key and body through h.
+func sendWithKey(h http.Handler, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/students", strings.NewReader(body))
+	req.Header.Set("Idempotency-Key", key)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+// errorCode returns the "code" of a JSON error response.
+func errorCode(t *testing.T, rec *httptest.ResponseRecorder) any {
+	t.Helper()
+	var body map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON response %q: %v", rec.Body, err)
+	}
+	return body["code"]
+}
+
+func TestIdempotencyReplayHeaders(t *testing.T) {
+	var calls atomic.Int64
+	h := Idempotency(idempotency.New(time.Hour))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Location", "/students/1")
+		w.Header().Set("X-Request-ID", "original")
+		w.Header().Set("X-Custom", "first")
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	sendWithKey(h, "k", `{}`)
+	rec := sendWithKey(h, "k", `{}`)
+	if calls.Load() != 1 {
+		t.Fatalf("handler ran %d times, want once", calls.Load())
+	}
+	if rec.Code != http.StatusCreated || rec.Header().Get("Location") != "/students/1" {
+		t.Errorf("replay = %d, Location %q; want 201, /students/1", rec.Code, rec.Header().Get("Location"))
+	}
+	for _, k := range []string{"X-Request-ID", "X-Custom"} {
+		if got := rec.Header().Get(k); got != "" {
+			t.Errorf("replayed %s = %q, want it left to the retry", k, got)
+		}
+	}
+}
+
+func TestIdempotencyInFlight(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	h := Idempotency(idempotency.New(time.Hour))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- sendWithKey(h, "k", `{}`) }()
+	<-started
+
+	rec := sendWithKey(h, "k", `{}`)
+	if rec.Code != http.StatusConflict || errorCode(t, rec) != "IDEMPOTENCY_KEY_IN_USE" {
+		t.Errorf("duplicate in flight = %d %s, want 409 IDEMPOTENCY_KEY_IN_USE", rec.Code, rec.Body)
+	}
+
+	close(release)
+	if rec := <-done; rec.Code != http.StatusCreated {
+		t.Errorf("original = %d, want 201", rec.Code)
+	}
+}
+
+func TestIdempotencyReleasesServerErrors(t *testing.T) {
+	var calls atomic.Int64
+	h := Idempotency(idempotency.New(time.Hour))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	if rec := sendWithKey(h, "k", `{}`); rec.Code != http.StatusInternalServerError {
+		t.Fatalf("first = %d, want 500", rec.Code)
+	}
+	// The 500 was not recorded, so the retry runs the handler again
+	rec := sendWithKey(h, "k", `{}`)
+	if rec.Code != http.StatusCreated || rec.Header().Get("Idempotent-Replayed") != "" {
+		t.Errorf("retry = %d, replayed %q; want a fresh 201", rec.Code, rec.Header().Get("Idempotent-Replayed"))
+	}
+	if calls.Load() != 2 {
+		t.Errorf("handler ran %d times, want twice", calls.Load())
+	}
+}
+
+func TestIdempotencyBodyTooLarge(t *testing.T) {
+	ran := false
+	h := Idempotency(idempotency.New(time.Hour))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ran = true
+	}))
+
+	rec := sendWithKey(h, "k", strings.Repeat("x", request.MaxBodySize+1))
+	if rec.Code != http.StatusRequestEntityTooLarge || errorCode(t, rec) != "PAYLOAD_TOO_LARGE" {
+		t.Errorf("oversized body = %d %s, want 413 PAYLOAD_TOO_LARGE", rec.Code, rec.Body)
+	}
+	if ran {
+		t.Error("handler ran for an oversized body")
+	}
+}
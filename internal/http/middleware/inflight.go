@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// InFlight counts the requests currently being handled, so shutdown can
+// report how many are still draining. The zero value is ready to use.
+type InFlight struct {
+	n atomic.Int64
+}
+
+// Track is middleware that counts a request as in flight until its handler
+// returns, including when it panics.
+func (f *InFlight) Track(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.n.Add(1)
+		defer f.n.Add(-1)
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Count returns the number of requests currently in flight.
+func (f *InFlight) Count() int64 {
+	return f.n.Load()
+}
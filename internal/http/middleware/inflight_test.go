@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestInFlight(t *testing.T) {
+	var f InFlight
+	started, release := make(chan struct{}), make(chan struct{})
+	h := f.Track(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+	}))
+
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Go(func() {
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		})
+	}
+	for range 3 {
+		<-started
+	}
+	if n := f.Count(); n != 3 {
+		t.Errorf("Count while 3 requests run = %d, want 3", n)
+	}
+
+	close(release)
+	wg.Wait()
+	if n := f.Count(); n != 0 {
+		t.Errorf("Count after they finished = %d, want 0", n)
+	}
+}
+
+func TestInFlightPanic(t *testing.T) {
+	var f InFlight
+	h := f.Track(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	func() {
+		defer func() { recover() }()
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}()
+	if n := f.Count(); n != 0 {
+		t.Errorf("Count after a panic = %d, want 0", n)
+	}
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gourav224/student-api/internal/utils/response"
+)
+
+// JSONAPI is middleware that asks response.WriteJson to format responses as
+// JSON:API documents (https://jsonapi.org) when the Accept header lists
+// application/vnd.api+json, with resources of the given type, e.g. "students".
+// Other requests keep the plain envelope. Request bodies stay plain JSON.
+func JSONAPI(resourceType string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// The response depends on Accept, so caches must key on it
+			addVary(w.Header(), "Accept")
+
+			if acceptsJSONAPI(r.Header.Values("Accept")) {
+				w = &jsonAPIWriter{ResponseWriter: w, resourceType: resourceType}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// acceptsJSONAPI reports whether the Accept header values list the JSON:API
+// media type.
+func acceptsJSONAPI(accept []string) bool {
+	for _, part := range strings.Split(strings.Join(accept, ","), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err == nil && mediaType == response.JSONAPIMediaType {
+			return true
+		}
+	}
+	return false
+}
+
+// addVary adds value to the Vary header unless it is already listed.
+func addVary(h http.Header, value string) {
+	for _, v := range h.Values("Vary") {
+		if slices.ContainsFunc(strings.Split(v, ","), func(listed string) bool {
+			return strings.EqualFold(strings.TrimSpace(listed), value)
+		}) {
+			return
+		}
+	}
+	h.Add("Vary", value)
+}
+
+// jsonAPIWriter marks a response as wanting JSON:API.
+type jsonAPIWriter struct {
+	http.ResponseWriter
+	resourceType string
+}
+
+// JSONAPIType is checked by response.WriteJson.
+func (jw *jsonAPIWriter) JSONAPIType() string {
+	return jw.resourceType
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (jw *jsonAPIWriter) Unwrap() http.ResponseWriter {
+	return jw.ResponseWriter
+}
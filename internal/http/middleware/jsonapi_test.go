@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gourav224/student-api/internal/utils/response"
+)
+
+func TestJSONAPINegotiation(t *testing.T) {
+	h := JSONAPI("students")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response.WriteJson(w, http.StatusOK, map[string]any{"status": "success", "data": map[string]any{"id": 1, "name": "Jane Doe"}})
+	}))
+
+	tests := []struct {
+		accept  string
+		jsonAPI bool
+	}{
+		{"", false},
+		{"application/json", false},
+		{"application/vnd.api+json", true},
+		{"application/json, application/vnd.api+json", true},
+		{"text/html;q=0.9, application/vnd.api+json;q=0.8", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/students/1", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if got := rec.Header().Get("Vary"); got != "Accept" {
+				t.Errorf("Vary = %q, want Accept", got)
+			}
+			var body map[string]any
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			_, isDocument := body["data"].(map[string]any)["attributes"]
+			if isDocument != tt.jsonAPI || (rec.Header().Get("Content-Type") == response.JSONAPIMediaType) != tt.jsonAPI {
+				t.Errorf("JSON:API = %v (Content-Type %q), want %v", isDocument, rec.Header().Get("Content-Type"), tt.jsonAPI)
+			}
+		})
+	}
+}
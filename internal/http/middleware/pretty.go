@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// PrettyJSON is middleware that asks response.WriteJson to indent its output,
+// which makes responses readable when debugging with curl.
+//
+// A "pretty" query parameter (e.g. ?pretty=true or ?pretty=false) decides per
+// request; without one, or with an unparsable value, byDefault applies.
+func PrettyJSON(byDefault bool) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			pretty := byDefault
+			if v := r.URL.Query().Get("pretty"); v != "" {
+				if b, err := strconv.ParseBool(v); err == nil {
+					pretty = b
+				}
+			}
+
+			if pretty {
+				w = &prettyWriter{ResponseWriter: w}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// prettyWriter marks a response as wanting indented JSON.
+type prettyWriter struct {
+	http.ResponseWriter
+}
+
+// PrettyJSON is checked by response.WriteJson.
+func (pw *prettyWriter) PrettyJSON() bool {
+	return true
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (pw *prettyWriter) Unwrap() http.ResponseWriter {
+	return pw.ResponseWriter
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gourav224/student-api/internal/utils/response"
+)
+
+func TestPrettyJSON(t *testing.T) {
+	const (
+		compact  = "{\"status\":\"ok\"}\n"
+		indented = "{\n  \"status\": \"ok\"\n}\n"
+	)
+
+	tests := []struct {
+		name      string
+		byDefault bool
+		target    string
+		want      string
+	}{
+		{name: "default off", byDefault: false, target: "/", want: compact},
+		{name: "default on", byDefault: true, target: "/", want: indented},
+		{name: "query enables", byDefault: false, target: "/?pretty=true", want: indented},
+		{name: "query disables", byDefault: true, target: "/?pretty=false", want: compact},
+		{name: "invalid query keeps default", byDefault: true, target: "/?pretty=maybe", want: indented},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := PrettyJSON(tt.byDefault)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				response.WriteJson(w, http.StatusOK, map[string]string{"status": "ok"})
+			}))
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if got := rec.Body.String(); got != tt.want {
+				t.Errorf("body = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
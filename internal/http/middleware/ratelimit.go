@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gourav224/student-api/internal/utils/response"
+)
+
+// rateLimitSweepInterval is how often RateLimit forgets clients whose
+// allowance has refilled completely.
+const rateLimitSweepInterval = time.Minute
+
+// RateLimit returns middleware that lets each client make limit's rate of
+// requests per second on average, with bursts of up to burst requests. A
+// client is identified by its IP (see ClientIP), falling back to the peer
+// address without RealIP. Requests over the limit are rejected with 429 Too
+// Many Requests and a Retry-After header saying when the next one will be
+// let through.
+// A zero rate disables it. limit is called on every request, so it can be
+// changed at runtime.
+func RateLimit(limit func() (rate float64, burst int)) Middleware {
+	return rateLimit(limit, time.Now)
+}
+
+// rateLimit is RateLimit reading the time from now.
+func rateLimit(limit func() (rate float64, burst int), now func() time.Time) Middleware {
+	return func(next http.Handler) http.Handler {
+		l := &rateLimiter{buckets: make(map[string]*tokenBucket), now: now}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rate, burst := limit()
+			if rate <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			client := ClientIP(r.Context())
+			if client == "" {
+				client = peerAddr(r.RemoteAddr).String()
+			}
+			if wait := l.take(client, rate, burst); wait > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				response.WriteJson(w, http.StatusTooManyRequests, response.GeneralError(errors.New("too many requests, retry later")).WithCode(response.CodeTooManyRequests))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// rateLimiter keeps a token bucket per client.
+type rateLimiter struct {
+	now func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+// tokenBucket holds the requests a client may still make at once, as of last.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// take spends one of client's tokens, refilled at rate per second up to
+// burst. It returns zero if there was one, and otherwise how long until
+// there is.
+func (l *rateLimiter) take(client string, rate float64, burst int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now, rate, burst)
+
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &tokenBucket{tokens: float64(burst), last: now}
+		l.buckets[client] = b
+	}
+	b.tokens = b.refilled(now, rate, burst)
+	b.last = now
+
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) / rate * float64(time.Second))
+	}
+	b.tokens--
+	return 0
+}
+
+// sweep drops, at most once per rateLimitSweepInterval, the buckets that have
+// refilled completely, as they are no different from new ones. It keeps the
+// map from growing with every client ever seen.
+func (l *rateLimiter) sweep(now time.Time, rate float64, burst int) {
+	if now.Sub(l.lastSweep) < rateLimitSweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for client, b := range l.buckets {
+		if b.refilled(now, rate, burst) >= float64(burst) {
+			delete(l.buckets, client)
+		}
+	}
+}
+
+// refilled returns the tokens b holds at now.
+func (b *tokenBucket) refilled(now time.Time, rate float64, burst int) float64 {
+	return min(float64(burst), b.tokens+now.Sub(b.last).Seconds()*rate)
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	clock := time.Date(2026, 1, 15, 9, 30, 0, 0, time.UTC)
+	rate, burst := 2.0, 3
+	h := rateLimit(func() (float64, int) { return rate, burst }, func() time.Time { return clock })(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	serve := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// A burst is let through, then the client has to wait
+	for i := range burst {
+		if rec := serve("203.0.113.7:5000"); rec.Code != http.StatusOK {
+			t.Fatalf("request %d of the burst: status = %d, want 200", i+1, rec.Code)
+		}
+	}
+	rec := serve("203.0.113.7:5001")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "1" {
+		t.Fatalf("request over the burst = %d with Retry-After %q, want 429 with 1", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if code := errorCode(t, rec); code != "TOO_MANY_REQUESTS" {
+		t.Errorf("code = %v, want TOO_MANY_REQUESTS", code)
+	}
+
+	// Other clients have their own allowance
+	if rec := serve("198.51.100.1:5000"); rec.Code != http.StatusOK {
+		t.Errorf("another client: status = %d, want 200", rec.Code)
+	}
+
+	// Tokens come back at the configured rate
+	clock = clock.Add(500 * time.Millisecond)
+	if rec := serve("203.0.113.7:5000"); rec.Code != http.StatusOK {
+		t.Errorf("after waiting for a token: status = %d, want 200", rec.Code)
+	}
+	if rec := serve("203.0.113.7:5000"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("after spending it: status = %d, want 429", rec.Code)
+	}
+
+	// Turning the limit off lets everything through
+	rate = 0
+	for range 2 * burst {
+		if rec := serve("203.0.113.7:5000"); rec.Code != http.StatusOK {
+			t.Fatalf("with the limit off: status = %d, want 200", rec.Code)
+		}
+	}
+}
+
+func TestRateLimitUsesClientIP(t *testing.T) {
+	h := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		RealIP([]netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}), RateLimit(func() (float64, int) { return 1, 1 }))
+	serve := func(client string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "10.0.0.1:5000"
+		req.Header.Set("X-Forwarded-For", client)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Clients behind the same proxy are limited separately
+	if serve("198.51.100.1") != http.StatusOK || serve("198.51.100.2") != http.StatusOK {
+		t.Error("first request of each client was rejected")
+	}
+	if code := serve("198.51.100.1"); code != http.StatusTooManyRequests {
+		t.Errorf("second request of a client: status = %d, want 429", code)
+	}
+}
+
+func TestRateLimiterSweep(t *testing.T) {
+	clock := time.Date(2026, 1, 15, 9, 30, 0, 0, time.UTC)
+	l := &rateLimiter{buckets: make(map[string]*tokenBucket), now: func() time.Time { return clock }}
+
+	l.take("203.0.113.7", 1, 5)
+	l.take("198.51.100.1", 1, 5)
+	clock = clock.Add(rateLimitSweepInterval)
+	l.take("198.51.100.1", 1, 5)
+
+	// Clients whose allowance has refilled are forgotten
+	if _, ok := l.buckets["203.0.113.7"]; ok || len(l.buckets) != 1 {
+		t.Errorf("buckets after sweep = %v, want only 198.51.100.1", l.buckets)
+	}
+}
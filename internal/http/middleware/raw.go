@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// RawResponse is middleware that asks response.WriteJson to drop the
+// {"status", "message", "data"} envelope of successful responses and write
+// the bare resource instead, for clients generated from plain schemas.
+// Error responses keep their structured form.
+//
+// A "raw" query parameter (e.g. ?raw=true or ?raw=false) decides per
+// request; without one, or with an unparsable value, byDefault applies.
+func RawResponse(byDefault bool) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			raw := byDefault
+			if v := r.URL.Query().Get("raw"); v != "" {
+				if b, err := strconv.ParseBool(v); err == nil {
+					raw = b
+				}
+			}
+
+			if raw {
+				w = &rawWriter{ResponseWriter: w}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// rawWriter marks a response as wanting no success envelope.
+type rawWriter struct {
+	http.ResponseWriter
+}
+
+// RawJSON is checked by response.WriteJson.
+func (rw *rawWriter) RawJSON() bool {
+	return true
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (rw *rawWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
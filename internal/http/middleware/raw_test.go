@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gourav224/student-api/internal/utils/response"
+)
+
+func TestRawResponse(t *testing.T) {
+	const (
+		wrapped = "{\"data\":{\"id\":1},\"status\":\"success\"}\n"
+		raw     = "{\"id\":1}\n"
+	)
+
+	tests := []struct {
+		name      string
+		byDefault bool
+		target    string
+		want      string
+	}{
+		{name: "default off", byDefault: false, target: "/", want: wrapped},
+		{name: "default on", byDefault: true, target: "/", want: raw},
+		{name: "query enables", byDefault: false, target: "/?raw=true", want: raw},
+		{name: "query disables", byDefault: true, target: "/?raw=false", want: wrapped},
+		{name: "invalid query keeps default", byDefault: false, target: "/?raw=maybe", want: wrapped},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := RawResponse(tt.byDefault)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				response.WriteJson(w, http.StatusOK, map[string]any{"status": "success", "data": map[string]int{"id": 1}})
+			}))
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if got := rec.Body.String(); got != tt.want {
+				t.Errorf("body = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRawResponseKeepsErrors(t *testing.T) {
+	h := RawResponse(true)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response.WriteJson(w, http.StatusNotFound, response.GeneralError(errors.New("student not found")))
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if want := "{\"status\":\"error\",\"code\":\"NOT_FOUND\",\"error\":\"student not found\"}\n"; rec.Body.String() != want {
+		t.Errorf("body = %q, want %q", rec.Body.String(), want)
+	}
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gourav224/student-api/internal/utils/response"
+)
+
+// ReadOnly returns middleware for maintenance windows: while enabled reports
+// true, GET, HEAD and OPTIONS requests pass through, while every other method
+// is rejected with 503 Service Unavailable so nothing is written. enabled is
+// called on every request, so the mode can be switched at runtime.
+func ReadOnly(enabled func() bool) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				next.ServeHTTP(w, r)
+			default:
+				if !enabled() {
+					next.ServeHTTP(w, r)
+					return
+				}
+				response.WriteJson(w, http.StatusServiceUnavailable, response.GeneralError(errors.New("the API is in read-only mode for maintenance; only GET requests are allowed")).WithCode(response.CodeReadOnly))
+			}
+		})
+	}
+}
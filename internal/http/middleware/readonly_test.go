@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestReadOnly(t *testing.T) {
+	h := ReadOnly(func() bool { return true })(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		method string
+		status int
+	}{
+		{http.MethodGet, http.StatusOK},
+		{http.MethodHead, http.StatusOK},
+		{http.MethodOptions, http.StatusOK},
+		{http.MethodPost, http.StatusServiceUnavailable},
+		{http.MethodPatch, http.StatusServiceUnavailable},
+		{http.MethodPut, http.StatusServiceUnavailable},
+		{http.MethodDelete, http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(tt.method, "/api/students", nil))
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if tt.status == http.StatusServiceUnavailable && !strings.Contains(rec.Body.String(), "read-only mode") {
+				t.Errorf("body = %s, want the read-only error", rec.Body)
+			}
+		})
+	}
+}
+
+func TestReadOnlyToggle(t *testing.T) {
+	var enabled atomic.Bool
+	h := ReadOnly(enabled.Load)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	// The mode is read on every request, so switching it needs no new handler
+	for _, on := range []bool{false, true, false} {
+		enabled.Store(on)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/students", nil))
+
+		want := http.StatusOK
+		if on {
+			want = http.StatusServiceUnavailable
+		}
+		if rec.Code != want {
+			t.Errorf("read-only %v: POST status = %d, want %d", on, rec.Code, want)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// clientIPKey is the context key under which RealIP stores the client IP.
+type clientIPKey struct{}
+
+// RealIP returns middleware that resolves the IP of the client behind any
+// trusted reverse proxies and stores it in the request context, where
+// ClientIP reads it (for logging, rate limiting and the like).
+//
+// Forwarding headers are only believed when the direct peer is within one of
+// the trusted prefixes, since anyone else can set them. X-Forwarded-For is
+// then walked from the right, skipping trusted proxies, and the first other
+// address is the client; X-Real-IP is used when X-Forwarded-For is absent.
+// Without trusted proxies the peer address is always the client.
+func RealIP(trusted []netip.Prefix) Middleware {
+	isTrusted := func(addr netip.Addr) bool {
+		for _, p := range trusted {
+			if p.Contains(addr) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := peerAddr(r.RemoteAddr)
+
+			if ip.IsValid() && isTrusted(ip) {
+				if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+					hops := strings.Split(strings.Join(xff, ","), ",")
+					for i := len(hops) - 1; i >= 0; i-- {
+						hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+						if err != nil {
+							break
+						}
+						ip = hop.Unmap()
+						if !isTrusted(ip) {
+							break
+						}
+					}
+				} else if realIP, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+					ip = realIP.Unmap()
+				}
+			}
+
+			if ip.IsValid() {
+				r = r.WithContext(context.WithValue(r.Context(), clientIPKey{}, ip.String()))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ClientIP returns the client IP resolved by RealIP, or "" if it is unknown.
+func ClientIP(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey{}).(string)
+	return ip
+}
+
+// peerAddr extracts the IP of a "host:port" RemoteAddr, returning the zero
+// Addr if it can't be parsed.
+func peerAddr(remoteAddr string) netip.Addr {
+	if ap, err := netip.ParseAddrPort(remoteAddr); err == nil {
+		return ap.Addr().Unmap()
+	}
+	addr, _ := netip.ParseAddr(remoteAddr)
+	return addr.Unmap()
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestRealIP(t *testing.T) {
+	proxies := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+	tests := []struct {
+		name       string
+		trusted    []netip.Prefix
+		remoteAddr string
+		header     map[string]string
+		want       string
+	}{
+		{"no proxies", nil, "203.0.113.7:5000", nil, "203.0.113.7"},
+		{"no proxies ignores headers", nil, "203.0.113.7:5000",
+			map[string]string{"X-Forwarded-For": "198.51.100.1", "X-Real-IP": "198.51.100.2"}, "203.0.113.7"},
+		{"untrusted peer ignores headers", proxies, "203.0.113.7:5000",
+			map[string]string{"X-Forwarded-For": "198.51.100.1"}, "203.0.113.7"},
+		{"trusted peer without headers", proxies, "10.0.0.1:5000", nil, "10.0.0.1"},
+		{"trusted peer with forwarded for", proxies, "10.0.0.1:5000",
+			map[string]string{"X-Forwarded-For": "198.51.100.1"}, "198.51.100.1"},
+		{"spoofed hops left of the client", proxies, "10.0.0.1:5000",
+			map[string]string{"X-Forwarded-For": "192.0.2.66, 198.51.100.1, 10.0.0.2"}, "198.51.100.1"},
+		{"real ip header", proxies, "10.0.0.1:5000",
+			map[string]string{"X-Real-IP": "198.51.100.2"}, "198.51.100.2"},
+		{"forwarded for wins over real ip", proxies, "10.0.0.1:5000",
+			map[string]string{"X-Forwarded-For": "198.51.100.1", "X-Real-IP": "198.51.100.2"}, "198.51.100.1"},
+		{"malformed hop", proxies, "10.0.0.1:5000",
+			map[string]string{"X-Forwarded-For": "not-an-ip"}, "10.0.0.1"},
+		{"ipv6 peer", nil, "[2001:db8::1]:5000", nil, "2001:db8::1"},
+		{"ipv4-mapped ipv6 hop", proxies, "10.0.0.1:5000",
+			map[string]string{"X-Forwarded-For": "::ffff:198.51.100.1"}, "198.51.100.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			h := RealIP(tt.trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = ClientIP(r.Context())
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.want {
+				t.Errorf("ClientIP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gourav224/student-api/internal/logging"
+	"github.com/gourav224/student-api/internal/utils/response"
+)
+
+// Recover is middleware that turns a panic in a downstream handler into a
+// logged stack trace and a 500 JSON error, instead of a dropped connection.
+//
+// Panics with http.ErrAbortHandler are re-raised so net/http can abort the
+// response silently, as it would without this middleware.
+func Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if err, ok := rec.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(rec)
+			}
+
+			// The request logger already carries the method and path
+			logging.FromContext(r.Context()).Error("panic while handling request",
+				slog.String("panic", fmt.Sprint(rec)),
+				slog.String("stack", string(debug.Stack())),
+			)
+
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(errors.New("internal server error")))
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+
+	"github.com/gourav224/student-api/internal/logging"
+)
+
+// RequestIDHeader carries the id that correlates a request with its logs.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds client-supplied request ids so they can't bloat logs.
+const maxRequestIDLength = 128
+
+// RequestLogger is middleware that stores a logger in the request context,
+// enriched with the request id, method, path and client IP (see RealIP), so
+// that every line logged through logging.FromContext while handling the
+// request can be correlated.
+//
+// The id is taken from an incoming X-Request-ID header when it is a sane
+// value (printable ASCII, at most 128 characters), e.g. one set by a proxy,
+// and generated otherwise. It is echoed in the X-Request-ID response header.
+func RequestLogger(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+
+		attrs := []any{
+			slog.String("request_id", id),
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		}
+		if ip := ClientIP(r.Context()); ip != "" {
+			attrs = append(attrs, slog.String("client_ip", ip))
+		}
+		logger := slog.Default().With(attrs...)
+		next.ServeHTTP(w, r.WithContext(logging.NewContext(r.Context(), logger)))
+	})
+}
+
+// validRequestID reports whether a client-supplied id is safe to log and echo.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns a random 128-bit id as 32 hex characters.
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gourav224/student-api/internal/logging"
+)
+
+// generatedRequestID matches an id made by newRequestID.
+var generatedRequestID = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+func TestRequestLoggerID(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{name: "none sent", incoming: "", keep: false},
+		{name: "client id kept", incoming: "abc-123", keep: true},
+		{name: "space rejected", incoming: "abc 123", keep: false},
+		{name: "non-ASCII rejected", incoming: "abcé", keep: false},
+		{name: "too long rejected", incoming: strings.Repeat("a", maxRequestIDLength+1), keep: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.incoming != "" {
+				req.Header.Set(RequestIDHeader, tt.incoming)
+			}
+			rec := httptest.NewRecorder()
+			RequestLogger(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(rec, req)
+
+			got := rec.Header().Get(RequestIDHeader)
+			switch {
+			case tt.keep && got != tt.incoming:
+				t.Errorf("%s = %q, want %q", RequestIDHeader, got, tt.incoming)
+			case !tt.keep && !generatedRequestID.MatchString(got):
+				t.Errorf("%s = %q, want a generated id", RequestIDHeader, got)
+			}
+		})
+	}
+}
+
+func TestRequestLoggerFields(t *testing.T) {
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+
+	h := RequestLogger(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logging.FromContext(r.Context()).Info("handled")
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/api/students", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	var line map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("invalid log line %q: %v", buf.String(), err)
+	}
+	want := map[string]any{"msg": "handled", "request_id": "abc-123", "method": "POST", "path": "/api/students"}
+	for k, v := range want {
+		if line[k] != v {
+			t.Errorf("log %s = %v, want %v", k, line[k], v)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gourav224/student-api/internal/utils/response"
+)
+
+// RouteErrors is middleware that replaces the plain-text 404 Not Found and
+// 405 Method Not Allowed responses of http.ServeMux (and http.NotFoundHandler
+// and http.FileServer) with the standard JSON error envelope, so every error
+// response has the same shape. The Allow header ServeMux sets on a 405 is
+// kept. JSON responses with these statuses, such as an unknown student id,
+// pass through unchanged.
+func RouteErrors(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(&routeErrorWriter{ResponseWriter: w, r: r}, r)
+	})
+}
+
+// routeErrorWriter swaps a plain-text 404 or 405 response for a JSON one.
+type routeErrorWriter struct {
+	http.ResponseWriter
+	r *http.Request
+
+	wroteHeader bool
+	replaced    bool
+}
+
+func (rw *routeErrorWriter) WriteHeader(status int) {
+	if rw.wroteHeader {
+		return
+	}
+	rw.wroteHeader = true
+
+	// http.Error, which ServeMux uses, sets a text/plain content type first
+	plain := strings.HasPrefix(rw.Header().Get("Content-Type"), "text/plain")
+	switch {
+	case plain && status == http.StatusNotFound:
+		rw.replace(status, fmt.Errorf("no resource at %s", rw.r.URL.Path))
+	case plain && status == http.StatusMethodNotAllowed:
+		rw.replace(status, fmt.Errorf("method %s is not allowed for %s (allowed: %s)", rw.r.Method, rw.r.URL.Path, rw.Header().Get("Allow")))
+	default:
+		rw.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (rw *routeErrorWriter) Write(b []byte) (int, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	if rw.replaced {
+		// Drop the plain-text body; the JSON one was already sent
+		return len(b), nil
+	}
+	return rw.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (rw *routeErrorWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+func (rw *routeErrorWriter) replace(status int, err error) {
+	rw.replaced = true
+	rw.Header().Del("X-Content-Type-Options")
+	response.WriteJson(rw.ResponseWriter, status, response.GeneralError(err))
+}
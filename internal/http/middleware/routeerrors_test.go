@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// routeMux serves GET and POST /students and GET /students/{id}, with JSON
+// 404s for missing students, behind RouteErrors.
+func routeMux() http.Handler {
+	mux := http.NewServeMux()
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	mux.HandleFunc("GET /students", ok)
+	mux.HandleFunc("POST /students", ok)
+	mux.HandleFunc("GET /students/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"status":"error","code":"NOT_FOUND","error":"student with id 9 not found"}`))
+	})
+	return RouteErrors(mux)
+}
+
+// routeError serves method and target with routeMux and decodes the JSON
+// error envelope of the response.
+func routeError(t *testing.T, method, target string) (*httptest.ResponseRecorder, map[string]any) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	routeMux().ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	var body map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON response %q: %v", rec.Body, err)
+	}
+	return rec, body
+}
+
+func TestRouteErrorsMethodNotAllowed(t *testing.T) {
+	rec, body := routeError(t, http.MethodDelete, "/students")
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("status = %d, want 405", rec.Code)
+	}
+	allow := rec.Header().Get("Allow")
+	for _, method := range []string{"GET", "HEAD", "POST"} {
+		if !strings.Contains(allow, method) {
+			t.Errorf("Allow = %q, want it to list %s", allow, method)
+		}
+	}
+	if body["status"] != "error" || body["code"] != "METHOD_NOT_ALLOWED" {
+		t.Errorf("body = %v, want the error envelope with METHOD_NOT_ALLOWED", body)
+	}
+	if msg, _ := body["error"].(string); !strings.Contains(msg, "DELETE") || !strings.Contains(msg, allow) {
+		t.Errorf("error = %q, want the method and the allowed ones", msg)
+	}
+}
+
+func TestRouteErrorsNotFound(t *testing.T) {
+	rec, body := routeError(t, http.MethodGet, "/teachers")
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404", rec.Code)
+	}
+	if body["status"] != "error" || body["code"] != "NOT_FOUND" || body["error"] != "no resource at /teachers" {
+		t.Errorf("body = %v, want the error envelope naming the path", body)
+	}
+	if rec.Header().Get("X-Content-Type-Options") != "" {
+		t.Error("X-Content-Type-Options of the plain-text error was kept")
+	}
+}
+
+func TestRouteErrorsKeepsJSONErrors(t *testing.T) {
+	rec, body := routeError(t, http.MethodGet, "/students/9")
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404", rec.Code)
+	}
+	if body["error"] != "student with id 9 not found" {
+		t.Errorf("body = %v, want the handler's own error", body)
+	}
+}
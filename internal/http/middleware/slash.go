@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+// StripTrailingSlash is middleware that removes trailing slashes from the
+// request path, so /api/students/ is served exactly like /api/students.
+// ServeMux treats the two as different routes, which confuses clients.
+//
+// The request is rewritten in place rather than redirected, so bodies of
+// POST, PATCH and DELETE requests are not lost. The root path "/" is kept.
+func StripTrailingSlash(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if p := r.URL.Path; len(p) > 1 && strings.HasSuffix(p, "/") {
+			r2 := r.Clone(r.Context())
+			r2.URL.Path = strings.TrimRight(p, "/")
+			if r2.URL.Path == "" {
+				r2.URL.Path = "/"
+			}
+			r2.URL.RawPath = strings.TrimRight(r.URL.RawPath, "/")
+			r = r2
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
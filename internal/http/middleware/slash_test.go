@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStripTrailingSlash(t *testing.T) {
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"/api/students", "/api/students"},
+		{"/api/students/", "/api/students"},
+		{"/api/students//", "/api/students"},
+		{"/api/students/1/", "/api/students/1"},
+		{"/api/students/?limit=2", "/api/students"},
+		{"/", "/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			var got, query string
+			h := StripTrailingSlash(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got, query = r.URL.Path, r.URL.RawQuery
+			}))
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.want {
+				t.Errorf("path = %q, want %q", got, tt.want)
+			}
+			if query != req.URL.RawQuery {
+				t.Errorf("query = %q, want %q kept", query, req.URL.RawQuery)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gourav224/student-api/internal/utils/response"
+)
+
+// Timeout returns middleware that gives every request a deadline of d.
+//
+// The deadline is set on the request context, which handlers pass down to
+// storage, so slow queries are cancelled when it expires. If the deadline has
+// passed by the time the handler responds with an error (or doesn't respond
+// at all), the client gets 503 Service Unavailable in the standard JSON
+// envelope instead. Unlike http.TimeoutHandler, responses are not buffered,
+// so streaming handlers keep working. A zero d disables the timeout.
+func Timeout(d time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		if d <= 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+
+			tw := &timeoutWriter{ResponseWriter: w, ctx: ctx}
+			next.ServeHTTP(tw, r.WithContext(ctx))
+
+			if !tw.wroteHeader && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				tw.writeTimeout()
+			}
+		})
+	}
+}
+
+// timeoutWriter swaps an error response for a 503 timeout response when
+// the request deadline has already expired.
+type timeoutWriter struct {
+	http.ResponseWriter
+	ctx context.Context
+
+	wroteHeader bool
+	timedOut    bool
+}
+
+func (tw *timeoutWriter) WriteHeader(status int) {
+	if tw.wroteHeader {
+		return
+	}
+	if status >= http.StatusInternalServerError && errors.Is(tw.ctx.Err(), context.DeadlineExceeded) {
+		tw.writeTimeout()
+		return
+	}
+	tw.wroteHeader = true
+	tw.ResponseWriter.WriteHeader(status)
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	if !tw.wroteHeader {
+		tw.WriteHeader(http.StatusOK)
+	}
+	if tw.timedOut {
+		// Drop the handler's own error body; the timeout response was already sent
+		return len(b), nil
+	}
+	return tw.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (tw *timeoutWriter) Unwrap() http.ResponseWriter {
+	return tw.ResponseWriter
+}
+
+func (tw *timeoutWriter) writeTimeout() {
+	tw.wroteHeader = true
+	tw.timedOut = true
+	response.WriteJson(tw.ResponseWriter, http.StatusServiceUnavailable, response.GeneralError(errors.New("request timed out")).WithCode(response.CodeTimeout))
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gourav224/student-api/internal/utils/response"
+)
+
+// slowHandler waits for its request context to end, as a blocked storage
+// call would, and then reports the context error as a 500.
+var slowHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	select {
+	case <-r.Context().Done():
+		response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(r.Context().Err()))
+	case <-time.After(5 * time.Second):
+		io.WriteString(w, "too late")
+	}
+})
+
+func TestTimeoutSlowHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	start := time.Now()
+	Timeout(20*time.Millisecond)(slowHandler).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("request took %s, want it cut short by the deadline", elapsed)
+	}
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", rec.Code)
+	}
+	var body map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("response is not a single JSON document %q: %v", rec.Body, err)
+	}
+	if body["status"] != "error" || body["error"] != "request timed out" {
+		t.Errorf("body = %v, want the timeout envelope", body)
+	}
+}
+
+func TestTimeoutSilentHandler(t *testing.T) {
+	// A handler that gives up without writing anything still gets a 503
+	h := Timeout(10 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503", rec.Code)
+	}
+}
+
+func TestTimeoutFastHandler(t *testing.T) {
+	var deadline time.Time
+	h := Timeout(time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deadline, _ = r.Context().Deadline()
+		w.WriteHeader(http.StatusCreated)
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusCreated {
+		t.Errorf("status = %d, want the handler's 201", rec.Code)
+	}
+	if until := time.Until(deadline); until <= 0 || until > time.Minute {
+		t.Errorf("handler saw a deadline %s away, want about a minute", until)
+	}
+}
+
+func TestTimeoutDisabled(t *testing.T) {
+	h := Timeout(0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); ok {
+			t.Error("a zero timeout set a deadline")
+		}
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ResponseTime is middleware that adds an X-Response-Time header, e.g.
+// "X-Response-Time: 12.345ms", measured from when the request reached this
+// middleware until the response headers were written.
+func ResponseTime(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tw := &timingWriter{ResponseWriter: w, start: time.Now()}
+		next.ServeHTTP(tw, r)
+
+		// Handlers that never write still get a header with the implicit 200
+		tw.stamp()
+	})
+}
+
+// timingWriter stamps X-Response-Time just before the headers are sent,
+// which is the last moment a header can still be added.
+type timingWriter struct {
+	http.ResponseWriter
+	start   time.Time
+	stamped bool
+}
+
+func (tw *timingWriter) stamp() {
+	if tw.stamped {
+		return
+	}
+	tw.stamped = true
+
+	ms := float64(time.Since(tw.start).Microseconds()) / 1000
+	tw.Header().Set("X-Response-Time", strconv.FormatFloat(ms, 'f', 3, 64)+"ms")
+}
+
+func (tw *timingWriter) WriteHeader(status int) {
+	tw.stamp()
+	tw.ResponseWriter.WriteHeader(status)
+}
+
+func (tw *timingWriter) Write(b []byte) (int, error) {
+	tw.stamp()
+	return tw.ResponseWriter.Write(b)
+}
+
+// Flush sends buffered data to the client, so streamed responses such as
+// the CSV export keep working behind this middleware.
+func (tw *timingWriter) Flush() {
+	tw.stamp()
+	if f, ok := tw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (tw *timingWriter) Unwrap() http.ResponseWriter {
+	return tw.ResponseWriter
+}
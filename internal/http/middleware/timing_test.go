@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+// responseTimeFormat matches an X-Response-Time value such as "1.234ms".
+var responseTimeFormat = regexp.MustCompile(`^\d+\.\d{3}ms$`)
+
+func TestResponseTime(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{"write", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("ok")) }},
+		{"write header", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) }},
+		{"flush", func(w http.ResponseWriter, r *http.Request) { w.(http.Flusher).Flush() }},
+		{"no write", func(w http.ResponseWriter, r *http.Request) {}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			ResponseTime(tt.handler).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if got := rec.Header().Get("X-Response-Time"); !responseTimeFormat.MatchString(got) {
+				t.Errorf("X-Response-Time = %q, want milliseconds like 1.234ms", got)
+			}
+		})
+	}
+}
+
+func TestResponseTimeKeepsGzipFlushing(t *testing.T) {
+	h := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		w.(http.Flusher).Flush()
+	}), ResponseTime, Gzip(DefaultGzipMinSize))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !rec.Flushed {
+		t.Error("flush did not reach the underlying writer")
+	}
+}
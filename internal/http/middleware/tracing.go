@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName identifies the spans started by Tracing.
+const tracerName = "github.com/gourav224/student-api/internal/http/middleware"
+
+// Tracing returns middleware that starts a server span for every request
+// using tp, continuing any trace context sent by the caller (see
+// otel.GetTextMapPropagator). Handlers and storage find the span in the
+// request context, so their spans become its children.
+//
+// The span is named after the method, and records the response status; 5xx
+// responses mark it as an error. Paths hold ids, so they would make too many
+// distinct span names; NameSpan adds the matched route instead.
+func Tracing(tp trace.TracerProvider) Middleware {
+	tracer := tp.Tracer(tracerName)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx, span := tracer.Start(ctx, r.Method,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					attribute.String("http.request.method", r.Method),
+					attribute.String("url.path", r.URL.Path),
+				),
+			)
+			defer span.End()
+
+			sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(sw, r.WithContext(ctx))
+
+			span.SetAttributes(attribute.Int("http.response.status_code", sw.status))
+			if sw.status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(sw.status))
+			}
+		})
+	}
+}
+
+// NameSpan returns a handler serving mux that renames the request span (see
+// Tracing) after the route mux matches, e.g. "GET /api/students/{id}", and
+// records it as http.route. prefix is the path mux is mounted under with
+// http.StripPrefix, if any. The span keeps its name when mux has no route for
+// the request; with nested muxes, the innermost match wins.
+func NameSpan(prefix string, mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if span := trace.SpanFromContext(r.Context()); span.IsRecording() {
+			if _, pattern := mux.Handler(r); pattern != "" {
+				// Patterns may start with a method, which r.Method also covers
+				_, path, ok := strings.Cut(pattern, " ")
+				if !ok {
+					path = pattern
+				}
+				route := prefix + path
+				span.SetName(r.Method + " " + route)
+				span.SetAttributes(attribute.String("http.route", route))
+			}
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// statusWriter records the status code of the response.
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (sw *statusWriter) WriteHeader(status int) {
+	if !sw.wroteHeader {
+		sw.wroteHeader = true
+		sw.status = status
+	}
+	sw.ResponseWriter.WriteHeader(status)
+}
+
+func (sw *statusWriter) Write(b []byte) (int, error) {
+	sw.wroteHeader = true
+	return sw.ResponseWriter.Write(b)
+}
+
+// Flush sends buffered data to the client, so streamed responses such as
+// the JSON Lines export keep working behind this middleware.
+func (sw *statusWriter) Flush() {
+	sw.wroteHeader = true
+	http.NewResponseController(sw.ResponseWriter).Flush()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (sw *statusWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator()) })
+
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	tests := []struct {
+		name       string
+		header     string
+		status     int
+		wantParent bool
+		wantError  bool
+	}{
+		{name: "new trace", status: http.StatusOK},
+		{name: "continued trace", header: traceparent, status: http.StatusOK, wantParent: true},
+		{name: "client error", status: http.StatusNotFound},
+		{name: "server error", status: http.StatusInternalServerError, wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+			var handlerSpan trace.SpanContext
+			h := Tracing(tp)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				handlerSpan = trace.SpanContextFromContext(r.Context())
+				w.WriteHeader(tt.status)
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/api/students/1", nil)
+			if tt.header != "" {
+				req.Header.Set("traceparent", tt.header)
+			}
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			spans := recorder.Ended()
+			if len(spans) != 1 {
+				t.Fatalf("got %d spans, want 1", len(spans))
+			}
+			span := spans[0]
+			if span.Name() != "GET" || span.SpanKind() != trace.SpanKindServer {
+				t.Errorf("span %q of kind %v, want a server span named after the method", span.Name(), span.SpanKind())
+			}
+			if handlerSpan.SpanID() != span.SpanContext().SpanID() {
+				t.Error("the handler's context doesn't carry the request span")
+			}
+			if got := span.Parent().IsRemote(); got != tt.wantParent {
+				t.Errorf("remote parent = %v, want %v", got, tt.wantParent)
+			}
+			if tt.wantParent && span.SpanContext().TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+				t.Errorf("trace id = %s, want the caller's", span.SpanContext().TraceID())
+			}
+			if got := span.Status().Code == codes.Error; got != tt.wantError {
+				t.Errorf("error status = %v, want %v", got, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestNameSpan(t *testing.T) {
+	api := http.NewServeMux()
+	api.HandleFunc("GET /students/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {})
+	mux.Handle("/api/", http.StripPrefix("/api", NameSpan("/api", api)))
+
+	tests := []struct {
+		method, path, name, route string
+	}{
+		{http.MethodGet, "/api/students/1", "GET /api/students/{id}", "/api/students/{id}"},
+		{http.MethodHead, "/api/students/2", "HEAD /api/students/{id}", "/api/students/{id}"},
+		{http.MethodGet, "/healthz", "GET /healthz", "/healthz"},
+		{http.MethodGet, "/api/nope", "GET /api/", "/api/"},
+		{http.MethodGet, "/nope", "GET", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+			h := Tracing(tp)(NameSpan("", mux))
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, nil))
+
+			span := recorder.Ended()[0]
+			var route string
+			for _, attr := range span.Attributes() {
+				if attr.Key == "http.route" {
+					route = attr.Value.AsString()
+				}
+			}
+			if span.Name() != tt.name || route != tt.route {
+				t.Errorf("span %q with route %q, want %q with %q", span.Name(), route, tt.name, tt.route)
+			}
+		})
+	}
+}
+
+func TestTracingKeepsFlushing(t *testing.T) {
+	tp := sdktrace.NewTracerProvider()
+	h := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		http.NewResponseController(w).Flush()
+	}), Tracing(tp), ResponseTime, Gzip(DefaultGzipMinSize))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !rec.Flushed {
+		t.Error("flush did not reach the underlying writer")
+	}
+}
@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gourav224/student-api/internal/utils/response"
+)
+
+// SupportedAPIVersions lists the API versions clients may request, oldest first.
+var SupportedAPIVersions = []int{1}
+
+// DefaultAPIVersion is used when a request doesn't ask for a version.
+const DefaultAPIVersion = 1
+
+// versionMediaPrefix and versionMediaSuffix bracket the version number in a
+// versioned media type, e.g. "application/vnd.studentapi.v1+json".
+const (
+	versionMediaPrefix = "application/vnd.studentapi.v"
+	versionMediaSuffix = "+json"
+)
+
+// apiVersionKey is the context key under which Versioning stores the version.
+type apiVersionKey struct{}
+
+// Versioning is middleware that negotiates the API version from the Accept
+// header, e.g. "Accept: application/vnd.studentapi.v1+json", and stores it in
+// the request context, where handlers read it with APIVersion to pick a
+// response shape. Responses to a request that named a version are labeled
+// with its media type by response.WriteJson.
+//
+// Requests that don't name a version (no Accept header, application/json,
+// */* and the like) get DefaultAPIVersion. If several versions are listed the
+// first supported one wins; if none of them is supported, the request is
+// rejected with 406 Not Acceptable.
+func Versioning(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The response depends on Accept, so caches must key on it
+		addVary(w.Header(), "Accept")
+
+		version, requested, err := negotiateVersion(r.Header.Values("Accept"))
+		if err != nil {
+			response.WriteJson(w, http.StatusNotAcceptable, response.GeneralError(err))
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), apiVersionKey{}, version)
+		if requested {
+			w = &versionWriter{ResponseWriter: w, mediaType: versionMediaType(version)}
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// APIVersion returns the version negotiated by Versioning, or
+// DefaultAPIVersion for requests that didn't pass through it.
+func APIVersion(ctx context.Context) int {
+	if v, ok := ctx.Value(apiVersionKey{}).(int); ok {
+		return v
+	}
+	return DefaultAPIVersion
+}
+
+// negotiateVersion picks the API version requested by the Accept header
+// values, and reports whether they named one at all.
+func negotiateVersion(accept []string) (int, bool, error) {
+	requested := false
+	for _, part := range strings.Split(strings.Join(accept, ","), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || !strings.HasPrefix(mediaType, versionMediaPrefix) || !strings.HasSuffix(mediaType, versionMediaSuffix) {
+			continue
+		}
+		requested = true
+
+		digits := strings.TrimSuffix(strings.TrimPrefix(mediaType, versionMediaPrefix), versionMediaSuffix)
+		if v, err := strconv.Atoi(digits); err == nil && slices.Contains(SupportedAPIVersions, v) {
+			return v, true, nil
+		}
+	}
+
+	if requested {
+		supported := make([]string, len(SupportedAPIVersions))
+		for i, v := range SupportedAPIVersions {
+			supported[i] = versionMediaType(v)
+		}
+		return 0, true, fmt.Errorf("unsupported API version; supported media types: %s", strings.Join(supported, ", "))
+	}
+	return DefaultAPIVersion, false, nil
+}
+
+// versionMediaType returns the media type of API version v.
+func versionMediaType(v int) string {
+	return fmt.Sprintf("%s%d%s", versionMediaPrefix, v, versionMediaSuffix)
+}
+
+// versionWriter carries the media type of the negotiated API version.
+type versionWriter struct {
+	http.ResponseWriter
+	mediaType string
+}
+
+// APIMediaType is checked by response.WriteJson.
+func (vw *versionWriter) APIMediaType() string {
+	return vw.mediaType
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (vw *versionWriter) Unwrap() http.ResponseWriter {
+	return vw.ResponseWriter
+}
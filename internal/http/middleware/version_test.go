@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gourav224/student-api/internal/utils/response"
+)
+
+// versionedEcho answers with the API version Versioning negotiated.
+var versionedEcho = Versioning(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	response.WriteJson(w, http.StatusOK, map[string]any{"status": "success", "data": APIVersion(r.Context())})
+}))
+
+func TestVersioningSupported(t *testing.T) {
+	tests := []struct {
+		name        string
+		accept      []string
+		contentType string
+	}{
+		{"no accept", nil, "application/json"},
+		{"plain json", []string{"application/json"}, "application/json"},
+		{"wildcard", []string{"*/*"}, "application/json"},
+		{"v1", []string{"application/vnd.studentapi.v1+json"}, "application/vnd.studentapi.v1+json"},
+		{"v1 with params", []string{"application/vnd.studentapi.v1+json; q=0.9"}, "application/vnd.studentapi.v1+json"},
+		{"first supported wins", []string{"application/vnd.studentapi.v9+json, application/vnd.studentapi.v1+json"}, "application/vnd.studentapi.v1+json"},
+		{"split headers", []string{"application/vnd.studentapi.v9+json", "application/vnd.studentapi.v1+json"}, "application/vnd.studentapi.v1+json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/students", nil)
+			for _, a := range tt.accept {
+				req.Header.Add("Accept", a)
+			}
+			rec := httptest.NewRecorder()
+			versionedEcho.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200; body %s", rec.Code, rec.Body)
+			}
+			if got := rec.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.contentType)
+			}
+			if got := rec.Header().Get("Vary"); got != "Accept" {
+				t.Errorf("Vary = %q, want Accept", got)
+			}
+			if want := `{"data":1,"status":"success"}` + "\n"; rec.Body.String() != want {
+				t.Errorf("body = %q, want %q", rec.Body, want)
+			}
+		})
+	}
+}
+
+func TestVersioningUnsupported(t *testing.T) {
+	tests := []struct {
+		name   string
+		accept string
+	}{
+		{"unknown version", "application/vnd.studentapi.v2+json"},
+		{"version zero", "application/vnd.studentapi.v0+json"},
+		{"not a number", "application/vnd.studentapi.vx+json"},
+		{"only unsupported", "application/vnd.studentapi.v2+json, application/vnd.studentapi.v3+json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/students", nil)
+			req.Header.Set("Accept", tt.accept)
+			rec := httptest.NewRecorder()
+			versionedEcho.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusNotAcceptable {
+				t.Fatalf("status = %d, want 406", rec.Code)
+			}
+			if got := rec.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", got)
+			}
+		})
+	}
+}
+
+func TestAPIVersionDefault(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if got := APIVersion(req.Context()); got != DefaultAPIVersion {
+		t.Errorf("APIVersion = %d, want %d", got, DefaultAPIVersion)
+	}
+}
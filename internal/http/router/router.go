@@ -0,0 +1,90 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gourav224/student-api/internal/avatar"
+	"github.com/gourav224/student-api/internal/config"
+	"github.com/gourav224/student-api/internal/http/handlers/admin"
+	"github.com/gourav224/student-api/internal/http/handlers/health"
+	"github.com/gourav224/student-api/internal/http/handlers/student"
+	"github.com/gourav224/student-api/internal/http/middleware"
+	"github.com/gourav224/student-api/internal/idempotency"
+	"github.com/gourav224/student-api/internal/storage"
+	"github.com/gourav224/student-api/internal/version"
+)
+
+// New builds the application's routes on top of store.
+//
+// It registers every endpoint with its per-route middleware, but none of the
+// global middleware, which the caller applies around the result. Keeping the
+// wiring here lets the server and an httptest.Server exercise the same routes.
+// Routes are built from the active configuration of live; the reloadable
+// settings among them are read from live on every request. Request spans
+// are named after the matched routes (see middleware.NameSpan).
+func New(live *config.Live, store storage.Storage, idempotencyKeys *idempotency.Store, avatars *avatar.Store) http.Handler {
+	cfg := live.Config()
+
+	// Debug logging of JSON bodies, with emails redacted, never runs in prod
+	logBody := middleware.LogBody(cfg.Env != "prod")
+
+	// API routes are registered relative to the configured prefix
+	api := http.NewServeMux()
+	api.Handle("POST /students", middleware.Chain(student.New(store, cfg.APIPrefix), middleware.RequireJSON, logBody, middleware.Idempotency(idempotencyKeys)))
+	api.Handle("POST /students/bulk", middleware.Chain(student.BulkCreate(store), middleware.RequireJSON, logBody))
+	api.HandleFunc("POST /students/import", student.ImportCSV(store))
+	api.HandleFunc("GET /students", student.GetList(store, cfg.APIPrefix, cfg.MaxPageSize))
+	api.HandleFunc("GET /students/search", student.Search(store))
+	api.HandleFunc("GET /students/export", student.Export(store))
+	api.HandleFunc("GET /students/stats/age", student.AgeStats(store))
+	api.HandleFunc("GET /students/{id}", student.GetById(store))
+	api.Handle("PATCH /students/{id}", middleware.Chain(student.UpdateById(store), middleware.RequireJSON, logBody))
+	api.Handle("PATCH /students", middleware.Chain(student.UpdateWhere(store), middleware.AdminAuth(cfg.AdminToken), middleware.RequireJSON, logBody))
+	api.Handle("POST /students/age/increment", middleware.Chain(student.IncrementAges(store), middleware.AdminAuth(cfg.AdminToken)))
+	api.HandleFunc("DELETE /students/{id}", student.DeleteById(store, avatars))
+	api.HandleFunc("POST /students/{id}/restore", student.Restore(store))
+	api.HandleFunc("POST /students/{id}/avatar", student.UploadAvatar(store, avatars))
+	api.Handle("GET /admin/db/stats", middleware.Chain(admin.DBStats(store), middleware.AdminAuth(cfg.AdminToken)))
+
+	// Batch delete shares its route with the dataset reset, which is for test
+	// environments only and always requires the admin token
+	var deleteAll http.Handler
+	if cfg.Env != "prod" {
+		deleteAll = middleware.Chain(student.DeleteAll(store, avatars), middleware.AdminAuth(cfg.AdminToken))
+	}
+	api.HandleFunc("DELETE /students", student.DeleteMany(store, avatars, deleteAll))
+
+	// Seeding sample data is likewise kept out of prod entirely
+	if cfg.Env != "prod" {
+		api.Handle("POST /seed", middleware.Chain(student.Seed(store), middleware.AdminAuth(cfg.AdminToken)))
+	}
+
+	// Probes and build info stay at the root so they are reachable regardless of the prefix
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", health.Live())
+	mux.HandleFunc("GET /readyz", health.Ready(store))
+	mux.HandleFunc("GET /version", version.Handler())
+	// Only API requests count against the rate and concurrency limits, so
+	// probes still answer when the instance is saturated
+	rateLimit := func() (float64, int) {
+		server := live.Config().HTTPServer
+		return server.RateLimit, server.RateLimitBurst
+	}
+	mux.Handle(cfg.APIPrefix+"/", http.StripPrefix(cfg.APIPrefix, middleware.Chain(middleware.NameSpan(cfg.APIPrefix, api),
+		middleware.RateLimit(rateLimit),
+		middleware.ConcurrencyLimit(func() int { return live.Config().HTTPServer.MaxConcurrentRequests }),
+		middleware.Versioning,
+	)))
+	mux.Handle("GET "+avatars.URLPrefix(), avatars.Handler())
+
+	// ServeMux would redirect the bare subtree roots to their "/" form, which
+	// the global StripTrailingSlash middleware undoes, looping forever.
+	// Nothing is served there, so answer them with 404 instead.
+	if cfg.APIPrefix != "" {
+		mux.Handle(cfg.APIPrefix, http.NotFoundHandler())
+	}
+	mux.Handle("GET "+strings.TrimSuffix(avatars.URLPrefix(), "/"), http.NotFoundHandler())
+
+	return middleware.NameSpan("", mux)
+}
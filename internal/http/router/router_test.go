@@ -0,0 +1,832 @@
+package router_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gourav224/student-api/internal/avatar"
+	"github.com/gourav224/student-api/internal/config"
+	"github.com/gourav224/student-api/internal/http/middleware"
+	"github.com/gourav224/student-api/internal/http/router"
+	"github.com/gourav224/student-api/internal/idempotency"
+	"github.com/gourav224/student-api/internal/storage"
+	_ "github.com/gourav224/student-api/internal/storage/sqlite"
+)
+
+const adminToken = "test-admin-token"
+
+// newServer starts the application's routes on a fresh SQLite database in a
+// temporary directory, configured through a config file like the real server.
+// Avatars of at most 1 KiB are stored in the same directory. Everything is
+// torn down when t ends. The routes are wrapped in mws, for tests that depend
+// on part of the server's global middleware.
+func newServer(t *testing.T, mws ...middleware.Middleware) *httptest.Server {
+	t.Helper()
+	return newServerEnv(t, "dev", mws...)
+}
+
+// newServerEnv is newServer for the environment env.
+func newServerEnv(t *testing.T, env string, mws ...middleware.Middleware) *httptest.Server {
+	t.Helper()
+	dir := t.TempDir()
+
+	configPath := filepath.Join(dir, "config.yaml")
+	configFile := fmt.Sprintf("env: %s\nstorage_path: %s\nadmin_token: %s\n", env, filepath.Join(dir, "students.db"), adminToken)
+	if err := os.WriteFile(configPath, []byte(configFile), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		t.Fatalf("config.Load: %v", err)
+	}
+
+	store, err := storage.New(cfg)
+	if err != nil {
+		t.Fatalf("storage.New: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	avatars, err := avatar.New(filepath.Join(dir, "avatars"), cfg.APIPrefix+"/avatars/", 1<<10)
+	if err != nil {
+		t.Fatalf("avatar.New: %v", err)
+	}
+
+	mux := router.New(config.NewLive(cfg), store, idempotency.New(time.Hour), avatars)
+	srv := httptest.NewServer(middleware.Chain(mux, mws...))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// apiCase is a request against the test server and the response it expects.
+type apiCase struct {
+	name   string
+	method string
+	path   string
+	body   string
+	header map[string]string
+	status int
+	// check, if set, inspects the decoded response body.
+	check func(t *testing.T, body map[string]any)
+}
+
+// run performs the request of c against srv and checks the response.
+func (c apiCase) run(t *testing.T, srv *httptest.Server) {
+	t.Helper()
+	var body io.Reader
+	if c.body != "" {
+		body = strings.NewReader(c.body)
+	}
+	req, err := http.NewRequest(c.method, srv.URL+c.path, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range c.header {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != c.status {
+		t.Fatalf("%s %s: status = %d, want %d; body %s", c.method, c.path, resp.StatusCode, c.status, raw)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		t.Fatalf("%s %s: invalid JSON response %q: %v", c.method, c.path, raw, err)
+	}
+	// Errors use the envelope, or the "errors" array of JSON:API
+	if c.status >= 400 && decoded["status"] != "error" && decoded["errors"] == nil {
+		t.Errorf("%s %s: body = %v, want an error", c.method, c.path, decoded)
+	}
+	if c.check != nil {
+		c.check(t, decoded)
+	}
+}
+
+// runCases runs each case as a subtest, in order, against srv.
+func runCases(t *testing.T, srv *httptest.Server, cases []apiCase) {
+	t.Helper()
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			c.run(t, srv)
+		})
+	}
+}
+
+// createStudent adds a student through the API and returns its id.
+func createStudent(t *testing.T, srv *httptest.Server, name, email string, age int) int64 {
+	t.Helper()
+	var id int64
+	apiCase{
+		method: http.MethodPost,
+		path:   "/api/students",
+		body:   fmt.Sprintf(`{"name":%q,"email":%q,"age":%d}`, name, email, age),
+		status: http.StatusCreated,
+		check: func(t *testing.T, body map[string]any) {
+			id = int64(data(t, body)["id"].(float64))
+		},
+	}.run(t, srv)
+	return id
+}
+
+// data returns the "data" object of a success envelope.
+func data(t *testing.T, body map[string]any) map[string]any {
+	t.Helper()
+	d, ok := body["data"].(map[string]any)
+	if !ok {
+		t.Fatalf("response data = %v, want an object", body["data"])
+	}
+	return d
+}
+
+// field returns a check that the "data" object has value under key.
+func field(key string, value any) func(*testing.T, map[string]any) {
+	return func(t *testing.T, body map[string]any) {
+		t.Helper()
+		if got := data(t, body)[key]; got != value {
+			t.Errorf("data.%s = %v, want %v", key, got, value)
+		}
+	}
+}
+
+// errorResponse returns a check that the body is an error with the given code
+// and message.
+func errorResponse(code, message string) func(*testing.T, map[string]any) {
+	return func(t *testing.T, body map[string]any) {
+		t.Helper()
+		if body["code"] != code || body["error"] != message {
+			t.Errorf("code, error = %v, %q; want %s, %q", body["code"], body["error"], code, message)
+		}
+	}
+}
+
+func TestCreateStudent(t *testing.T) {
+	srv := newServer(t)
+
+	runCases(t, srv, []apiCase{
+		{name: "valid", method: http.MethodPost, path: "/api/students", body: `{"name":"Jane Doe","email":"jane@example.com","age":20}`,
+			status: http.StatusCreated, check: field("email", "jane@example.com")},
+		{name: "missing name", method: http.MethodPost, path: "/api/students", body: `{"email":"x@example.com","age":20}`,
+			status: http.StatusBadRequest},
+		{name: "invalid email", method: http.MethodPost, path: "/api/students", body: `{"name":"X","email":"nope","age":20}`,
+			status: http.StatusBadRequest},
+		{name: "age out of range", method: http.MethodPost, path: "/api/students", body: `{"name":"X","email":"x@example.com","age":121}`,
+			status: http.StatusBadRequest},
+		{name: "unknown field", method: http.MethodPost, path: "/api/students", body: `{"name":"X","email":"x@example.com","age":20,"grade":1}`,
+			status: http.StatusBadRequest},
+		{name: "malformed JSON", method: http.MethodPost, path: "/api/students", body: `{"name":`,
+			status: http.StatusBadRequest},
+		{name: "wrong type", method: http.MethodPost, path: "/api/students", body: `{"name":"X","email":"x@example.com","age":"20"}`,
+			status: http.StatusBadRequest},
+		{name: "empty body", method: http.MethodPost, path: "/api/students", header: map[string]string{"Content-Type": "application/json"},
+			status: http.StatusBadRequest},
+		{name: "plain text", method: http.MethodPost, path: "/api/students", body: `{"name":"X","email":"x@example.com","age":20}`,
+			header: map[string]string{"Content-Type": "text/plain"}, status: http.StatusUnsupportedMediaType},
+		{name: "duplicate email", method: http.MethodPost, path: "/api/students", body: `{"name":"Jane Roe","email":"jane@example.com","age":22}`,
+			status: http.StatusConflict},
+	})
+}
+
+func TestGetStudent(t *testing.T) {
+	srv := newServer(t)
+	id := createStudent(t, srv, "Jane Doe", "jane@example.com", 20)
+
+	runCases(t, srv, []apiCase{
+		{name: "existing", method: http.MethodGet, path: fmt.Sprintf("/api/students/%d", id),
+			status: http.StatusOK, check: field("name", "Jane Doe")},
+		{name: "missing", method: http.MethodGet, path: "/api/students/999",
+			status: http.StatusNotFound},
+		{name: "non-numeric id", method: http.MethodGet, path: "/api/students/abc",
+			status: http.StatusBadRequest},
+	})
+}
+
+func TestListStudents(t *testing.T) {
+	srv := newServer(t)
+	for i := range 3 {
+		createStudent(t, srv, "Student", fmt.Sprintf("s%d@example.com", i), 20+i)
+	}
+
+	// count checks the number of students returned.
+	count := func(n int) func(*testing.T, map[string]any) {
+		return func(t *testing.T, body map[string]any) {
+			t.Helper()
+			if got := len(body["data"].([]any)); got != n {
+				t.Errorf("got %d students, want %d", got, n)
+			}
+		}
+	}
+
+	runCases(t, srv, []apiCase{
+		{name: "all", method: http.MethodGet, path: "/api/students", status: http.StatusOK, check: count(3)},
+		{name: "limit", method: http.MethodGet, path: "/api/students?limit=2", status: http.StatusOK, check: count(2)},
+		{name: "after cursor", method: http.MethodGet, path: "/api/students?after_id=2", status: http.StatusOK, check: count(1)},
+		{name: "fields", method: http.MethodGet, path: "/api/students?fields=id,name", status: http.StatusOK, check: func(t *testing.T, body map[string]any) {
+			first := body["data"].([]any)[0].(map[string]any)
+			if len(first) != 2 || first["name"] != "Student" {
+				t.Errorf("projected student = %v, want only id and name", first)
+			}
+		}},
+		{name: "invalid limit", method: http.MethodGet, path: "/api/students?limit=-1", status: http.StatusBadRequest},
+		{name: "unknown field", method: http.MethodGet, path: "/api/students?fields=password", status: http.StatusBadRequest},
+	})
+}
+
+func TestUpdateStudent(t *testing.T) {
+	srv := newServer(t)
+	id := createStudent(t, srv, "Jane Doe", "jane@example.com", 20)
+	createStudent(t, srv, "John Doe", "john@example.com", 21)
+	path := fmt.Sprintf("/api/students/%d", id)
+
+	runCases(t, srv, []apiCase{
+		{name: "age", method: http.MethodPatch, path: path, body: `{"age":21}`,
+			status: http.StatusOK, check: field("age", float64(21))},
+		{name: "duplicate email", method: http.MethodPatch, path: path, body: `{"email":"john@example.com"}`,
+			status: http.StatusConflict},
+		{name: "no fields", method: http.MethodPatch, path: path, body: `{}`,
+			status: http.StatusBadRequest},
+		{name: "invalid age", method: http.MethodPatch, path: path, body: `{"age":0}`,
+			status: http.StatusBadRequest},
+		{name: "null name", method: http.MethodPatch, path: path, body: `{"name":null}`,
+			status: http.StatusBadRequest},
+		{name: "missing", method: http.MethodPatch, path: "/api/students/999", body: `{"age":30}`,
+			status: http.StatusNotFound},
+		{name: "non-numeric id", method: http.MethodPatch, path: "/api/students/abc", body: `{"age":30}`,
+			status: http.StatusBadRequest},
+	})
+}
+
+func TestDeleteStudent(t *testing.T) {
+	srv := newServer(t)
+	id := createStudent(t, srv, "Jane Doe", "jane@example.com", 20)
+
+	runCases(t, srv, []apiCase{
+		{name: "existing", method: http.MethodDelete, path: fmt.Sprintf("/api/students/%d", id),
+			status: http.StatusOK, check: func(t *testing.T, body map[string]any) {
+				if body["data"] != float64(1) {
+					t.Errorf("data = %v, want 1 row deleted", body["data"])
+				}
+			}},
+		{name: "already deleted", method: http.MethodDelete, path: fmt.Sprintf("/api/students/%d", id),
+			status: http.StatusNotFound},
+		{name: "non-numeric id", method: http.MethodDelete, path: "/api/students/abc",
+			status: http.StatusBadRequest},
+	})
+}
+
+func TestDeleteManyStudents(t *testing.T) {
+	srv := newServer(t)
+	first := createStudent(t, srv, "Jane Doe", "jane@example.com", 20)
+	second := createStudent(t, srv, "John Doe", "john@example.com", 21)
+	createStudent(t, srv, "Jim Doe", "jim@example.com", 22)
+
+	// deleted checks how many rows the batch delete reports.
+	deleted := func(n int) func(*testing.T, map[string]any) {
+		return func(t *testing.T, body map[string]any) {
+			t.Helper()
+			if body["data"] != float64(n) {
+				t.Errorf("data = %v, want %d rows deleted", body["data"], n)
+			}
+		}
+	}
+
+	runCases(t, srv, []apiCase{
+		{name: "invalid id", method: http.MethodDelete, path: fmt.Sprintf("/api/students?ids=%d,abc", first),
+			status: http.StatusBadRequest},
+		{name: "non-positive id", method: http.MethodDelete, path: "/api/students?ids=0",
+			status: http.StatusBadRequest},
+		{name: "empty list", method: http.MethodDelete, path: "/api/students?ids=",
+			status: http.StatusBadRequest},
+		{name: "existing and unknown", method: http.MethodDelete, path: fmt.Sprintf("/api/students?ids=%d,%d,999", first, second),
+			status: http.StatusOK, check: deleted(2)},
+		{name: "already deleted", method: http.MethodDelete, path: fmt.Sprintf("/api/students?ids=%d", first),
+			status: http.StatusOK, check: deleted(0)},
+		{name: "reset still needs the admin token", method: http.MethodDelete, path: "/api/students",
+			status: http.StatusUnauthorized},
+		// The reset also purges the two soft-deleted students
+		{name: "reset", method: http.MethodDelete, path: "/api/students", header: map[string]string{"Authorization": "Bearer " + adminToken},
+			status: http.StatusOK, check: deleted(3)},
+	})
+}
+
+func TestRestoreStudent(t *testing.T) {
+	srv := newServer(t)
+	id := createStudent(t, srv, "Jane Doe", "jane@example.com", 20)
+	path := fmt.Sprintf("/api/students/%d", id)
+
+	// listed checks the number of students in the list.
+	listed := func(n int) func(*testing.T, map[string]any) {
+		return func(t *testing.T, body map[string]any) {
+			t.Helper()
+			if students, _ := body["data"].([]any); len(students) != n {
+				t.Errorf("data = %v, want %d students listed", body["data"], n)
+			}
+		}
+	}
+
+	runCases(t, srv, []apiCase{
+		{name: "not deleted", method: http.MethodPost, path: path + "/restore",
+			status: http.StatusNotFound},
+		{name: "delete", method: http.MethodDelete, path: path,
+			status: http.StatusOK},
+		{name: "hidden once deleted", method: http.MethodGet, path: "/api/students",
+			status: http.StatusOK, check: listed(0)},
+		{name: "restore", method: http.MethodPost, path: path + "/restore",
+			status: http.StatusOK, check: field("email", "jane@example.com")},
+		{name: "fetch after restore", method: http.MethodGet, path: path,
+			status: http.StatusOK, check: field("email", "jane@example.com")},
+		{name: "listed after restore", method: http.MethodGet, path: "/api/students",
+			status: http.StatusOK, check: listed(1)},
+		{name: "restore again", method: http.MethodPost, path: path + "/restore",
+			status: http.StatusNotFound},
+		{name: "delete again", method: http.MethodDelete, path: path,
+			status: http.StatusOK},
+		{name: "email freed", method: http.MethodPost, path: "/api/students",
+			body: `{"name":"Jane Again","email":"jane@example.com","age":20}`, status: http.StatusCreated},
+		{name: "email taken since", method: http.MethodPost, path: path + "/restore",
+			status: http.StatusConflict, check: errorResponse("DUPLICATE_EMAIL", "a student with this email already exists")},
+	})
+}
+
+func TestETagPerRepresentation(t *testing.T) {
+	srv := newServer(t, middleware.Gzip(64), middleware.PrettyJSON(false), middleware.RawResponse(false), middleware.JSONAPI("students"))
+	id := createStudent(t, srv, "Jane Doe", "jane@example.com", 20)
+	path := fmt.Sprintf("/api/students/%d", id)
+
+	// get fetches the student; an explicit Accept-Encoding keeps the client
+	// from negotiating gzip on its own
+	get := func(query string, header map[string]string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodGet, srv.URL+path+query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Accept-Encoding", "identity")
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	representations := []struct {
+		name, query string
+		header      map[string]string
+		etag        string
+	}{
+		{"plain", "", nil, `"1"`},
+		{"raw", "?raw=true", nil, `"1-raw"`},
+		{"pretty", "?pretty=true", nil, `"1-pretty"`},
+		{"versioned", "", map[string]string{"Accept": "application/vnd.studentapi.v1+json"}, `"1-v1"`},
+		{"jsonapi", "", map[string]string{"Accept": "application/vnd.api+json"}, `"1-jsonapi"`},
+		{"gzip", "", map[string]string{"Accept-Encoding": "gzip"}, `"1-gzip"`},
+	}
+	for _, rep := range representations {
+		t.Run(rep.name, func(t *testing.T) {
+			resp := get(rep.query, rep.header)
+			if got := resp.Header.Get("ETag"); resp.StatusCode != http.StatusOK || got != rep.etag {
+				t.Fatalf("GET = %d with ETag %q, want 200 with %q", resp.StatusCode, got, rep.etag)
+			}
+
+			// Each tag only revalidates its own representation
+			header := map[string]string{"If-None-Match": rep.etag}
+			for k, v := range rep.header {
+				header[k] = v
+			}
+			if resp := get(rep.query, header); resp.StatusCode != http.StatusNotModified || resp.Header.Get("ETag") != rep.etag {
+				t.Errorf("revalidation = %d with ETag %q, want 304 with %q", resp.StatusCode, resp.Header.Get("ETag"), rep.etag)
+			}
+			if rep.etag != `"1"` {
+				if resp := get("", map[string]string{"If-None-Match": rep.etag}); resp.StatusCode != http.StatusOK {
+					t.Errorf("plain JSON revalidated with %s: status = %d, want 200", rep.etag, resp.StatusCode)
+				}
+			}
+		})
+	}
+
+	// Any representation's tag names the version for If-Match
+	apiCase{method: http.MethodPatch, path: path, body: `{"age":21}`, header: map[string]string{"If-Match": `"1-jsonapi"`},
+		status: http.StatusOK, check: field("version", 2.0)}.run(t, srv)
+	apiCase{method: http.MethodPatch, path: path, body: `{"age":22}`, header: map[string]string{"If-Match": `"1-gzip"`},
+		status: http.StatusConflict}.run(t, srv)
+}
+
+func TestIdempotentCreate(t *testing.T) {
+	srv := newServer(t, middleware.RequestLogger)
+	const body = `{"name":"Jane Doe","email":"jane@example.com","age":20}`
+
+	// post sends a create with the given Idempotency-Key and returns the
+	// response with its body read.
+	post := func(key, body string) (*http.Response, string) {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPost, srv.URL+"/api/students", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", key)
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		raw, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp, string(raw)
+	}
+
+	first, firstBody := post("create-jane", body)
+	if first.StatusCode != http.StatusCreated {
+		t.Fatalf("first create: status = %d, want 201; body %s", first.StatusCode, firstBody)
+	}
+
+	// The retry gets the recorded response, but its own request id
+	retry, retryBody := post("create-jane", body)
+	if retry.StatusCode != http.StatusCreated || retryBody != firstBody {
+		t.Errorf("retry = %d %s, want the first response %d %s", retry.StatusCode, retryBody, first.StatusCode, firstBody)
+	}
+	if got, want := retry.Header.Get("Location"), first.Header.Get("Location"); got != want || got == "" {
+		t.Errorf("retry Location = %q, want %q", got, want)
+	}
+	if got := retry.Header.Get("Idempotent-Replayed"); got != "true" {
+		t.Errorf("Idempotent-Replayed = %q, want true", got)
+	}
+	if got := retry.Header.Get("X-Request-ID"); got == "" || got == first.Header.Get("X-Request-ID") {
+		t.Errorf("retry X-Request-ID = %q, want a new one, not the first request's", got)
+	}
+
+	runCases(t, srv, []apiCase{
+		{name: "key reused with another body", method: http.MethodPost, path: "/api/students",
+			body:   `{"name":"John Doe","email":"john@example.com","age":21}`,
+			header: map[string]string{"Content-Type": "application/json", "Idempotency-Key": "create-jane"},
+			status: http.StatusUnprocessableEntity,
+			check:  errorResponse("IDEMPOTENCY_KEY_REUSED", "Idempotency-Key was already used with a different request")},
+		{name: "created once", method: http.MethodGet, path: "/api/students", status: http.StatusOK,
+			check: func(t *testing.T, body map[string]any) {
+				if total := body["meta"].(map[string]any)["total"]; total != 1.0 {
+					t.Errorf("meta.total = %v, want 1 student", total)
+				}
+			}},
+	})
+}
+
+func TestUpdateStudentsByFilter(t *testing.T) {
+	srv := newServer(t)
+	for i, age := range []int{15, 16, 17, 18, 30} {
+		createStudent(t, srv, "Student", fmt.Sprintf("s%d@example.com", i), age)
+	}
+	admin := map[string]string{"Authorization": "Bearer " + adminToken}
+
+	// updated checks how many rows the filtered update reports.
+	updated := func(n int) func(*testing.T, map[string]any) {
+		return func(t *testing.T, body map[string]any) {
+			t.Helper()
+			if body["data"] != float64(n) {
+				t.Errorf("data = %v, want %d rows updated", body["data"], n)
+			}
+		}
+	}
+
+	runCases(t, srv, []apiCase{
+		{name: "without token", method: http.MethodPatch, path: "/api/students?max_age=17&confirm=true", body: `{"age":18}`,
+			status: http.StatusUnauthorized},
+		{name: "without confirm", method: http.MethodPatch, path: "/api/students?max_age=17", body: `{"age":18}`, header: admin,
+			status: http.StatusBadRequest},
+		{name: "without filter", method: http.MethodPatch, path: "/api/students?confirm=true", body: `{"age":18}`, header: admin,
+			status: http.StatusBadRequest},
+		{name: "inverted range", method: http.MethodPatch, path: "/api/students?min_age=20&max_age=10&confirm=true", body: `{"age":18}`, header: admin,
+			status: http.StatusBadRequest},
+		{name: "invalid bound", method: http.MethodPatch, path: "/api/students?min_age=young&confirm=true", body: `{"age":18}`, header: admin,
+			status: http.StatusBadRequest},
+		{name: "email", method: http.MethodPatch, path: "/api/students?max_age=17&confirm=true", body: `{"email":"same@example.com"}`, header: admin,
+			status: http.StatusBadRequest},
+		{name: "no fields", method: http.MethodPatch, path: "/api/students?max_age=17&confirm=true", body: `{}`, header: admin,
+			status: http.StatusBadRequest},
+		{name: "invalid age", method: http.MethodPatch, path: "/api/students?max_age=17&confirm=true", body: `{"age":0}`, header: admin,
+			status: http.StatusBadRequest},
+		{name: "minors", method: http.MethodPatch, path: "/api/students?min_age=0&max_age=17&confirm=true", body: `{"age":18}`, header: admin,
+			status: http.StatusOK, check: updated(3)},
+		{name: "now adults", method: http.MethodPatch, path: "/api/students?min_age=18&max_age=18&confirm=true", body: `{"name":"Adult"}`, header: admin,
+			status: http.StatusOK, check: updated(4)},
+		{name: "nobody left", method: http.MethodPatch, path: "/api/students?max_age=17&confirm=true", body: `{"age":18}`, header: admin,
+			status: http.StatusOK, check: updated(0)},
+	})
+}
+
+// pngHeader is enough of a PNG file for content sniffing to accept it.
+const pngHeader = "\x89PNG\r\n\x1a\n"
+
+// uploadAvatar posts content as the "avatar" form field for student id and
+// returns the response status and decoded body.
+func uploadAvatar(t *testing.T, srv *httptest.Server, id int64, content string) (int, map[string]any) {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	part, err := mw.CreateFormFile("avatar", "avatar.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.WriteString(part, content)
+	mw.Close()
+
+	resp, err := srv.Client().Post(fmt.Sprintf("%s/api/students/%d/avatar", srv.URL, id), mw.FormDataContentType(), &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var body map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("invalid JSON response: %v", err)
+	}
+	return resp.StatusCode, body
+}
+
+func TestUploadAvatar(t *testing.T) {
+	srv := newServer(t)
+	id := createStudent(t, srv, "Jane Doe", "jane@example.com", 20)
+
+	tests := []struct {
+		name    string
+		id      int64
+		content string
+		status  int
+	}{
+		{name: "not an image", id: id, content: "hello", status: http.StatusUnsupportedMediaType},
+		{name: "too large", id: id, content: pngHeader + strings.Repeat("x", 1<<10), status: http.StatusRequestEntityTooLarge},
+		{name: "missing student", id: 999, content: pngHeader, status: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status, body := uploadAvatar(t, srv, tt.id, tt.content); status != tt.status {
+				t.Errorf("status = %d, want %d; body %v", status, tt.status, body)
+			}
+		})
+	}
+
+	status, body := uploadAvatar(t, srv, id, pngHeader)
+	if status != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body %v", status, body)
+	}
+	url, _ := data(t, body)["avatar_url"].(string)
+	if !strings.HasPrefix(url, "/api/avatars/") {
+		t.Fatalf("avatar_url = %q, want a URL under /api/avatars/", url)
+	}
+
+	apiCase{method: http.MethodGet, path: fmt.Sprintf("/api/students/%d", id),
+		status: http.StatusOK, check: field("avatar_url", url)}.run(t, srv)
+
+	// fetch returns the status of a GET for the avatar file.
+	fetch := func() int {
+		resp, err := srv.Client().Get(srv.URL + url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if got := fetch(); got != http.StatusOK {
+		t.Fatalf("GET %s: status = %d, want 200", url, got)
+	}
+
+	apiCase{method: http.MethodDelete, path: fmt.Sprintf("/api/students/%d", id), status: http.StatusOK}.run(t, srv)
+	if got := fetch(); got != http.StatusNotFound {
+		t.Errorf("GET %s after deleting the student: status = %d, want 404", url, got)
+	}
+}
+
+func TestHead(t *testing.T) {
+	srv := newServer(t)
+	id := createStudent(t, srv, "Jane Doe", "jane@example.com", 20)
+
+	for _, tt := range []struct{ path, header string }{
+		{"/api/students", "Content-Type"},
+		{fmt.Sprintf("/api/students/%d", id), "ETag"},
+	} {
+		t.Run(tt.path, func(t *testing.T) {
+			get, err := srv.Client().Get(srv.URL + tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			get.Body.Close()
+
+			head, err := srv.Client().Head(srv.URL + tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(head.Body)
+			head.Body.Close()
+
+			if head.StatusCode != http.StatusOK {
+				t.Fatalf("HEAD status = %d, want 200", head.StatusCode)
+			}
+			if len(body) != 0 {
+				t.Errorf("HEAD response has a %d byte body", len(body))
+			}
+			if got, want := head.Header.Get(tt.header), get.Header.Get(tt.header); got != want || got == "" {
+				t.Errorf("HEAD %s = %q, want GET's %q", tt.header, got, want)
+			}
+		})
+	}
+}
+
+func TestTrailingSlash(t *testing.T) {
+	srv := newServer(t, middleware.StripTrailingSlash)
+	createStudent(t, srv, "Jane Doe", "jane@example.com", 20)
+	createStudent(t, srv, "John Doe", "john@example.com", 21)
+
+	// listed checks the number of students in a list response.
+	listed := func(n int) func(*testing.T, map[string]any) {
+		return func(t *testing.T, body map[string]any) {
+			t.Helper()
+			if got := len(body["data"].([]any)); got != n {
+				t.Errorf("got %d students, want %d", got, n)
+			}
+		}
+	}
+
+	runCases(t, srv, []apiCase{
+		{name: "list", method: http.MethodGet, path: "/api/students", status: http.StatusOK, check: listed(2)},
+		{name: "list with slash", method: http.MethodGet, path: "/api/students/", status: http.StatusOK, check: listed(2)},
+		{name: "list with slash and query", method: http.MethodGet, path: "/api/students/?limit=1", status: http.StatusOK, check: listed(1)},
+		{name: "get with slash", method: http.MethodGet, path: "/api/students/1/", status: http.StatusOK, check: field("name", "Jane Doe")},
+		{name: "update with slash", method: http.MethodPatch, path: "/api/students/2/", body: `{"age":30}`, status: http.StatusOK, check: field("age", float64(30))},
+		{name: "create with slash", method: http.MethodPost, path: "/api/students/", body: `{"name":"Ann Lee","email":"ann@example.com","age":19}`, status: http.StatusCreated},
+	})
+}
+
+func TestSeed(t *testing.T) {
+	auth := map[string]string{"Authorization": "Bearer " + adminToken}
+
+	runCases(t, newServer(t), []apiCase{
+		{name: "without token", method: http.MethodPost, path: "/api/seed?count=3", status: http.StatusUnauthorized},
+		{name: "seed", method: http.MethodPost, path: "/api/seed?count=3", header: auth, status: http.StatusCreated},
+		{name: "seeded", method: http.MethodGet, path: "/api/students", status: http.StatusOK, check: func(t *testing.T, body map[string]any) {
+			if got := body["meta"].(map[string]any)["total"]; got != 3.0 {
+				t.Errorf("meta.total = %v, want 3", got)
+			}
+		}},
+	})
+
+	// The route isn't registered in prod, whatever the token
+	prod := newServerEnv(t, "prod")
+	req, err := http.NewRequest(http.MethodPost, prod.URL+"/api/seed?count=3", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", auth["Authorization"])
+	resp, err := prod.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("POST /api/seed in prod: status = %d, want 404", resp.StatusCode)
+	}
+	apiCase{method: http.MethodGet, path: "/api/students", status: http.StatusOK, check: func(t *testing.T, body map[string]any) {
+		if got := body["meta"].(map[string]any)["total"]; got != 0.0 {
+			t.Errorf("meta.total in prod = %v, want 0", got)
+		}
+	}}.run(t, prod)
+}
+
+func TestIncrementAges(t *testing.T) {
+	srv := newServer(t)
+	createStudent(t, srv, "Jane Doe", "jane@example.com", 20)
+	createStudent(t, srv, "John Doe", "john@example.com", 118)
+	auth := map[string]string{"Authorization": "Bearer " + adminToken}
+
+	// updated checks the number of rows an increment reports.
+	updated := func(n float64) func(*testing.T, map[string]any) {
+		return func(t *testing.T, body map[string]any) {
+			t.Helper()
+			if body["data"] != n {
+				t.Errorf("data = %v, want %v rows updated", body["data"], n)
+			}
+		}
+	}
+
+	runCases(t, srv, []apiCase{
+		{name: "without token", method: http.MethodPost, path: "/api/students/age/increment", status: http.StatusUnauthorized},
+		{name: "default", method: http.MethodPost, path: "/api/students/age/increment", header: auth, status: http.StatusOK, check: updated(2)},
+		{name: "incremented", method: http.MethodGet, path: "/api/students/1", status: http.StatusOK, check: field("age", 21.0)},
+		{name: "by", method: http.MethodPost, path: "/api/students/age/increment?by=-2", header: auth, status: http.StatusOK, check: updated(2)},
+		{name: "decremented", method: http.MethodGet, path: "/api/students/2", status: http.StatusOK, check: field("age", 117.0)},
+		{name: "zero", method: http.MethodPost, path: "/api/students/age/increment?by=0", header: auth, status: http.StatusBadRequest},
+		{name: "too large", method: http.MethodPost, path: "/api/students/age/increment?by=11", header: auth, status: http.StatusBadRequest},
+		{name: "not a number", method: http.MethodPost, path: "/api/students/age/increment?by=one", header: auth, status: http.StatusBadRequest},
+		{name: "out of range", method: http.MethodPost, path: "/api/students/age/increment?by=5", header: auth, status: http.StatusConflict},
+		{name: "unchanged", method: http.MethodGet, path: "/api/students/1", status: http.StatusOK, check: field("age", 19.0)},
+	})
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	srv := newServer(t, middleware.RouteErrors)
+
+	req, err := http.NewRequest(http.MethodPut, srv.URL+"/api/students/1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("status = %d, want 405", resp.StatusCode)
+	}
+	if allow := resp.Header.Get("Allow"); !strings.Contains(allow, "PATCH") || !strings.Contains(allow, "DELETE") {
+		t.Errorf("Allow = %q, want the student's methods", allow)
+	}
+
+	apiCase{method: http.MethodPut, path: "/api/students", status: http.StatusMethodNotAllowed, check: errorResponse("METHOD_NOT_ALLOWED", "method PUT is not allowed for /api/students (allowed: DELETE, GET, HEAD, PATCH, POST)")}.run(t, srv)
+}
+
+func TestUnknownPath(t *testing.T) {
+	srv := newServer(t, middleware.RouteErrors)
+
+	runCases(t, srv, []apiCase{
+		{name: "unknown resource", method: http.MethodGet, path: "/api/teachers", status: http.StatusNotFound, check: errorResponse("NOT_FOUND", "no resource at /api/teachers")},
+		{name: "outside the prefix", method: http.MethodGet, path: "/students", status: http.StatusNotFound, check: errorResponse("NOT_FOUND", "no resource at /students")},
+		{name: "bare prefix", method: http.MethodGet, path: "/api", status: http.StatusNotFound, check: errorResponse("NOT_FOUND", "no resource at /api")},
+		{name: "missing student", method: http.MethodGet, path: "/api/students/999", status: http.StatusNotFound, check: errorResponse("NOT_FOUND", "student with id 999 not found")},
+	})
+}
+
+func TestDBStats(t *testing.T) {
+	srv := newServer(t)
+	createStudent(t, srv, "Jane Doe", "jane@example.com", 20)
+
+	runCases(t, srv, []apiCase{
+		{name: "without token", method: http.MethodGet, path: "/api/admin/db/stats", status: http.StatusUnauthorized},
+		{name: "with token", method: http.MethodGet, path: "/api/admin/db/stats", header: map[string]string{"Authorization": "Bearer " + adminToken},
+			status: http.StatusOK, check: func(t *testing.T, body map[string]any) {
+				stats := data(t, body)
+				for _, key := range []string{"max_open_connections", "open_connections", "in_use", "idle", "wait_count", "wait_duration_ms"} {
+					if _, ok := stats[key].(float64); !ok {
+						t.Errorf("data.%s = %v, want a number", key, stats[key])
+					}
+				}
+				if open, _ := stats["open_connections"].(float64); open < 1 {
+					t.Errorf("open_connections = %v, want the connection used by the create", open)
+				}
+			}},
+	})
+}
+
+func TestJSONAPI(t *testing.T) {
+	srv := newServer(t, middleware.JSONAPI("students"))
+	createStudent(t, srv, "Jane Doe", "jane@example.com", 20)
+	accept := map[string]string{"Accept": "application/vnd.api+json"}
+
+	runCases(t, srv, []apiCase{
+		{name: "resource", method: http.MethodGet, path: "/api/students/1", header: accept, status: http.StatusOK,
+			check: func(t *testing.T, body map[string]any) {
+				resource := data(t, body)
+				attributes, _ := resource["attributes"].(map[string]any)
+				if resource["type"] != "students" || resource["id"] != "1" || attributes["name"] != "Jane Doe" {
+					t.Errorf("data = %v, want the student as a students resource", resource)
+				}
+			}},
+		{name: "collection", method: http.MethodGet, path: "/api/students", header: accept, status: http.StatusOK,
+			check: func(t *testing.T, body map[string]any) {
+				if resources, _ := body["data"].([]any); len(resources) != 1 {
+					t.Errorf("data = %v, want one resource", body["data"])
+				}
+			}},
+		{name: "error", method: http.MethodGet, path: "/api/students/999", header: accept, status: http.StatusNotFound,
+			check: func(t *testing.T, body map[string]any) {
+				errs, _ := body["errors"].([]any)
+				if len(errs) != 1 || errs[0].(map[string]any)["code"] != "NOT_FOUND" {
+					t.Errorf("errors = %v, want one NOT_FOUND error", body["errors"])
+				}
+			}},
+		{name: "plain by default", method: http.MethodGet, path: "/api/students/1", status: http.StatusOK, check: field("name", "Jane Doe")},
+	})
+}
@@ -0,0 +1,114 @@
+package idempotency
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// State describes what Reserve found for a key.
+type State int
+
+const (
+	// Reserved means the key was unused and now belongs to the caller,
+	// who must later call Complete or Release.
+	Reserved State = iota
+	// InFlight means another request holding the key is still running.
+	InFlight
+	// Done means a response was already recorded for the key.
+	Done
+)
+
+// Response is a recorded HTTP response that can be replayed to retries.
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+type entry struct {
+	fingerprint string
+	done        bool
+	response    Response
+	expiresAt   time.Time
+}
+
+// Store is an in-memory, TTL-bounded record of idempotency keys and the
+// responses they produced. It is safe for concurrent use.
+type Store struct {
+	ttl time.Duration
+
+	mu        sync.Mutex
+	entries   map[string]*entry
+	lastSweep time.Time
+}
+
+// New returns a Store whose keys expire ttl after they are first used.
+func New(ttl time.Duration) *Store {
+	return &Store{
+		ttl:     ttl,
+		entries: make(map[string]*entry),
+	}
+}
+
+// Reserve claims key for a request whose body hashes to fingerprint.
+//
+// For Done keys it also returns the recorded response. sameRequest reports
+// whether fingerprint matches the request that first used the key, so callers
+// can reject a key reused for a different payload.
+func (s *Store) Reserve(key, fingerprint string) (state State, resp Response, sameRequest bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	if e, ok := s.entries[key]; ok && now.Before(e.expiresAt) {
+		if !e.done {
+			return InFlight, Response{}, e.fingerprint == fingerprint
+		}
+		return Done, e.response, e.fingerprint == fingerprint
+	}
+
+	s.entries[key] = &entry{
+		fingerprint: fingerprint,
+		expiresAt:   now.Add(s.ttl),
+	}
+	return Reserved, Response{}, true
+}
+
+// Complete records the response for a reserved key so retries can replay it.
+func (s *Store) Complete(key string, resp Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[key]; ok {
+		e.done = true
+		e.response = resp
+	}
+}
+
+// Release forgets a reserved key without recording a response,
+// letting a later retry run the request again.
+func (s *Store) Release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[key]; ok && !e.done {
+		delete(s.entries, key)
+	}
+}
+
+// sweep drops expired entries, at most once a minute. Callers hold s.mu.
+func (s *Store) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+
+	for key, e := range s.entries {
+		if !now.Before(e.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package idempotency
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestStoreLifecycle(t *testing.T) {
+	s := New(time.Hour)
+
+	if state, _, same := s.Reserve("k", "a"); state != Reserved || !same {
+		t.Fatalf("first Reserve = %v, %v; want Reserved for the same request", state, same)
+	}
+	if state, _, same := s.Reserve("k", "b"); state != InFlight || same {
+		t.Errorf("Reserve while running = %v, %v; want InFlight for another request", state, same)
+	}
+
+	s.Complete("k", Response{Status: http.StatusCreated, Body: []byte("done")})
+	state, resp, same := s.Reserve("k", "a")
+	if state != Done || !same || resp.Status != http.StatusCreated || string(resp.Body) != "done" {
+		t.Errorf("Reserve after Complete = %v, %v, %+v; want Done with the recorded response", state, same, resp)
+	}
+
+	// Releasing a completed key keeps its response
+	s.Release("k")
+	if state, _, _ := s.Reserve("k", "a"); state != Done {
+		t.Errorf("Reserve after releasing a done key = %v, want Done", state)
+	}
+}
+
+func TestStoreRelease(t *testing.T) {
+	s := New(time.Hour)
+	s.Reserve("k", "a")
+	s.Release("k")
+
+	if state, _, same := s.Reserve("k", "b"); state != Reserved || !same {
+		t.Errorf("Reserve after Release = %v, %v; want the key free for any request", state, same)
+	}
+}
+
+func TestStoreExpiry(t *testing.T) {
+	s := New(20 * time.Millisecond)
+	s.Reserve("k", "a")
+	s.Complete("k", Response{Status: http.StatusCreated})
+
+	time.Sleep(30 * time.Millisecond)
+	if state, _, same := s.Reserve("k", "b"); state != Reserved || !same {
+		t.Errorf("Reserve after the TTL = %v, %v; want the expired key reserved again", state, same)
+	}
+}
@@ -0,0 +1,24 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+)
+
+// ctxKey is the context key under which NewContext stores a logger.
+type ctxKey struct{}
+
+// NewContext returns a copy of ctx carrying logger, which FromContext returns.
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, logger)
+}
+
+// FromContext returns the logger stored in ctx by NewContext, such as the
+// request-scoped logger set by the RequestLogger middleware, or
+// slog.Default() when there is none.
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(ctxKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
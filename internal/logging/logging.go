@@ -0,0 +1,49 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// Formats lists the accepted log output formats.
+var Formats = []string{"json", "text"}
+
+// ParseLevel converts a level name ("debug", "info", "warn", "error",
+// optionally with an offset such as "info+2") into a slog.Level.
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("invalid log level %q: %w", s, err)
+	}
+	return level, nil
+}
+
+// SetLevel sets level to the level named name, parsed with ParseLevel.
+// Loggers from New with that level follow the change right away, e.g. when
+// the config is reloaded.
+func SetLevel(level *slog.LevelVar, name string) error {
+	lvl, err := ParseLevel(name)
+	if err != nil {
+		return err
+	}
+	level.Set(lvl)
+	return nil
+}
+
+// New builds a logger writing to w records at level or above, using either
+// a JSON ("json") or human-readable key=value ("text") handler. Pass a
+// *slog.LevelVar to change the level later; see SetLevel.
+func New(w io.Writer, level slog.Leveler, format string) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{Level: level}
+
+	switch strings.ToLower(format) {
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("invalid log format %q (supported: %s)", format, strings.Join(Formats, ", "))
+	}
+}
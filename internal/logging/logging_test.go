@@ -0,0 +1,97 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    slog.Level
+		wantErr bool
+	}{
+		{"debug", slog.LevelDebug, false},
+		{"INFO", slog.LevelInfo, false},
+		{"warn", slog.LevelWarn, false},
+		{"error", slog.LevelError, false},
+		{"info+2", slog.LevelInfo + 2, false},
+		{"verbose", 0, true},
+		{"", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.name)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, %v; want %v, error %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestNewFormats(t *testing.T) {
+	var buf bytes.Buffer
+
+	logger, err := New(&buf, slog.LevelInfo, "json")
+	if err != nil {
+		t.Fatalf("New json: %v", err)
+	}
+	logger.Info("hello", slog.Int("id", 1))
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil || record["msg"] != "hello" || record["id"] != 1.0 {
+		t.Errorf("json output %q, want a JSON record with msg and id", buf.String())
+	}
+
+	buf.Reset()
+	logger, err = New(&buf, slog.LevelInfo, "Text")
+	if err != nil {
+		t.Fatalf("New text: %v", err)
+	}
+	logger.Info("hello", slog.Int("id", 1))
+	if out := buf.String(); !strings.Contains(out, "msg=hello") || !strings.Contains(out, "id=1") {
+		t.Errorf("text output %q, want key=value pairs", out)
+	}
+
+	if _, err := New(&buf, slog.LevelInfo, "xml"); err == nil {
+		t.Error("New with an unknown format succeeded")
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	var buf bytes.Buffer
+	var level slog.LevelVar
+	logger, err := New(&buf, &level, "text")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger.Debug("hidden")
+	if err := SetLevel(&level, "debug"); err != nil {
+		t.Fatalf("SetLevel: %v", err)
+	}
+	logger.Debug("shown")
+	if out := buf.String(); strings.Contains(out, "hidden") || !strings.Contains(out, "shown") {
+		t.Errorf("output %q, want only the record logged after lowering the level", out)
+	}
+
+	// An invalid name leaves the level alone
+	if err := SetLevel(&level, "verbose"); err == nil {
+		t.Error("SetLevel with an invalid level succeeded")
+	}
+	if level.Level() != slog.LevelDebug {
+		t.Errorf("level = %v after a failed SetLevel, want DEBUG", level.Level())
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	if got := FromContext(context.Background()); got != slog.Default() {
+		t.Error("FromContext without a logger did not return slog.Default()")
+	}
+
+	logger := slog.New(slog.DiscardHandler)
+	if got := FromContext(NewContext(context.Background(), logger)); got != logger {
+		t.Error("FromContext did not return the logger stored by NewContext")
+	}
+}
@@ -0,0 +1,46 @@
+package logging
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// RedactEmail masks an email address for logging, keeping only its first
+// character (a whole rune, so multi-byte names stay valid UTF-8) and the
+// domain: "jane@example.com" becomes "j***@example.com".
+// Values without an "@" are masked completely.
+func RedactEmail(email string) string {
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok || local == "" {
+		return "***"
+	}
+	_, size := utf8.DecodeRuneInString(local)
+	return local[:size] + "***@" + domain
+}
+
+// RedactJSON returns a copy of a decoded JSON value (as produced by
+// encoding/json into an any) with every string under an "email" key replaced
+// by RedactEmail, at any depth. Keys are matched case-insensitively, as
+// encoding/json matches them to struct fields, so "Email" is redacted too.
+func RedactJSON(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, val := range v {
+			if s, ok := val.(string); ok && strings.EqualFold(k, "email") {
+				out[k] = RedactEmail(s)
+				continue
+			}
+			out[k] = RedactJSON(val)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, val := range v {
+			out[i] = RedactJSON(val)
+		}
+		return out
+	default:
+		return v
+	}
+}
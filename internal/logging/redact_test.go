@@ -0,0 +1,53 @@
+package logging
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRedactEmail(t *testing.T) {
+	tests := []struct {
+		email string
+		want  string
+	}{
+		{"jane@example.com", "j***@example.com"},
+		{"j@example.com", "j***@example.com"},
+		{"élodie@example.fr", "é***@example.fr"},
+		{"李雷@example.cn", "李***@example.cn"},
+		{"@example.com", "***"},
+		{"not-an-email", "***"},
+		{"", "***"},
+	}
+
+	for _, tt := range tests {
+		if got := RedactEmail(tt.email); got != tt.want {
+			t.Errorf("RedactEmail(%q) = %q, want %q", tt.email, got, tt.want)
+		}
+	}
+}
+
+func TestRedactJSON(t *testing.T) {
+	in := map[string]any{
+		"email": "jane@example.com",
+		"name":  "Jane",
+		"students": []any{
+			map[string]any{"email": "john@example.com", "age": float64(20)},
+			map[string]any{"Email": "mary@example.com", "EMAIL": "max@example.com"},
+		},
+	}
+	want := map[string]any{
+		"email": "j***@example.com",
+		"name":  "Jane",
+		"students": []any{
+			map[string]any{"email": "j***@example.com", "age": float64(20)},
+			map[string]any{"Email": "m***@example.com", "EMAIL": "m***@example.com"},
+		},
+	}
+
+	if got := RedactJSON(in); !reflect.DeepEqual(got, want) {
+		t.Errorf("RedactJSON = %v, want %v", got, want)
+	}
+	if in["email"] != "jane@example.com" {
+		t.Errorf("RedactJSON modified its input")
+	}
+}
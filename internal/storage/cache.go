@@ -0,0 +1,271 @@
+package storage
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/gourav224/student-api/internal/types"
+)
+
+// cached is a Storage decorator that keeps recently read students in memory.
+// See WithCache.
+type cached struct {
+	next  Storage
+	cache *studentCache
+	// tx is set on the transaction-bound Storage handed out by WithTx; it
+	// records what the transaction wrote.
+	tx *txWrites
+}
+
+// WithCache wraps next so that GetStudentById is served from an in-memory
+// LRU cache of up to size students, each kept for at most ttl. Only found
+// students are cached, never errors.
+//
+// Update, Restore and the deletes drop the affected ids from the cache, and
+// UpdateWhere, IncrementAllAges and DeleteAll, which may touch any row, empty
+// it. Reads inside WithTx bypass the cache, since they may see uncommitted
+// changes, and whatever the transaction wrote is dropped again once it ends.
+// Writes made by other processes sharing the database are not seen, so a
+// student may be served up to ttl out of date.
+func WithCache(next Storage, size int, ttl time.Duration) Storage {
+	return &cached{
+		next: next,
+		cache: &studentCache{
+			size:    size,
+			ttl:     ttl,
+			entries: make(map[int64]*list.Element),
+			order:   list.New(),
+		},
+	}
+}
+
+// cacheEntry is an element of studentCache.order.
+type cacheEntry struct {
+	student   types.Student
+	expiresAt time.Time
+}
+
+// studentCache is a TTL-bounded LRU of students by id. It is safe for
+// concurrent use.
+type studentCache struct {
+	size int
+	ttl  time.Duration
+
+	mu      sync.Mutex
+	entries map[int64]*list.Element
+	// order holds the entries, most recently used first.
+	order *list.List
+	// generation is incremented by every invalidation, so a read that raced
+	// with a write doesn't cache what it read before the write.
+	generation uint64
+}
+
+// get returns the cached student with the given id, if any, and the current
+// generation to pass to put after reading the student from the database.
+func (c *studentCache) get(id int64) (types.Student, bool, uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[id]
+	if !ok {
+		return types.Student{}, false, c.generation
+	}
+	entry := elem.Value.(*cacheEntry)
+	if !time.Now().Before(entry.expiresAt) {
+		c.order.Remove(elem)
+		delete(c.entries, id)
+		return types.Student{}, false, c.generation
+	}
+	c.order.MoveToFront(elem)
+	return entry.student, true, c.generation
+}
+
+// put caches student unless the cache was invalidated since generation was
+// returned by get, evicting the least recently used student when full.
+func (c *studentCache) put(student types.Student, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+	entry := &cacheEntry{student: student, expiresAt: time.Now().Add(c.ttl)}
+	if elem, ok := c.entries[student.Id]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[student.Id] = c.order.PushFront(entry)
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).student.Id)
+	}
+}
+
+// invalidate drops the students with the given ids.
+func (c *studentCache) invalidate(ids ...int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	for _, id := range ids {
+		if elem, ok := c.entries[id]; ok {
+			c.order.Remove(elem)
+			delete(c.entries, id)
+		}
+	}
+}
+
+// purge drops every student.
+func (c *studentCache) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	clear(c.entries)
+	c.order.Init()
+}
+
+// txWrites collects the ids written in a transaction, to invalidate them
+// again once it has committed or rolled back.
+type txWrites struct {
+	mu  sync.Mutex
+	ids []int64
+	all bool
+}
+
+// invalidate drops ids from the cache, remembering them for the end of the
+// transaction if there is one.
+func (s *cached) invalidate(ids ...int64) {
+	s.cache.invalidate(ids...)
+	if s.tx != nil {
+		s.tx.mu.Lock()
+		s.tx.ids = append(s.tx.ids, ids...)
+		s.tx.mu.Unlock()
+	}
+}
+
+// purge empties the cache, remembering to do so again at the end of the
+// transaction if there is one.
+func (s *cached) purge() {
+	s.cache.purge()
+	if s.tx != nil {
+		s.tx.mu.Lock()
+		s.tx.all = true
+		s.tx.mu.Unlock()
+	}
+}
+
+func (s *cached) CreateStudent(ctx context.Context, name string, email string, age int) (int64, error) {
+	return s.next.CreateStudent(ctx, name, email, age)
+}
+
+func (s *cached) GetStudentById(ctx context.Context, id int64) (types.Student, error) {
+	if s.tx != nil {
+		return s.next.GetStudentById(ctx, id)
+	}
+
+	student, ok, generation := s.cache.get(id)
+	if ok {
+		return student, nil
+	}
+	student, err := s.next.GetStudentById(ctx, id)
+	if err == nil {
+		s.cache.put(student, generation)
+	}
+	return student, err
+}
+
+func (s *cached) Exists(ctx context.Context, id int64) (bool, error) {
+	return s.next.Exists(ctx, id)
+}
+
+func (s *cached) GetStudents(ctx context.Context, opts ListOptions) ([]types.Student, error) {
+	return s.next.GetStudents(ctx, opts)
+}
+
+func (s *cached) EachStudent(ctx context.Context, opts ListOptions, fn func(types.Student) error) error {
+	return s.next.EachStudent(ctx, opts, fn)
+}
+
+func (s *cached) SearchStudents(ctx context.Context, q string, limit int) ([]types.Student, error) {
+	return s.next.SearchStudents(ctx, q, limit)
+}
+
+func (s *cached) CountStudents(ctx context.Context, filter StudentFilter) (int64, error) {
+	return s.next.CountStudents(ctx, filter)
+}
+
+func (s *cached) AgeDistribution(ctx context.Context) (map[int]int, error) {
+	return s.next.AgeDistribution(ctx)
+}
+
+// The writes below invalidate even when they fail, since an error such as a
+// timeout doesn't prove that nothing was written.
+
+func (s *cached) Update(ctx context.Context, id int64, version int64, updates map[string]any) (types.Student, error) {
+	defer s.invalidate(id)
+	return s.next.Update(ctx, id, version, updates)
+}
+
+func (s *cached) UpdateWhere(ctx context.Context, filter StudentFilter, updates map[string]any) (int64, error) {
+	defer s.purge()
+	return s.next.UpdateWhere(ctx, filter, updates)
+}
+
+func (s *cached) IncrementAllAges(ctx context.Context, by int) (int64, error) {
+	defer s.purge()
+	return s.next.IncrementAllAges(ctx, by)
+}
+
+func (s *cached) Delete(ctx context.Context, id int64, version int64) (int64, error) {
+	defer s.invalidate(id)
+	return s.next.Delete(ctx, id, version)
+}
+
+func (s *cached) DeleteMany(ctx context.Context, ids []int64) (int64, error) {
+	defer s.invalidate(ids...)
+	return s.next.DeleteMany(ctx, ids)
+}
+
+func (s *cached) DeleteAll(ctx context.Context) (int64, error) {
+	defer s.purge()
+	return s.next.DeleteAll(ctx)
+}
+
+func (s *cached) Restore(ctx context.Context, id int64) (types.Student, error) {
+	defer s.invalidate(id)
+	return s.next.Restore(ctx, id)
+}
+
+// WithTx hands fn a transaction-bound Storage that reads around the cache,
+// and invalidates what the transaction wrote once it has ended.
+func (s *cached) WithTx(ctx context.Context, fn func(txStorage Storage) error) error {
+	writes := &txWrites{}
+	defer func() {
+		if writes.all {
+			s.purge()
+		} else if len(writes.ids) > 0 {
+			s.invalidate(writes.ids...)
+		}
+	}()
+	return s.next.WithTx(ctx, func(txStorage Storage) error {
+		return fn(&cached{next: txStorage, cache: s.cache, tx: writes})
+	})
+}
+
+func (s *cached) Ping(ctx context.Context) error {
+	return s.next.Ping(ctx)
+}
+
+func (s *cached) Stats() sql.DBStats {
+	return s.next.Stats()
+}
+
+func (s *cached) Close() error {
+	return s.next.Close()
+}
@@ -0,0 +1,172 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gourav224/student-api/internal/types"
+)
+
+// mapStore is an in-memory Storage counting its GetStudentById calls.
+type mapStore struct {
+	Storage
+	students map[int64]types.Student
+	reads    int
+}
+
+func newMapStore(ids ...int64) *mapStore {
+	m := &mapStore{students: map[int64]types.Student{}}
+	for _, id := range ids {
+		m.students[id] = types.Student{Id: id, Age: 20}
+	}
+	return m
+}
+
+func (m *mapStore) GetStudentById(ctx context.Context, id int64) (types.Student, error) {
+	m.reads++
+	st, ok := m.students[id]
+	if !ok {
+		return types.Student{}, fmt.Errorf("%w: id %d", ErrNotFound, id)
+	}
+	return st, nil
+}
+
+func (m *mapStore) Update(ctx context.Context, id int64, version int64, updates map[string]any) (types.Student, error) {
+	st := m.students[id]
+	st.Age = updates["age"].(int)
+	m.students[id] = st
+	return st, nil
+}
+
+func (m *mapStore) IncrementAllAges(ctx context.Context, by int) (int64, error) {
+	for id, st := range m.students {
+		st.Age += by
+		m.students[id] = st
+	}
+	return int64(len(m.students)), nil
+}
+
+func (m *mapStore) Delete(ctx context.Context, id, version int64) (int64, error) {
+	delete(m.students, id)
+	return 1, nil
+}
+
+func (m *mapStore) WithTx(ctx context.Context, fn func(txStorage Storage) error) error {
+	return fn(m)
+}
+
+// age reads the age of student id through store.
+func age(t *testing.T, store Storage, id int64) int {
+	t.Helper()
+	st, err := store.GetStudentById(context.Background(), id)
+	if err != nil {
+		t.Fatalf("GetStudentById(%d): %v", id, err)
+	}
+	return st.Age
+}
+
+func TestCacheHitAndMiss(t *testing.T) {
+	m := newMapStore(1)
+	store := WithCache(m, 10, time.Minute)
+
+	age(t, store, 1)
+	age(t, store, 1)
+	if m.reads != 1 {
+		t.Errorf("%d database reads for two gets, want 1", m.reads)
+	}
+
+	// Missing students aren't cached
+	for range 2 {
+		store.GetStudentById(context.Background(), 2)
+	}
+	if m.reads != 3 {
+		t.Errorf("%d database reads, want the missing student read each time", m.reads)
+	}
+}
+
+func TestCacheInvalidation(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name  string
+		write func(Storage) error
+		want  int
+	}{
+		{"update", func(s Storage) error {
+			_, err := s.Update(ctx, 1, 0, map[string]any{"age": 30})
+			return err
+		}, 30},
+		{"increment all", func(s Storage) error {
+			_, err := s.IncrementAllAges(ctx, 1)
+			return err
+		}, 21},
+		{"update in transaction", func(s Storage) error {
+			return s.WithTx(ctx, func(tx Storage) error {
+				_, err := tx.Update(ctx, 1, 0, map[string]any{"age": 40})
+				return err
+			})
+		}, 40},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMapStore(1, 2)
+			store := WithCache(m, 10, time.Minute)
+			age(t, store, 1)
+			age(t, store, 2)
+
+			if err := tt.write(store); err != nil {
+				t.Fatal(err)
+			}
+			if got := age(t, store, 1); got != tt.want {
+				t.Errorf("age after the write = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCacheInvalidatesDelete(t *testing.T) {
+	m := newMapStore(1)
+	store := WithCache(m, 10, time.Minute)
+	age(t, store, 1)
+
+	if _, err := store.Delete(context.Background(), 1, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.GetStudentById(context.Background(), 1); err == nil {
+		t.Error("deleted student still served from the cache")
+	}
+}
+
+func TestCacheExpiry(t *testing.T) {
+	m := newMapStore(1)
+	store := WithCache(m, 10, 10*time.Millisecond)
+	age(t, store, 1)
+
+	time.Sleep(20 * time.Millisecond)
+	age(t, store, 1)
+	if m.reads != 2 {
+		t.Errorf("%d database reads, want the expired student read again", m.reads)
+	}
+}
+
+func TestCacheEviction(t *testing.T) {
+	m := newMapStore(1, 2, 3)
+	store := WithCache(m, 2, time.Minute)
+	age(t, store, 1)
+	age(t, store, 2)
+	age(t, store, 1) // 2 is now the least recently used
+	age(t, store, 3) // evicts 2
+
+	m.reads = 0
+	age(t, store, 1)
+	age(t, store, 3)
+	if m.reads != 0 {
+		t.Errorf("%d database reads for cached students, want none", m.reads)
+	}
+	age(t, store, 2)
+	if m.reads != 1 {
+		t.Errorf("evicted student not read from the database")
+	}
+}
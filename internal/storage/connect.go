@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// maxConnectBackoff caps the wait between two connection attempts.
+const maxConnectBackoff = 30 * time.Second
+
+// Pinger is the part of *sql.DB that PingWithRetry uses.
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// PingWithRetry pings db up to attempts times, waiting interval before the
+// first retry and doubling the wait after each failure (capped at 30s).
+// It lets the service start while a database container is still booting.
+// Returns the last ping error once every attempt has failed.
+func PingWithRetry(ctx context.Context, db Pinger, attempts int, interval time.Duration) error {
+	return pingWithRetry(ctx, db, attempts, interval, time.After)
+}
+
+// pingWithRetry is PingWithRetry waiting on after.
+func pingWithRetry(ctx context.Context, db Pinger, attempts int, interval time.Duration, after func(time.Duration) <-chan time.Time) error {
+	attempts = max(attempts, 1)
+	wait := interval
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = db.PingContext(ctx); err == nil {
+			return nil
+		}
+		if attempt == attempts {
+			break
+		}
+
+		slog.Warn("database not reachable, retrying",
+			slog.Int("attempt", attempt),
+			slog.Int("max_attempts", attempts),
+			slog.Duration("retry_in", wait),
+			slog.String("error", err.Error()),
+		)
+
+		select {
+		case <-after(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		wait = min(wait*2, maxConnectBackoff)
+	}
+
+	return fmt.Errorf("after %d attempts: %w", attempts, err)
+}
@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakePinger fails the first failures pings with errDown, then succeeds.
+type fakePinger struct {
+	failures int
+	pings    int
+}
+
+var errDown = errors.New("connection refused")
+
+func (p *fakePinger) PingContext(ctx context.Context) error {
+	p.pings++
+	if p.pings <= p.failures {
+		return errDown
+	}
+	return nil
+}
+
+// recordWaits returns an after function that records each wait and returns
+// right away, and the waits recorded.
+func recordWaits() (func(time.Duration) <-chan time.Time, *[]time.Duration) {
+	var waits []time.Duration
+	return func(d time.Duration) <-chan time.Time {
+		waits = append(waits, d)
+		ch := make(chan time.Time, 1)
+		ch <- time.Time{}
+		return ch
+	}, &waits
+}
+
+func TestPingWithRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  int
+		attempts  int
+		wantPings int
+		wantWaits []time.Duration
+		wantErr   bool
+	}{
+		{"up at once", 0, 5, 1, nil, false},
+		{"up after retries", 3, 5, 4, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}, false},
+		{"never up", 10, 3, 3, []time.Duration{time.Second, 2 * time.Second}, true},
+		{"zero attempts still pings once", 10, 0, 1, nil, true},
+		{"backoff is capped", 10, 8, 8, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second, 30 * time.Second, 30 * time.Second}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakePinger{failures: tt.failures}
+			after, waits := recordWaits()
+
+			err := pingWithRetry(context.Background(), db, tt.attempts, time.Second, after)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("error = %v, want error: %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, errDown) {
+				t.Errorf("error = %v, want it to wrap the last ping error", err)
+			}
+			if db.pings != tt.wantPings {
+				t.Errorf("pinged %d times, want %d", db.pings, tt.wantPings)
+			}
+			if !reflect.DeepEqual(*waits, tt.wantWaits) {
+				t.Errorf("waits = %v, want %v", *waits, tt.wantWaits)
+			}
+		})
+	}
+}
+
+func TestPingWithRetryCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	db := &fakePinger{failures: 10}
+
+	// Cancel while waiting for the first retry, which never comes
+	err := pingWithRetry(ctx, db, 5, time.Second, func(time.Duration) <-chan time.Time {
+		cancel()
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("error = %v, want context.Canceled", err)
+	}
+	if db.pings != 1 {
+		t.Errorf("pinged %d times, want 1", db.pings)
+	}
+}
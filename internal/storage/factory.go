@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gourav224/student-api/internal/config"
+)
+
+// Factory constructs a Storage implementation from the application config.
+type Factory func(cfg *config.Config) (Storage, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = map[string]Factory{}
+)
+
+// Register makes a storage backend available under the given driver name.
+// Backend packages call it from their init function, so importing a backend
+// (usually for side effects) is enough to make it selectable via config.
+// It panics if the factory is nil or the driver is registered twice.
+func Register(driver string, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	if factory == nil {
+		panic("storage: Register factory is nil")
+	}
+	if _, dup := factories[driver]; dup {
+		panic("storage: Register called twice for driver " + driver)
+	}
+	factories[driver] = factory
+}
+
+// New returns the Storage implementation selected by cfg.StorageDriver,
+// or an error if no backend is registered under that name. With a positive
+// cfg.StorageTimeout it is wrapped by WithTimeout, with a positive
+// cfg.SlowQueryThreshold by WithSlowQueryLog, and with a positive
+// cfg.StudentCacheSize by WithCache.
+func New(cfg *config.Config) (Storage, error) {
+	factoriesMu.RLock()
+	factory, ok := factories[cfg.StorageDriver]
+	factoriesMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown storage driver %q (available: %s)", cfg.StorageDriver, strings.Join(Drivers(), ", "))
+	}
+
+	store, err := factory(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// The slow query log wraps the timeout, so operations that time out are
+	// logged as slow too
+	if cfg.StorageTimeout > 0 {
+		store = WithTimeout(store, cfg.StorageTimeout.Std())
+	}
+	if cfg.SlowQueryThreshold > 0 {
+		store = WithSlowQueryLog(store, cfg.SlowQueryThreshold.Std())
+	}
+	// The cache goes outside, so cache hits skip the timeout and slow query log
+	if cfg.StudentCacheSize > 0 {
+		store = WithCache(store, cfg.StudentCacheSize, cfg.StudentCacheTTL.Std())
+	}
+	return store, nil
+}
+
+// Drivers returns the sorted names of all registered storage backends.
+func Drivers() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/gourav224/student-api/internal/config"
+)
+
+// fakeStore is a Storage that records which driver built it. Its methods are
+// never called by the factory, so the embedded interface stays nil.
+type fakeStore struct {
+	Storage
+	driver string
+}
+
+func init() {
+	for _, driver := range []string{"fake-a", "fake-b"} {
+		Register(driver, func(cfg *config.Config) (Storage, error) {
+			return &fakeStore{driver: driver}, nil
+		})
+	}
+	Register("fake-broken", func(cfg *config.Config) (Storage, error) {
+		return nil, errBrokenBackend
+	})
+}
+
+var errBrokenBackend = errors.New("backend unavailable")
+
+func TestNewDispatchesOnDriver(t *testing.T) {
+	for _, driver := range []string{"fake-a", "fake-b"} {
+		t.Run(driver, func(t *testing.T) {
+			store, err := New(&config.Config{StorageDriver: driver})
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			fake, ok := store.(*fakeStore)
+			if !ok {
+				t.Fatalf("New returned %T, want the undecorated *fakeStore", store)
+			}
+			if fake.driver != driver {
+				t.Errorf("New built driver %q, want %q", fake.driver, driver)
+			}
+		})
+	}
+}
+
+func TestNewUnknownDriver(t *testing.T) {
+	for _, driver := range []string{"", "postgres", "SQLITE", "fake"} {
+		t.Run(driver, func(t *testing.T) {
+			store, err := New(&config.Config{StorageDriver: driver})
+			if err == nil {
+				t.Fatalf("New(%q) = %T, want an error", driver, store)
+			}
+			if store != nil {
+				t.Errorf("New(%q) returned a store along with its error", driver)
+			}
+			if !strings.Contains(err.Error(), "unknown storage driver") || !strings.Contains(err.Error(), "fake-a, fake-b") {
+				t.Errorf("error %q should name the driver and list the available ones", err)
+			}
+		})
+	}
+}
+
+func TestNewFactoryError(t *testing.T) {
+	store, err := New(&config.Config{StorageDriver: "fake-broken"})
+	if !errors.Is(err, errBrokenBackend) {
+		t.Fatalf("New error = %v, want %v", err, errBrokenBackend)
+	}
+	if store != nil {
+		t.Errorf("New returned a store along with its error")
+	}
+}
+
+func TestDriversSorted(t *testing.T) {
+	if got, want := strings.Join(Drivers(), ","), "fake-a,fake-b,fake-broken"; got != want {
+		t.Errorf("Drivers() = %s, want %s", got, want)
+	}
+}
+
+func TestRegisterPanics(t *testing.T) {
+	tests := []struct {
+		name    string
+		driver  string
+		factory Factory
+	}{
+		{"nil factory", "fake-nil", nil},
+		{"duplicate driver", "fake-a", func(*config.Config) (Storage, error) { return nil, nil }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Register(%q) did not panic", tt.driver)
+				}
+			}()
+			Register(tt.driver, tt.factory)
+		})
+	}
+}
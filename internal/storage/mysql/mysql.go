@@ -0,0 +1,833 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-sql-driver/mysql" // Also registers the MySQL driver
+	"github.com/gourav224/student-api/internal/config"
+	"github.com/gourav224/student-api/internal/storage"
+	"github.com/gourav224/student-api/internal/types"
+)
+
+// errDuplicateEntry is the MySQL server error number for a unique key violation.
+const errDuplicateEntry = 1062
+
+// Mysql wraps the SQL database connection.
+type Mysql struct {
+	Db *sql.DB
+
+	table       string // validated table name, interpolated into every query
+	maxStudents int    // quota enforced by CreateStudent; zero disables it
+
+	q  queryer // Db, or tx for transaction-bound copies
+	tx *sql.Tx // non-nil when bound to a transaction by WithTx
+}
+
+// queryer is the subset of *sql.DB and *sql.Tx used by the storage methods,
+// so the same code runs inside or outside a transaction.
+type queryer interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func init() {
+	storage.Register("mysql", func(cfg *config.Config) (storage.Storage, error) {
+		return New(cfg)
+	})
+}
+
+// New initializes and returns a new MySQL connection using cfg.StorageDSN.
+// Students are kept in cfg.StorageTable (storage.DefaultTable if empty),
+// which must be a plain SQL identifier. With cfg.AutoMigrate it creates and
+// migrates the table as needed; otherwise it only checks that the schema is
+// in place.
+func New(cfg *config.Config) (*Mysql, error) {
+	if cfg.StorageDSN == "" {
+		return nil, fmt.Errorf("storage_dsn is required for the mysql driver")
+	}
+
+	table := cfg.StorageTable
+	if table == "" {
+		table = storage.DefaultTable
+	}
+	if err := storage.ValidateIdentifier(table); err != nil {
+		return nil, err
+	}
+
+	// Timestamps must scan into time.Time, whatever the DSN says
+	dsn, err := mysql.ParseDSN(cfg.StorageDSN)
+	if err != nil {
+		return nil, fmt.Errorf("invalid storage_dsn: %w", err)
+	}
+	dsn.ParseTime = true
+
+	// Open the connection pool (does not connect yet)
+	db, err := sql.Open("mysql", dsn.FormatDSN())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mysql db: %w", err)
+	}
+
+	// Verify the database connection, retrying while it comes up
+	if err := storage.PingWithRetry(context.Background(), db, cfg.StorageConnectAttempts, cfg.StorageConnectInterval.Std()); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping mysql db: %w", err)
+	}
+
+	if cfg.AutoMigrate {
+		// Create the students table if it doesn't exist. Emails are unique
+		// among students that aren't soft-deleted: active_email is NULL for
+		// deleted ones, and the unique index allows any number of NULLs.
+		createTableQuery := `
+		CREATE TABLE IF NOT EXISTS ` + table + ` (
+			id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+			email VARCHAR(255) NOT NULL,
+			name VARCHAR(255) NOT NULL,
+			age INT NOT NULL,
+			avatar_url VARCHAR(1024) NULL,
+			created_at DATETIME(6) NOT NULL,
+			updated_at DATETIME(6) NOT NULL,
+			version BIGINT NOT NULL DEFAULT 1,
+			deleted_at DATETIME(6) NULL,
+			active_email VARCHAR(255) AS (` + activeEmail + `) STORED,
+			UNIQUE INDEX ` + emailIndexName(table) + ` (active_email)
+		);`
+
+		if _, err = db.Exec(createTableQuery); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to create %s table: %w", table, err)
+		}
+
+		// Bring tables created by older versions up to date
+		if err := migrate(db, table); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to migrate %s table: %w", table, err)
+		}
+	} else if err := checkSchema(db, table); err != nil {
+		// Schema is managed externally, so fail fast rather than create a guess
+		db.Close()
+		return nil, fmt.Errorf("auto_migrate is off and the schema is not ready: %w", err)
+	}
+
+	return &Mysql{Db: db, q: db, table: table, maxStudents: cfg.MaxStudents}, nil
+}
+
+// addedColumns are the columns added to the students table after its first
+// release, with their definitions. migrate adds any that are missing.
+// Columns marked backfill are added as nullable and existing rows get the
+// migration time, as in the SQLite backend.
+var addedColumns = []struct {
+	name, definition string
+	backfill         bool
+}{
+	{"avatar_url", "VARCHAR(1024) NULL", false},
+	{"created_at", "DATETIME(6) NULL", true},
+	{"updated_at", "DATETIME(6) NULL", true},
+	{"version", "BIGINT NOT NULL DEFAULT 1", false},
+	{"deleted_at", "DATETIME(6) NULL", false},
+	{"active_email", "VARCHAR(255) AS (" + activeEmail + ") STORED", false},
+}
+
+// activeEmail is the expression of the generated active_email column: the
+// email of a student that isn't soft-deleted, and NULL otherwise.
+const activeEmail = "IF(deleted_at IS NULL, email, NULL)"
+
+// emailIndexName returns the name of the unique index on active_email.
+func emailIndexName(table string) string {
+	return table + "_email"
+}
+
+// migrate adds the columns in addedColumns that an existing table lacks, and
+// moves email uniqueness to the index on active_email; see migrateEmailIndex.
+func migrate(db *sql.DB, table string) error {
+	now := storage.Now()
+	for _, col := range addedColumns {
+		exists, err := hasColumn(db, table, col.name)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		if _, err := db.Exec("ALTER TABLE " + table + " ADD COLUMN " + col.name + " " + col.definition); err != nil {
+			return fmt.Errorf("add column %s: %w", col.name, err)
+		}
+		if col.backfill {
+			if _, err := db.Exec("UPDATE "+table+" SET "+col.name+" = ? WHERE "+col.name+" IS NULL", now); err != nil {
+				return fmt.Errorf("backfill column %s: %w", col.name, err)
+			}
+		}
+	}
+	return migrateEmailIndex(db, table)
+}
+
+// migrateEmailIndex adds the unique index on active_email if it is missing,
+// then drops the UNIQUE constraint on email that tables created by older
+// versions have, as it also covers soft-deleted students.
+func migrateEmailIndex(db *sql.DB, table string) error {
+	index := emailIndexName(table)
+	exists, err := hasIndex(db, table, index)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if _, err := db.Exec("ALTER TABLE " + table + " ADD UNIQUE INDEX " + index + " (active_email)"); err != nil {
+			return fmt.Errorf("add index %s: %w", index, err)
+		}
+	}
+
+	// MySQL names the index of a column's UNIQUE constraint after the column
+	constrained, err := hasIndex(db, table, "email")
+	if err != nil {
+		return err
+	}
+	if constrained {
+		if _, err := db.Exec("ALTER TABLE " + table + " DROP INDEX email"); err != nil {
+			return fmt.Errorf("drop email constraint: %w", err)
+		}
+	}
+	return nil
+}
+
+// hasIndex reports whether table has an index named index.
+func hasIndex(db *sql.DB, table, index string) (bool, error) {
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = ? AND index_name = ?", table, index).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// checkSchema verifies, without changing anything, that table exists and has
+// every column in addedColumns and the email index. It is used instead of
+// creating and migrating the table when auto_migrate is off.
+func checkSchema(db *sql.DB, table string) error {
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?", table).Scan(&count); err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("table %s does not exist", table)
+	}
+
+	for _, col := range addedColumns {
+		exists, err := hasColumn(db, table, col.name)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("table %s has no column %s", table, col.name)
+		}
+	}
+
+	index := emailIndexName(table)
+	exists, err := hasIndex(db, table, index)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("table %s has no index %s", table, index)
+	}
+	return nil
+}
+
+// hasColumn reports whether table has a column named column.
+func hasColumn(db *sql.DB, table, column string) (bool, error) {
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = ? AND column_name = ?", table, column).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// CreateStudent inserts a new student record into the students table.
+// Returns the ID of the newly created student, storage.ErrDuplicateEmail
+// if the email is already taken, or storage.ErrQuotaExceeded if the table
+// already holds the configured maximum number of students.
+// Email uniqueness is left to the database index, with no pre-check, so of
+// several concurrent creates with the same email exactly one succeeds.
+// Soft-deleted students neither hold on to their email nor count towards the
+// quota.
+func (m *Mysql) CreateStudent(ctx context.Context, name string, email string, age int) (int64, error) {
+	now := storage.Now()
+	query := "INSERT INTO " + m.table + " (name, email, age, created_at, updated_at) VALUES (?, ?, ?, ?, ?)"
+	args := []any{name, email, age, now, now}
+	if m.maxStudents > 0 {
+		// Check the quota and insert in one statement, so concurrent creates
+		// can't both pass the check and overshoot it
+		query = "INSERT INTO " + m.table + " (name, email, age, created_at, updated_at) SELECT ?, ?, ?, ?, ? FROM (SELECT COUNT(*) AS n FROM " + m.table + " WHERE " + storage.NotDeleted + ") AS c WHERE c.n < ?"
+		args = append(args, m.maxStudents)
+	}
+
+	// Prepare the INSERT statement
+	stmt, err := m.q.PrepareContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	// Execute the statement with provided parameters
+	res, err := stmt.ExecContext(ctx, args...)
+	if err != nil {
+		return 0, translateError(err)
+	}
+
+	// With a quota, no inserted row means it was reached
+	if m.maxStudents > 0 {
+		if n, err := res.RowsAffected(); err != nil {
+			return 0, err
+		} else if n == 0 {
+			return 0, fmt.Errorf("%w: at most %d students are allowed", storage.ErrQuotaExceeded, m.maxStudents)
+		}
+	}
+
+	// The driver reports LAST_INSERT_ID() for this connection from the OK packet,
+	// so no extra round trip is needed.
+	lastId, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return lastId, nil
+}
+
+// GetStudentById retrieves a single student record by its ID.
+// Returns a Student struct, or storage.ErrNotFound if no row matches or the
+// student is soft-deleted.
+func (m *Mysql) GetStudentById(ctx context.Context, id int64) (types.Student, error) {
+	// Prepare the SELECT statement
+	stmt, err := m.q.PrepareContext(ctx, "SELECT "+strings.Join(storage.StudentColumns, ", ")+" FROM "+m.table+" WHERE id = ? AND "+storage.NotDeleted+" LIMIT 1")
+	if err != nil {
+		return types.Student{}, err
+	}
+	defer stmt.Close()
+
+	var student types.Student
+
+	// Query a single row and scan the result into the student struct
+	err = stmt.QueryRowContext(ctx, id).Scan(storage.StudentScanTargets(&student, storage.StudentColumns)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.Student{}, fmt.Errorf("%w: id %d", storage.ErrNotFound, id)
+	}
+	if err != nil {
+		return types.Student{}, err
+	}
+
+	return student, nil
+}
+
+// Exists reports whether a student with the given id exists, and isn't
+// soft-deleted, using a SELECT 1 probe, which is cheaper than GetStudentById
+// when only existence matters.
+func (m *Mysql) Exists(ctx context.Context, id int64) (bool, error) {
+	stmt, err := m.q.PrepareContext(ctx, "SELECT 1 FROM "+m.table+" WHERE id = ? AND "+storage.NotDeleted+" LIMIT 1")
+	if err != nil {
+		return false, err
+	}
+	defer stmt.Close()
+
+	var one int
+	err = stmt.QueryRowContext(ctx, id).Scan(&one)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// requireExists returns storage.ErrNotFound unless student id exists.
+func (m *Mysql) requireExists(ctx context.Context, id int64) error {
+	exists, err := m.Exists(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("%w: id %d", storage.ErrNotFound, id)
+	}
+	return nil
+}
+
+// GetStudents retrieves student records from the students table ordered by id.
+// opts narrows the result to a keyset page (ids after opts.AfterId, at most opts.Limit rows),
+// to the students matching opts.Filter, and optionally to a subset of columns;
+// unselected fields are left zero.
+// Returns a slice of Student structs or an error.
+func (m *Mysql) GetStudents(ctx context.Context, opts storage.ListOptions) ([]types.Student, error) {
+	var students []types.Student
+
+	err := m.EachStudent(ctx, opts, func(student types.Student) error {
+		students = append(students, student)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return students, nil
+}
+
+// EachStudent runs the GetStudents query and calls fn for each row as it is read,
+// so callers can stream large result sets.
+func (m *Mysql) EachStudent(ctx context.Context, opts storage.ListOptions, fn func(types.Student) error) error {
+	columns := storage.SelectColumns(opts.Fields)
+
+	where, whereArgs := storage.WhereClause(opts.Filter)
+	query := "SELECT " + strings.Join(columns, ", ") + " FROM " + m.table + " WHERE id > ? AND " + where + " ORDER BY id"
+	args := append([]any{opts.AfterId}, whereArgs...)
+	if opts.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, opts.Limit)
+		if opts.Offset > 0 {
+			query += " OFFSET ?"
+			args = append(args, opts.Offset)
+		}
+	}
+
+	// Prepare the SELECT statement
+	stmt, err := m.q.PrepareContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	// Execute the query to get multiple rows
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	// Iterate over the result set and hand each row to fn
+	for rows.Next() {
+		var student types.Student
+		if err := rows.Scan(storage.StudentScanTargets(&student, columns)...); err != nil {
+			return err
+		}
+		if err := fn(student); err != nil {
+			return err
+		}
+	}
+
+	// Check for iteration errors
+	return rows.Err()
+}
+
+// SearchStudents returns up to limit students whose name or email contains q,
+// ordered by id. The term is matched literally; LIKE wildcards in q are escaped.
+func (m *Mysql) SearchStudents(ctx context.Context, q string, limit int) ([]types.Student, error) {
+	// Prepare the SELECT statement
+	// Backslash is also the escape character inside MySQL string literals,
+	// hence the doubled '\\' to mean a single backslash.
+	stmt, err := m.q.PrepareContext(ctx, "SELECT "+strings.Join(storage.StudentColumns, ", ")+" FROM "+m.table+`
+		WHERE (name LIKE ? ESCAPE '\\' OR email LIKE ? ESCAPE '\\') AND `+storage.NotDeleted+`
+		ORDER BY id LIMIT ?`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	pattern := "%" + storage.EscapeLike(q) + "%"
+
+	rows, err := stmt.QueryContext(ctx, pattern, pattern, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var students []types.Student
+
+	// Iterate over the result set and map each row to a Student struct
+	for rows.Next() {
+		var student types.Student
+		if err := rows.Scan(storage.StudentScanTargets(&student, storage.StudentColumns)...); err != nil {
+			return nil, err
+		}
+		students = append(students, student)
+	}
+
+	// Check for iteration errors
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return students, nil
+}
+
+// CountStudents returns the number of students matching filter with a single
+// COUNT(*) query.
+func (m *Mysql) CountStudents(ctx context.Context, filter storage.StudentFilter) (int64, error) {
+	where, args := storage.WhereClause(filter)
+	query := "SELECT COUNT(*) FROM " + m.table + " WHERE " + where
+
+	stmt, err := m.q.PrepareContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	var count int64
+	err = stmt.QueryRowContext(ctx, args...).Scan(&count)
+	return count, err
+}
+
+// AgeDistribution counts students per age with a single GROUP BY query,
+// so callers can aggregate without fetching every row.
+func (m *Mysql) AgeDistribution(ctx context.Context) (map[int]int, error) {
+	// Prepare the aggregation statement
+	stmt, err := m.q.PrepareContext(ctx, "SELECT age, COUNT(*) FROM "+m.table+" WHERE "+storage.NotDeleted+" GROUP BY age")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	distribution := map[int]int{}
+	for rows.Next() {
+		var age, count int
+		if err := rows.Scan(&age, &count); err != nil {
+			return nil, err
+		}
+		distribution[age] = count
+	}
+
+	// Check for iteration errors
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return distribution, nil
+}
+
+// Update modifies one or more fields of a student record.
+// Builds a dynamic SQL UPDATE statement using only the provided fields.
+// Email uniqueness is enforced by the database constraint alone, so concurrent
+// updates can't both pass a check; a violation returns storage.ErrDuplicateEmail.
+// Returns the updated student, storage.ErrNoFieldsToUpdate for empty updates,
+// storage.ErrNotFound if the student does not exist, or
+// storage.ErrVersionConflict if a non-zero version is no longer current.
+func (m *Mysql) Update(ctx context.Context, id int64, version int64, updates map[string]any) (types.Student, error) {
+
+	// Ensure at least one field is being updated
+	if len(updates) == 0 {
+		return types.Student{}, storage.ErrNoFieldsToUpdate
+	}
+
+	// Run the existence check, update and re-read in one transaction so the
+	// returned record is exactly what this update produced
+	var student types.Student
+	err := m.WithTx(ctx, func(txStorage storage.Storage) error {
+		var err error
+		student, err = txStorage.(*Mysql).update(ctx, id, version, updates)
+		return err
+	})
+	return student, err
+}
+
+// update performs Update's work on an already transaction-bound Mysql.
+func (m *Mysql) update(ctx context.Context, id int64, version int64, updates map[string]any) (types.Student, error) {
+	// Check if student exists. MySQL reports zero affected rows when the new
+	// values equal the old ones, so RowsAffected can't be used for this.
+	if err := m.requireExists(ctx, id); err != nil {
+		return types.Student{}, err
+	}
+
+	// Build dynamic UPDATE query from the provided fields
+	query, args := storage.BuildUpdateQuery(m.table, id, version, updates)
+
+	// Prepare the dynamic UPDATE statement
+	stmt, err := m.q.PrepareContext(ctx, query)
+	if err != nil {
+		return types.Student{}, err
+	}
+	defer stmt.Close()
+
+	// Execute UPDATE with values
+	res, err := stmt.ExecContext(ctx, args...)
+	if err != nil {
+		return types.Student{}, translateError(err)
+	}
+
+	// The student exists, so no matched row means its version has moved on.
+	// The version always changes, so MySQL counts the row even when the new
+	// values equal the old ones.
+	if version != 0 {
+		n, err := res.RowsAffected()
+		if err != nil {
+			return types.Student{}, err
+		}
+		if n == 0 {
+			return types.Student{}, storage.ErrVersionConflict
+		}
+	}
+
+	// Return updated student
+	return m.GetStudentById(ctx, id)
+}
+
+// UpdateWhere sets the fields in updates on every student matching filter
+// with a single UPDATE statement, so the change is atomic.
+// Returns the number of rows changed, or storage.ErrNoFieldsToUpdate for
+// empty updates.
+func (m *Mysql) UpdateWhere(ctx context.Context, filter storage.StudentFilter, updates map[string]any) (int64, error) {
+	if len(updates) == 0 {
+		return 0, storage.ErrNoFieldsToUpdate
+	}
+
+	query, args := storage.BuildUpdateWhereQuery(m.table, filter, updates)
+	res, err := m.q.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, translateError(err)
+	}
+
+	return res.RowsAffected()
+}
+
+// IncrementAllAges adds by to every student's age. The range check and the
+// UPDATE run in one transaction, so either every age changes or none does.
+func (m *Mysql) IncrementAllAges(ctx context.Context, by int) (int64, error) {
+	var rowsUpdated int64
+	err := m.WithTx(ctx, func(txStorage storage.Storage) error {
+		var err error
+		rowsUpdated, err = txStorage.(*Mysql).incrementAllAges(ctx, by)
+		return err
+	})
+	return rowsUpdated, err
+}
+
+// incrementAllAges performs IncrementAllAges's work on an already
+// transaction-bound Mysql.
+func (m *Mysql) incrementAllAges(ctx context.Context, by int) (int64, error) {
+	// Refuse the whole change if any student would end up out of range. FOR
+	// UPDATE locks the rows, so none can change between the check and the update.
+	stmt, err := m.q.PrepareContext(ctx, "SELECT COUNT(*) FROM "+m.table+" WHERE "+storage.NotDeleted+" AND age + ? NOT BETWEEN ? AND ? FOR UPDATE")
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	var outOfRange int64
+	if err := stmt.QueryRowContext(ctx, by, storage.MinAge, storage.MaxAge).Scan(&outOfRange); err != nil {
+		return 0, err
+	}
+	if outOfRange > 0 {
+		return 0, fmt.Errorf("%w: %d student(s) would be outside %d-%d", storage.ErrAgeOutOfRange, outOfRange, storage.MinAge, storage.MaxAge)
+	}
+
+	res, err := m.q.ExecContext(ctx, "UPDATE "+m.table+" SET age = age + ?, updated_at = ?, version = version + 1 WHERE "+storage.NotDeleted, by, storage.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// Delete soft-deletes a student by ID, setting its deleted_at.
+// Returns the number of rows deleted, or storage.ErrNotFound if no row matches
+// or the student is already deleted.
+// A non-zero version only deletes the student while it is still at that
+// version, returning storage.ErrVersionConflict otherwise.
+func (m *Mysql) Delete(ctx context.Context, id int64, version int64) (int64, error) {
+	// Ensure the student exists before deleting
+	if err := m.requireExists(ctx, id); err != nil {
+		return 0, err
+	}
+
+	// Prepare DELETE query
+	query, args := storage.BuildDeleteQuery(m.table, id, version)
+	stmt, err := m.q.PrepareContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	// Execute the delete
+	res, err := stmt.ExecContext(ctx, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	// How many rows were deleted?
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	// The existence check ran outside this statement, so a delete that
+	// removed nothing lost a race with another delete or, when conditional,
+	// found the student at another version
+	if rowsAffected == 0 {
+		if version == 0 {
+			return 0, fmt.Errorf("%w: id %d", storage.ErrNotFound, id)
+		}
+		if err := m.requireExists(ctx, id); err != nil {
+			return 0, err
+		}
+		return 0, storage.ErrVersionConflict
+	}
+
+	return rowsAffected, nil
+}
+
+// DeleteMany soft-deletes every student whose id is in ids using a single
+// UPDATE ... WHERE id IN (...) statement, so the batch is atomic.
+// Returns the number of rows deleted; ids that don't exist or are already
+// deleted are skipped.
+func (m *Mysql) DeleteMany(ctx context.Context, ids []int64) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	query, args := storage.BuildDeleteManyQuery(m.table, ids)
+	res, err := m.q.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// DeleteAll permanently removes every student from the database, including
+// soft-deleted ones. Returns the number of rows deleted.
+func (m *Mysql) DeleteAll(ctx context.Context) (int64, error) {
+	res, err := m.q.ExecContext(ctx, "DELETE FROM "+m.table)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// Restore clears the deleted_at of a soft-deleted student and returns the
+// restored record. Returns storage.ErrNotFound unless the student exists and
+// is deleted, storage.ErrDuplicateEmail if another student has taken its
+// email since, or storage.ErrQuotaExceeded if the table already holds the
+// configured maximum number of students. The avatar removed by the delete is
+// not brought back.
+func (m *Mysql) Restore(ctx context.Context, id int64) (types.Student, error) {
+	var student types.Student
+	err := m.WithTx(ctx, func(txStorage storage.Storage) error {
+		var err error
+		student, err = txStorage.(*Mysql).restore(ctx, id)
+		return err
+	})
+	return student, err
+}
+
+// restore performs Restore's work on an already transaction-bound Mysql.
+func (m *Mysql) restore(ctx context.Context, id int64) (types.Student, error) {
+	query := "UPDATE " + m.table + " SET deleted_at = NULL, updated_at = ?, version = version + 1 WHERE id = ? AND deleted_at IS NOT NULL"
+	args := []any{storage.Now(), id}
+	if m.maxStudents > 0 {
+		// As in CreateStudent, check the quota in the same statement. MySQL
+		// refuses to read the updated table in a plain subquery, hence the
+		// derived table.
+		query += " AND (SELECT n FROM (SELECT COUNT(*) AS n FROM " + m.table + " WHERE " + storage.NotDeleted + ") AS c) < ?"
+		args = append(args, m.maxStudents)
+	}
+
+	res, err := m.q.ExecContext(ctx, query, args...)
+	if err != nil {
+		return types.Student{}, translateError(err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return types.Student{}, err
+	}
+	if n == 0 {
+		// Either there was nothing to restore or the quota is reached
+		stmt, err := m.q.PrepareContext(ctx, "SELECT COUNT(*) FROM "+m.table+" WHERE id = ? AND deleted_at IS NOT NULL")
+		if err != nil {
+			return types.Student{}, err
+		}
+		defer stmt.Close()
+
+		var deleted int
+		if err := stmt.QueryRowContext(ctx, id).Scan(&deleted); err != nil {
+			return types.Student{}, err
+		}
+		if deleted > 0 && m.maxStudents > 0 {
+			return types.Student{}, fmt.Errorf("%w: at most %d students are allowed", storage.ErrQuotaExceeded, m.maxStudents)
+		}
+		return types.Student{}, fmt.Errorf("%w: no deleted student with id %d", storage.ErrNotFound, id)
+	}
+
+	return m.GetStudentById(ctx, id)
+}
+
+// WithTx runs fn inside a transaction, passing it a transaction-bound Mysql.
+// The transaction is committed if fn returns nil and rolled back otherwise,
+// including when fn panics. If m is already bound to a transaction,
+// fn joins it instead of starting a new one.
+func (m *Mysql) WithTx(ctx context.Context, fn func(txStorage storage.Storage) error) error {
+	if m.tx != nil {
+		return fn(m)
+	}
+
+	tx, err := m.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	// Roll back if fn panics, then let the panic continue
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(&Mysql{Db: m.Db, table: m.table, maxStudents: m.maxStudents, q: tx, tx: tx}); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return errors.Join(err, fmt.Errorf("failed to roll back transaction: %w", rbErr))
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Ping verifies the database connection is still alive.
+func (m *Mysql) Ping(ctx context.Context) error {
+	return m.Db.PingContext(ctx)
+}
+
+// Stats reports the state of the connection pool, which transaction-bound
+// copies share.
+func (m *Mysql) Stats() sql.DBStats {
+	return m.Db.Stats()
+}
+
+// Close closes the underlying connection pool.
+// It fails for transaction-bound copies, which don't own the connection.
+func (m *Mysql) Close() error {
+	if m.tx != nil {
+		return errors.New("cannot close a transaction-bound storage")
+	}
+	return m.Db.Close()
+}
+
+// translateError maps driver-specific errors to storage sentinel errors.
+// Errors that have no storage-level meaning are returned unchanged.
+func translateError(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == errDuplicateEntry {
+		return fmt.Errorf("%w: %v", storage.ErrDuplicateEmail, err)
+	}
+	return err
+}
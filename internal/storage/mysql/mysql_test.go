@@ -0,0 +1,129 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/gourav224/student-api/internal/config"
+	"github.com/gourav224/student-api/internal/storage"
+)
+
+// openTest connects to the server named by MYSQL_TEST_DSN, skipping the test
+// without one, and keeps students in a new table that is dropped, with the
+// connection closed, when t ends.
+func openTest(t *testing.T) *Mysql {
+	t.Helper()
+	dsn := os.Getenv("MYSQL_TEST_DSN")
+	if dsn == "" {
+		t.Skip("MYSQL_TEST_DSN is not set")
+	}
+
+	table := fmt.Sprintf("students_test_%d", time.Now().UnixNano())
+	m, err := New(&config.Config{StorageDSN: dsn, StorageTable: table, AutoMigrate: true})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() {
+		m.Db.Exec("DROP TABLE " + table)
+		m.Close()
+	})
+	return m
+}
+
+func TestTranslateError(t *testing.T) {
+	other := errors.New("connection refused")
+
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"duplicate entry", &mysql.MySQLError{Number: errDuplicateEntry, Message: "Duplicate entry"}, storage.ErrDuplicateEmail},
+		{"wrapped duplicate entry", fmt.Errorf("insert: %w", &mysql.MySQLError{Number: errDuplicateEntry}), storage.ErrDuplicateEmail},
+		{"other server error", &mysql.MySQLError{Number: 1146, Message: "Table doesn't exist"}, nil},
+		{"other error", other, other},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := translateError(tt.err)
+			if tt.want == nil {
+				if got != tt.err {
+					t.Errorf("translateError = %v, want the error unchanged", got)
+				}
+				return
+			}
+			if !errors.Is(got, tt.want) {
+				t.Errorf("translateError = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewRejectsBadConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.Config
+		want string
+	}{
+		{"no DSN", config.Config{}, "storage_dsn is required"},
+		{"invalid table", config.Config{StorageDSN: "user@tcp(localhost:3306)/db", StorageTable: "students; --"}, "invalid table name"},
+		{"invalid DSN", config.Config{StorageDSN: "not a dsn"}, "invalid storage_dsn"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(&tt.cfg); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("New error = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestStudentLifecycle(t *testing.T) {
+	m := openTest(t)
+	ctx := context.Background()
+
+	id, err := m.CreateStudent(ctx, "Jane Doe", "jane@example.com", 20)
+	if err != nil {
+		t.Fatalf("CreateStudent: %v", err)
+	}
+	if _, err := m.CreateStudent(ctx, "Jane Again", "jane@example.com", 21); !errors.Is(err, storage.ErrDuplicateEmail) {
+		t.Errorf("CreateStudent with a taken email = %v, want ErrDuplicateEmail", err)
+	}
+
+	st, err := m.Update(ctx, id, 1, map[string]any{"name": "Jane Roe", "age": 21})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if st.Name != "Jane Roe" || st.Age != 21 || st.Version != 2 {
+		t.Errorf("updated student = %+v, want Jane Roe, 21, version 2", st)
+	}
+	if _, err := m.Update(ctx, id, 1, map[string]any{"age": 22}); !errors.Is(err, storage.ErrVersionConflict) {
+		t.Errorf("Update at a stale version = %v, want ErrVersionConflict", err)
+	}
+
+	minAge := 21
+	students, err := m.GetStudents(ctx, storage.ListOptions{Filter: storage.StudentFilter{MinAge: &minAge}, Limit: 10})
+	if err != nil || len(students) != 1 || students[0].Id != id {
+		t.Errorf("GetStudents(min age 21) = %v, %v, want the updated student", students, err)
+	}
+
+	// A deleted student's email can be used again, but then not restored
+	if _, err := m.Delete(ctx, id, 0); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := m.GetStudentById(ctx, id); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("GetStudentById after Delete = %v, want ErrNotFound", err)
+	}
+	if _, err := m.CreateStudent(ctx, "Jane New", "jane@example.com", 22); err != nil {
+		t.Fatalf("CreateStudent with a deleted student's email: %v", err)
+	}
+	if _, err := m.Restore(ctx, id); !errors.Is(err, storage.ErrDuplicateEmail) {
+		t.Errorf("Restore with the email taken = %v, want ErrDuplicateEmail", err)
+	}
+}
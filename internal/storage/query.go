@@ -0,0 +1,222 @@
+package storage
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gourav224/student-api/internal/types"
+)
+
+// DefaultTable is the table students are stored in unless configured otherwise.
+const DefaultTable = "students"
+
+// identifierPattern matches the table names backends accept: a letter or
+// underscore followed by letters, digits or underscores, at most 64 long.
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,63}$`)
+
+// ValidateIdentifier reports whether name is safe to interpolate into SQL as
+// a table name. Identifiers can't be bound as parameters, so anything outside
+// identifierPattern is rejected.
+func ValidateIdentifier(name string) error {
+	if !identifierPattern.MatchString(name) {
+		return fmt.Errorf("invalid table name %q: must match %s", name, identifierPattern)
+	}
+	return nil
+}
+
+// StudentColumns lists the student columns clients may select, in default order.
+// Each name matches both the database column and the JSON field.
+var StudentColumns = []string{"id", "name", "email", "age", "avatar_url", "created_at", "updated_at", "version"}
+
+// NullableColumns lists the student columns an update may clear by sending
+// an explicit null. avatar_url is nullable in the schema but only managed
+// through the avatar endpoint, so it is not listed.
+var NullableColumns = []string{}
+
+// SelectColumns returns the columns to select for the requested fields.
+// The id is always included; an empty request selects every column.
+func SelectColumns(fields []string) []string {
+	if len(fields) == 0 {
+		return StudentColumns
+	}
+
+	columns := []string{"id"}
+	for _, f := range fields {
+		if f != "id" {
+			columns = append(columns, f)
+		}
+	}
+	return columns
+}
+
+// StudentScanTargets returns pointers into st for the given columns, in order,
+// for use with rows.Scan. Columns must come from StudentColumns.
+//
+// Nullable columns scan into pointer fields such as Student.AvatarURL:
+// database/sql stores nil for a NULL, which is how sql.NullString would
+// report it, but without a conversion step after Scan.
+func StudentScanTargets(st *types.Student, columns []string) []any {
+	targets := make([]any, len(columns))
+	for i, col := range columns {
+		switch col {
+		case "id":
+			targets[i] = &st.Id
+		case "name":
+			targets[i] = &st.Name
+		case "email":
+			targets[i] = &st.Email
+		case "age":
+			targets[i] = &st.Age
+		case "avatar_url":
+			targets[i] = &st.AvatarURL
+		case "created_at":
+			targets[i] = &st.CreatedAt
+		case "updated_at":
+			targets[i] = &st.UpdatedAt
+		case "version":
+			targets[i] = &st.Version
+		}
+	}
+	return targets
+}
+
+// NotDeleted is the condition matching students that haven't been
+// soft-deleted. Every query except Restore's applies it.
+const NotDeleted = "deleted_at IS NULL"
+
+// Placeholders returns n comma-separated "?" bind parameters for an IN list.
+func Placeholders(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return strings.Repeat("?, ", n-1) + "?"
+}
+
+// Now returns the current time as stored in created_at and updated_at: in
+// UTC and truncated to microseconds, the precision of both backends, so a
+// value reads back exactly as it was written.
+func Now() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+// BuildUpdateQuery builds a parameterized UPDATE statement for a single row
+// of the given table, setting every column present in updates and bumping
+// updated_at and version. Soft-deleted rows never match. A non-zero version
+// restricts the update to the row still at that version, so a stale update
+// matches no row.
+//
+// Columns are emitted in sorted order so the generated SQL is deterministic.
+// Column names are interpolated directly, so callers must only pass keys
+// from a trusted whitelist. The returned args end with the row id, followed
+// by the version if it is non-zero.
+func BuildUpdateQuery(table string, id int64, version int64, updates map[string]any) (string, []any) {
+	sets, args := setClause(updates)
+	args = append(args, id)
+
+	query := "UPDATE " + table + " SET " + sets + " WHERE id = ? AND " + NotDeleted
+	if version != 0 {
+		query += " AND version = ?"
+		args = append(args, version)
+	}
+	return query, args
+}
+
+// BuildDeleteQuery builds a parameterized statement soft-deleting a single
+// row of table: it sets deleted_at and bumps updated_at and version. The
+// avatar is cleared, since its files are removed with the delete. As with
+// BuildUpdateQuery, a non-zero version restricts it to the row still at that
+// version.
+func BuildDeleteQuery(table string, id int64, version int64) (string, []any) {
+	now := Now()
+	query := "UPDATE " + table + " SET " + softDeleteSets + " WHERE id = ? AND " + NotDeleted
+	args := []any{now, now, id}
+	if version != 0 {
+		query += " AND version = ?"
+		args = append(args, version)
+	}
+	return query, args
+}
+
+// BuildDeleteManyQuery builds a parameterized statement soft-deleting, as
+// BuildDeleteQuery does, every row of table whose id is in ids.
+func BuildDeleteManyQuery(table string, ids []int64) (string, []any) {
+	now := Now()
+	args := []any{now, now}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	return "UPDATE " + table + " SET " + softDeleteSets + " WHERE id IN (" + Placeholders(len(ids)) + ") AND " + NotDeleted, args
+}
+
+// softDeleteSets is the SET list of a soft delete, taking deleted_at and
+// updated_at as args.
+const softDeleteSets = "deleted_at = ?, avatar_url = NULL, updated_at = ?, version = version + 1"
+
+// BuildUpdateWhereQuery builds a parameterized UPDATE statement setting every
+// column present in updates, and updated_at, on all rows of table that match
+// filter.
+// As with BuildUpdateQuery, keys of updates must come from a trusted whitelist.
+// A filter without bounds matches every row that isn't soft-deleted.
+func BuildUpdateWhereQuery(table string, filter StudentFilter, updates map[string]any) (string, []any) {
+	sets, args := setClause(updates)
+
+	where, whereArgs := WhereClause(filter)
+	query := "UPDATE " + table + " SET " + sets + " WHERE " + where
+	return query, append(args, whereArgs...)
+}
+
+// WhereClause returns the conditions selecting the students that match
+// filter, joined with AND, and their args. The conditions always include
+// NotDeleted, so a filter without bounds matches every student that isn't
+// soft-deleted.
+func WhereClause(filter StudentFilter) (string, []any) {
+	conds := []string{NotDeleted}
+	var args []any
+	if filter.MinAge != nil {
+		conds = append(conds, "age >= ?")
+		args = append(args, *filter.MinAge)
+	}
+	if filter.MaxAge != nil {
+		conds = append(conds, "age <= ?")
+		args = append(args, *filter.MaxAge)
+	}
+	if filter.CreatedAfter != nil {
+		conds = append(conds, "created_at >= ?")
+		args = append(args, filter.CreatedAfter.UTC())
+	}
+	if filter.CreatedBefore != nil {
+		conds = append(conds, "created_at < ?")
+		args = append(args, filter.CreatedBefore.UTC())
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// setClause returns the "col = ?, ..." list for updates, with columns in
+// sorted order so the generated SQL is deterministic, followed by
+// "updated_at = ?" and the version increment, and the matching args.
+func setClause(updates map[string]any) (string, []any) {
+	columns := make([]string, 0, len(updates))
+	for k := range updates {
+		columns = append(columns, k)
+	}
+	sort.Strings(columns)
+
+	sets := make([]string, 0, len(columns)+1)
+	args := make([]any, 0, len(columns)+3)
+	for _, col := range columns {
+		sets = append(sets, col+" = ?")
+		args = append(args, updates[col])
+	}
+	sets = append(sets, "updated_at = ?", "version = version + 1")
+	args = append(args, Now())
+	return strings.Join(sets, ", "), args
+}
+
+// EscapeLike escapes the LIKE wildcards in s so it matches literally
+// in a pattern declared with ESCAPE '\'.
+func EscapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
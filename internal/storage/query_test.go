@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestValidateIdentifier(t *testing.T) {
+	tests := []struct {
+		name string
+		ok   bool
+	}{
+		{"students", true},
+		{"_students_2", true},
+		{"Students", true},
+		{"", false},
+		{"2students", false},
+		{"students; DROP TABLE students", false},
+		{"db.students", false},
+		{"`students`", false},
+	}
+	for _, tt := range tests {
+		if err := ValidateIdentifier(tt.name); (err == nil) != tt.ok {
+			t.Errorf("ValidateIdentifier(%q) = %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestBuildUpdateQuery(t *testing.T) {
+	updates := map[string]any{"name": "Jane", "age": 21}
+
+	query, args := BuildUpdateQuery("students", 7, 0, updates)
+	if want := "UPDATE students SET age = ?, name = ?, updated_at = ?, version = version + 1 WHERE id = ? AND deleted_at IS NULL"; query != want {
+		t.Errorf("query = %q, want %q", query, want)
+	}
+	if len(args) != 4 || args[0] != 21 || args[1] != "Jane" || args[3] != int64(7) {
+		t.Errorf("args = %v, want [21 Jane <now> 7]", args)
+	}
+	if _, ok := args[2].(time.Time); !ok {
+		t.Errorf("updated_at arg = %T, want time.Time", args[2])
+	}
+
+	// A version adds a condition and its arg
+	query, args = BuildUpdateQuery("students", 7, 3, updates)
+	if want := "UPDATE students SET age = ?, name = ?, updated_at = ?, version = version + 1 WHERE id = ? AND deleted_at IS NULL AND version = ?"; query != want {
+		t.Errorf("query with version = %q, want %q", query, want)
+	}
+	if len(args) != 5 || args[4] != int64(3) {
+		t.Errorf("args with version = %v, want the version last", args)
+	}
+}
+
+func TestBuildDeleteQueries(t *testing.T) {
+	query, args := BuildDeleteQuery("students", 7, 3)
+	if want := "UPDATE students SET " + softDeleteSets + " WHERE id = ? AND deleted_at IS NULL AND version = ?"; query != want {
+		t.Errorf("BuildDeleteQuery = %q, want %q", query, want)
+	}
+	if len(args) != 4 || args[2] != int64(7) || args[3] != int64(3) {
+		t.Errorf("BuildDeleteQuery args = %v, want [<now> <now> 7 3]", args)
+	}
+
+	query, args = BuildDeleteManyQuery("students", []int64{1, 2, 3})
+	if want := "UPDATE students SET " + softDeleteSets + " WHERE id IN (?, ?, ?) AND deleted_at IS NULL"; query != want {
+		t.Errorf("BuildDeleteManyQuery = %q, want %q", query, want)
+	}
+	if len(args) != 5 || !reflect.DeepEqual(args[2:], []any{int64(1), int64(2), int64(3)}) {
+		t.Errorf("BuildDeleteManyQuery args = %v, want the ids after the times", args)
+	}
+}
+
+func TestWhereClause(t *testing.T) {
+	minAge, maxAge := 18, 30
+	after := time.Date(2026, 1, 1, 0, 0, 0, 0, time.FixedZone("CET", 3600))
+
+	where, args := WhereClause(StudentFilter{})
+	if where != NotDeleted || len(args) != 0 {
+		t.Errorf("empty filter = %q %v, want only %q", where, args, NotDeleted)
+	}
+
+	where, args = WhereClause(StudentFilter{MinAge: &minAge, MaxAge: &maxAge, CreatedAfter: &after})
+	if want := "deleted_at IS NULL AND age >= ? AND age <= ? AND created_at >= ?"; where != want {
+		t.Errorf("where = %q, want %q", where, want)
+	}
+	if want := []any{18, 30, after.UTC()}; !reflect.DeepEqual(args, want) {
+		t.Errorf("args = %v, want %v with the time in UTC", args, want)
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	if got, want := EscapeLike(`50%_off\`), `50\%\_off\\`; got != want {
+		t.Errorf("EscapeLike = %q, want %q", got, want)
+	}
+}
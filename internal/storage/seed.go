@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Name parts combined by SeedStudents. Their lengths are coprime, so
+// consecutive students get distinct name combinations.
+var (
+	seedFirstNames = []string{"Ada", "Alan", "Barbara", "Dennis", "Edsger", "Frances", "Grace", "John", "Ken", "Linus", "Margaret"}
+	seedLastNames  = []string{"Hopper", "Kernighan", "Knuth", "Liskov", "Lovelace", "Ritchie", "Thompson", "Turing", "Wirth"}
+)
+
+// SeedStudents inserts n generated students into store in one transaction,
+// for demos and local testing of pagination and search. If any insert fails
+// (e.g. the quota is reached), nothing is kept.
+//
+// The data is deterministic: student k gets the k-th name combination, an
+// age between 18 and 30, and the email "<first>.<last>.<k>@example.com".
+// Numbering continues after the current number of students, and numbers
+// whose email is already taken (e.g. after deletions) are skipped, so seeding
+// again always adds n new students.
+func SeedStudents(ctx context.Context, store Storage, n int) error {
+	return store.WithTx(ctx, func(tx Storage) error {
+		start, err := tx.CountStudents(ctx, StudentFilter{})
+		if err != nil {
+			return err
+		}
+
+		for k, created := int(start)+1, 0; created < n; k++ {
+			first := seedFirstNames[k%len(seedFirstNames)]
+			last := seedLastNames[k%len(seedLastNames)]
+			email := fmt.Sprintf("%s.%s.%d@example.com", strings.ToLower(first), strings.ToLower(last), k)
+
+			_, err := tx.CreateStudent(ctx, first+" "+last, email, 18+k%13)
+			if errors.Is(err, ErrDuplicateEmail) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("seed student %d: %w", k, err)
+			}
+			created++
+		}
+		return nil
+	})
+}
@@ -0,0 +1,161 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/gourav224/student-api/internal/logging"
+	"github.com/gourav224/student-api/internal/types"
+)
+
+// slowLog is a Storage decorator that logs operations taking longer than
+// threshold. See WithSlowQueryLog.
+type slowLog struct {
+	next      Storage
+	threshold time.Duration
+}
+
+// WithSlowQueryLog wraps next so that every operation taking threshold or
+// longer is logged as a warning, with its name, duration and a summary of its
+// arguments. Personal data such as names and emails is left out of the
+// summary. The log goes through logging.FromContext, so lines written while
+// handling a request carry its request id.
+//
+// Operations inside WithTx are timed individually as well as the transaction
+// as a whole. EachStudent is timed including the callback, so a slow consumer
+// (such as a slow client of a streamed export) also shows up.
+func WithSlowQueryLog(next Storage, threshold time.Duration) Storage {
+	return &slowLog{next: next, threshold: threshold}
+}
+
+// observe logs op if it has been running since start for at least the threshold.
+// It is meant to be deferred, with start taken when the operation begins.
+func (s *slowLog) observe(ctx context.Context, op string, start time.Time, attrs ...any) {
+	elapsed := time.Since(start)
+	if elapsed < s.threshold {
+		return
+	}
+	attrs = append([]any{slog.String("op", op), slog.Duration("duration", elapsed), slog.Duration("threshold", s.threshold)}, attrs...)
+	logging.FromContext(ctx).Warn("slow storage operation", attrs...)
+}
+
+// listAttrs summarizes opts for the log.
+func listAttrs(opts ListOptions) []any {
+	return []any{slog.Int64("after_id", opts.AfterId), slog.Int("limit", opts.Limit), slog.Int("offset", opts.Offset)}
+}
+
+// updateAttrs summarizes updates by their column names; the values may be
+// personal data.
+func updateAttrs(updates map[string]any) slog.Attr {
+	return slog.Any("columns", updatedColumns(updates))
+}
+
+// updatedColumns returns the sorted column names of updates.
+func updatedColumns(updates map[string]any) []string {
+	columns := make([]string, 0, len(updates))
+	for col := range updates {
+		columns = append(columns, col)
+	}
+	slices.Sort(columns)
+	return columns
+}
+
+func (s *slowLog) CreateStudent(ctx context.Context, name string, email string, age int) (int64, error) {
+	defer s.observe(ctx, "CreateStudent", time.Now())
+	return s.next.CreateStudent(ctx, name, email, age)
+}
+
+func (s *slowLog) GetStudentById(ctx context.Context, id int64) (types.Student, error) {
+	defer s.observe(ctx, "GetStudentById", time.Now(), slog.Int64("id", id))
+	return s.next.GetStudentById(ctx, id)
+}
+
+func (s *slowLog) Exists(ctx context.Context, id int64) (bool, error) {
+	defer s.observe(ctx, "Exists", time.Now(), slog.Int64("id", id))
+	return s.next.Exists(ctx, id)
+}
+
+func (s *slowLog) GetStudents(ctx context.Context, opts ListOptions) ([]types.Student, error) {
+	defer s.observe(ctx, "GetStudents", time.Now(), listAttrs(opts)...)
+	return s.next.GetStudents(ctx, opts)
+}
+
+func (s *slowLog) EachStudent(ctx context.Context, opts ListOptions, fn func(types.Student) error) error {
+	defer s.observe(ctx, "EachStudent", time.Now(), listAttrs(opts)...)
+	return s.next.EachStudent(ctx, opts, fn)
+}
+
+func (s *slowLog) SearchStudents(ctx context.Context, q string, limit int) ([]types.Student, error) {
+	defer s.observe(ctx, "SearchStudents", time.Now(), slog.Int("query_length", len(q)), slog.Int("limit", limit))
+	return s.next.SearchStudents(ctx, q, limit)
+}
+
+func (s *slowLog) CountStudents(ctx context.Context, filter StudentFilter) (int64, error) {
+	defer s.observe(ctx, "CountStudents", time.Now())
+	return s.next.CountStudents(ctx, filter)
+}
+
+func (s *slowLog) AgeDistribution(ctx context.Context) (map[int]int, error) {
+	defer s.observe(ctx, "AgeDistribution", time.Now())
+	return s.next.AgeDistribution(ctx)
+}
+
+func (s *slowLog) Update(ctx context.Context, id int64, version int64, updates map[string]any) (types.Student, error) {
+	defer s.observe(ctx, "Update", time.Now(), slog.Int64("id", id), slog.Int64("version", version), updateAttrs(updates))
+	return s.next.Update(ctx, id, version, updates)
+}
+
+func (s *slowLog) UpdateWhere(ctx context.Context, filter StudentFilter, updates map[string]any) (int64, error) {
+	defer s.observe(ctx, "UpdateWhere", time.Now(), updateAttrs(updates))
+	return s.next.UpdateWhere(ctx, filter, updates)
+}
+
+func (s *slowLog) IncrementAllAges(ctx context.Context, by int) (int64, error) {
+	defer s.observe(ctx, "IncrementAllAges", time.Now(), slog.Int("by", by))
+	return s.next.IncrementAllAges(ctx, by)
+}
+
+func (s *slowLog) Delete(ctx context.Context, id int64, version int64) (int64, error) {
+	defer s.observe(ctx, "Delete", time.Now(), slog.Int64("id", id))
+	return s.next.Delete(ctx, id, version)
+}
+
+func (s *slowLog) DeleteMany(ctx context.Context, ids []int64) (int64, error) {
+	defer s.observe(ctx, "DeleteMany", time.Now(), slog.Int("ids", len(ids)))
+	return s.next.DeleteMany(ctx, ids)
+}
+
+func (s *slowLog) DeleteAll(ctx context.Context) (int64, error) {
+	defer s.observe(ctx, "DeleteAll", time.Now())
+	return s.next.DeleteAll(ctx)
+}
+
+func (s *slowLog) Restore(ctx context.Context, id int64) (types.Student, error) {
+	defer s.observe(ctx, "Restore", time.Now(), slog.Int64("id", id))
+	return s.next.Restore(ctx, id)
+}
+
+// WithTx times the whole transaction and hands fn a decorated transaction-bound
+// Storage, so the operations inside are timed too.
+func (s *slowLog) WithTx(ctx context.Context, fn func(txStorage Storage) error) error {
+	defer s.observe(ctx, "WithTx", time.Now())
+	return s.next.WithTx(ctx, func(txStorage Storage) error {
+		return fn(&slowLog{next: txStorage, threshold: s.threshold})
+	})
+}
+
+func (s *slowLog) Ping(ctx context.Context) error {
+	defer s.observe(ctx, "Ping", time.Now())
+	return s.next.Ping(ctx)
+}
+
+func (s *slowLog) Stats() sql.DBStats {
+	return s.next.Stats()
+}
+
+func (s *slowLog) Close() error {
+	return s.next.Close()
+}
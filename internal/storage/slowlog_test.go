@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gourav224/student-api/internal/logging"
+	"github.com/gourav224/student-api/internal/types"
+)
+
+// delayStore is a Storage whose operations take delay.
+type delayStore struct {
+	Storage
+	delay time.Duration
+}
+
+func (d *delayStore) CreateStudent(ctx context.Context, name string, email string, age int) (int64, error) {
+	time.Sleep(d.delay)
+	return 1, nil
+}
+
+func (d *delayStore) GetStudentById(ctx context.Context, id int64) (types.Student, error) {
+	time.Sleep(d.delay)
+	return types.Student{Id: id}, nil
+}
+
+func (d *delayStore) Update(ctx context.Context, id, version int64, updates map[string]any) (types.Student, error) {
+	time.Sleep(d.delay)
+	return types.Student{Id: id}, nil
+}
+
+// logContext returns a context whose logger writes JSON lines to buf.
+func logContext(buf *bytes.Buffer) context.Context {
+	return logging.NewContext(context.Background(), slog.New(slog.NewJSONHandler(buf, nil)))
+}
+
+// logEntries decodes the JSON log lines in buf.
+func logEntries(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var entries []map[string]any
+	for line := range strings.Lines(buf.String()) {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("invalid log line %q: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestSlowQueryLog(t *testing.T) {
+	var logs bytes.Buffer
+	ctx := logContext(&logs)
+	store := WithSlowQueryLog(&delayStore{delay: 20 * time.Millisecond}, 10*time.Millisecond)
+
+	store.GetStudentById(ctx, 7)
+	store.Update(ctx, 7, 0, map[string]any{"name": "Jane Doe", "email": "jane@example.com"})
+	store.CreateStudent(ctx, "Jane Doe", "jane@example.com", 20)
+
+	entries := logEntries(t, &logs)
+	if len(entries) != 3 {
+		t.Fatalf("got %d log entries, want 3: %s", len(entries), logs.String())
+	}
+	get := entries[0]
+	if get["level"] != "WARN" || get["msg"] != "slow storage operation" || get["op"] != "GetStudentById" || get["id"] != 7.0 {
+		t.Errorf("GetStudentById entry = %v, want a warning naming the op and id", get)
+	}
+	if d, _ := get["duration"].(float64); time.Duration(d) < 10*time.Millisecond {
+		t.Errorf("logged duration = %v, want at least the threshold", time.Duration(d))
+	}
+	if columns := entries[1]["columns"]; len(columns.([]any)) != 2 || columns.([]any)[0] != "email" {
+		t.Errorf("Update columns = %v, want [email name]", columns)
+	}
+	if strings.Contains(logs.String(), "jane@example.com") || strings.Contains(logs.String(), "Jane Doe") {
+		t.Errorf("log contains personal data: %s", logs.String())
+	}
+}
+
+func TestSlowQueryLogSkipsFastOperations(t *testing.T) {
+	var logs bytes.Buffer
+	store := WithSlowQueryLog(&delayStore{}, time.Second)
+
+	store.GetStudentById(logContext(&logs), 7)
+	if logs.Len() != 0 {
+		t.Errorf("fast operation was logged: %s", logs.String())
+	}
+}
@@ -0,0 +1,98 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// The FTS5 full-text index of the students table mirrors the name and email
+// columns of every row. It is an external content table, so it stores only
+// the index, kept in sync with the students table by triggers.
+//
+// FTS5 is only compiled into the SQLite driver when building with
+// -tags sqlite_fts5. Without it, search falls back to LIKE.
+
+// ftsTable returns the name of the full-text index of table.
+func ftsTable(table string) string {
+	return table + "_fts"
+}
+
+// ftsTriggers returns the names of the triggers keeping the index of table in
+// sync, for inserts, deletes and updates.
+func ftsTriggers(table string) []string {
+	return []string{table + "_fts_insert", table + "_fts_delete", table + "_fts_update"}
+}
+
+// setupFTS creates the full-text index of table and its triggers if needed,
+// and rebuilds the index from table, since rows may have changed while the
+// triggers were missing. It returns false, with a warning logged, when this
+// build of SQLite has no FTS5; the triggers are then dropped, as they would
+// make every write fail.
+func setupFTS(db *sql.DB, table string) (bool, error) {
+	fts := ftsTable(table)
+	if _, err := db.Exec("CREATE VIRTUAL TABLE IF NOT EXISTS " + fts + " USING fts5(name, email, content='" + table + "', content_rowid='id')"); err != nil {
+		if !strings.Contains(err.Error(), "no such module: fts5") {
+			return false, fmt.Errorf("create %s: %w", fts, err)
+		}
+		slog.Warn("sqlite_fts is on but SQLite was built without FTS5 (build with -tags sqlite_fts5); searching with LIKE")
+		return false, dropFTSTriggers(db, table)
+	}
+
+	triggers := ftsTriggers(table)
+	statements := []string{
+		"CREATE TRIGGER IF NOT EXISTS " + triggers[0] + " AFTER INSERT ON " + table + ` BEGIN
+			INSERT INTO ` + fts + `(rowid, name, email) VALUES (new.id, new.name, new.email);
+		END`,
+		"CREATE TRIGGER IF NOT EXISTS " + triggers[1] + " AFTER DELETE ON " + table + ` BEGIN
+			INSERT INTO ` + fts + `(` + fts + `, rowid, name, email) VALUES ('delete', old.id, old.name, old.email);
+		END`,
+		"CREATE TRIGGER IF NOT EXISTS " + triggers[2] + " AFTER UPDATE OF name, email ON " + table + ` BEGIN
+			INSERT INTO ` + fts + `(` + fts + `, rowid, name, email) VALUES ('delete', old.id, old.name, old.email);
+			INSERT INTO ` + fts + `(rowid, name, email) VALUES (new.id, new.name, new.email);
+		END`,
+		"INSERT INTO " + fts + "(" + fts + ") VALUES ('rebuild')",
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			return false, fmt.Errorf("set up %s: %w", fts, err)
+		}
+	}
+	return true, nil
+}
+
+// dropFTSTriggers removes the triggers maintaining the full-text index of
+// table, leaving the index itself in place. While they are missing, the
+// index goes stale; setupFTS rebuilds it.
+func dropFTSTriggers(db *sql.DB, table string) error {
+	for _, trigger := range ftsTriggers(table) {
+		if _, err := db.Exec("DROP TRIGGER IF EXISTS " + trigger); err != nil {
+			return fmt.Errorf("drop trigger %s: %w", trigger, err)
+		}
+	}
+	return nil
+}
+
+// checkFTS reports whether the full-text index of table exists and can be
+// queried, for when the schema is managed externally. It logs a warning
+// when it can't.
+func checkFTS(db *sql.DB, table string) bool {
+	if _, err := db.Exec("SELECT rowid FROM " + ftsTable(table) + " LIMIT 0"); err != nil {
+		slog.Warn("sqlite_fts is on but the full-text index is not usable; searching with LIKE", slog.String("error", err.Error()))
+		return false
+	}
+	return true
+}
+
+// ftsQuery turns a search term into an FTS5 query matching rows that contain
+// every word of q as a prefix of one of their tokens, in name or email. Each
+// word is quoted, so FTS5 operators in q are matched literally. It returns
+// "" when q has no words.
+func ftsQuery(q string) string {
+	var terms []string
+	for _, word := range strings.Fields(q) {
+		terms = append(terms, `"`+strings.ReplaceAll(word, `"`, `""`)+`"*`)
+	}
+	return strings.Join(terms, " ")
+}
@@ -0,0 +1,143 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gourav224/student-api/internal/config"
+)
+
+// openSearch opens a temporary store, with the full-text index if fts is
+// set, holding the same few students.
+func openSearch(t *testing.T, fts bool) *Sqlite {
+	t.Helper()
+	s, err := New(&config.Config{
+		StoragePath:            filepath.Join(t.TempDir(), "students.db"),
+		StorageTable:           "students",
+		StorageConnectAttempts: 1,
+		StorageBusyBackoff:     config.Duration(5 * time.Millisecond),
+		SQLiteFTS:              fts,
+		AutoMigrate:            true,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	for _, st := range []struct{ name, email string }{
+		{"John Doe", "john@example.com"},
+		{"Jane Doe", "jane@school.edu"},
+		{"Johanna Smith", "jsmith@example.com"},
+		{"Bob Stone", "bob_100%@example.com"},
+	} {
+		if _, err := s.CreateStudent(context.Background(), st.name, st.email, 20); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+// searchNames returns the names of the students s finds for q.
+func searchNames(t *testing.T, s *Sqlite, q string) []string {
+	t.Helper()
+	students, err := s.SearchStudents(context.Background(), q, 10)
+	if err != nil {
+		t.Fatalf("SearchStudents(%q): %v", q, err)
+	}
+	var names []string
+	for _, st := range students {
+		names = append(names, st.Name)
+	}
+	return names
+}
+
+func TestSearchFTSMatchesLike(t *testing.T) {
+	like := openSearch(t, false)
+	fts := openSearch(t, true)
+	if !fts.fts {
+		t.Skip("SQLite built without FTS5; run with -tags sqlite_fts5")
+	}
+
+	// Whole words and word prefixes are found by both
+	for _, q := range []string{"doe", "Jane", "joh", "school", "smith", "nobody"} {
+		if l, f := searchNames(t, like, q), searchNames(t, fts, q); !slices.Equal(l, f) {
+			t.Errorf("search %q: LIKE found %v, FTS found %v", q, l, f)
+		}
+	}
+
+	// Only FTS matches words in any order, and only LIKE matches inside words
+	if got, want := searchNames(t, fts, "do jo"), []string{"John Doe"}; !slices.Equal(got, want) {
+		t.Errorf("FTS search %q = %v, want %v", "do jo", got, want)
+	}
+	if got := searchNames(t, like, "do jo"); got != nil {
+		t.Errorf("LIKE search %q = %v, want nothing", "do jo", got)
+	}
+	if got, want := searchNames(t, like, "ohn"), []string{"John Doe"}; !slices.Equal(got, want) {
+		t.Errorf("LIKE search %q = %v, want %v", "ohn", got, want)
+	}
+	if got := searchNames(t, fts, "ohn"); got != nil {
+		t.Errorf("FTS search %q = %v, want nothing", "ohn", got)
+	}
+}
+
+func TestSearchFTSFollowsWrites(t *testing.T) {
+	s := openSearch(t, true)
+	if !s.fts {
+		t.Skip("SQLite built without FTS5; run with -tags sqlite_fts5")
+	}
+	ctx := context.Background()
+
+	if _, err := s.Update(ctx, 1, 0, map[string]any{"name": "Johnny Walker"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := searchNames(t, s, "walker"), []string{"Johnny Walker"}; !slices.Equal(got, want) {
+		t.Errorf("search after update = %v, want %v", got, want)
+	}
+	if got, want := searchNames(t, s, "doe"), []string{"Jane Doe"}; !slices.Equal(got, want) {
+		t.Errorf("search for the old name = %v, want %v", got, want)
+	}
+
+	if _, err := s.Delete(ctx, 2, 0); err != nil {
+		t.Fatal(err)
+	}
+	if got := searchNames(t, s, "doe"); got != nil {
+		t.Errorf("search after delete = %v, want nothing", got)
+	}
+}
+
+func TestSearchLike(t *testing.T) {
+	// Without FTS5 in the build, sqlite_fts falls back to LIKE
+	for _, fts := range []bool{false, true} {
+		s := openSearch(t, fts)
+		if s.fts {
+			continue
+		}
+		if got, want := searchNames(t, s, "100%"), []string{"Bob Stone"}; !slices.Equal(got, want) {
+			t.Errorf("search %q = %v, want %v", "100%", got, want)
+		}
+		if got := searchNames(t, s, "_1"); !slices.Equal(got, []string{"Bob Stone"}) {
+			t.Errorf("search %q = %v, want only the literal match", "_1", got)
+		}
+		if got, want := searchNames(t, s, "doe"), []string{"John Doe", "Jane Doe"}; !slices.Equal(got, want) {
+			t.Errorf("search %q = %v, want %v", "doe", got, want)
+		}
+	}
+}
+
+func TestFTSQuery(t *testing.T) {
+	tests := []struct{ q, want string }{
+		{"", ""},
+		{"   ", ""},
+		{"jo", `"jo"*`},
+		{" jo  do ", `"jo"* "do"*`},
+		{`a"b OR c`, `"a""b"* "OR"* "c"*`},
+	}
+	for _, tt := range tests {
+		if got := ftsQuery(tt.q); got != tt.want {
+			t.Errorf("ftsQuery(%q) = %q, want %q", tt.q, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/gourav224/student-api/internal/logging"
+	"github.com/mattn/go-sqlite3"
+)
+
+// maxBusyBackoff caps the wait between two attempts of a locked operation.
+const maxBusyBackoff = time.Second
+
+// isBusy reports whether err means the database or a table was locked by
+// another connection (SQLITE_BUSY or SQLITE_LOCKED), which is transient.
+func isBusy(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) &&
+		(sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked)
+}
+
+// retryBusy runs op and, while it fails with a lock error, retries it up to
+// s.busyRetries more times, waiting s.busyBackoff before the first retry and
+// doubling the wait after each one (capped at 1s). Other errors and the last
+// lock error are returned as is.
+//
+// op must be safe to repeat as a whole. On a transaction-bound Sqlite op runs
+// once: a single statement can't be retried inside a transaction, so the
+// caller retries the whole transaction instead.
+func retryBusy[T any](ctx context.Context, s *Sqlite, op func() (T, error)) (T, error) {
+	if s.tx != nil {
+		return op()
+	}
+
+	wait := s.busyBackoff
+	for attempt := 1; ; attempt++ {
+		v, err := op()
+		if err == nil || !isBusy(err) || attempt > s.busyRetries {
+			return v, err
+		}
+
+		logging.FromContext(ctx).Debug("sqlite database is locked, retrying",
+			slog.Int("attempt", attempt),
+			slog.Int("max_retries", s.busyRetries),
+			slog.Duration("retry_in", wait),
+		)
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		}
+		wait = min(wait*2, maxBusyBackoff)
+	}
+}
@@ -0,0 +1,80 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gourav224/student-api/internal/config"
+)
+
+// lockedStore opens a store that fails fast on locks, so they reach
+// retryBusy, and locks its database from another connection for hold.
+func lockedStore(t *testing.T, retries int, hold time.Duration) *Sqlite {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "students.db")
+	s, err := New(&config.Config{
+		StoragePath:            path + "?_busy_timeout=0",
+		StorageTable:           "students",
+		StorageConnectAttempts: 1,
+		StorageBusyRetries:     retries,
+		StorageBusyBackoff:     config.Duration(5 * time.Millisecond),
+		AutoMigrate:            true,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	other, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { other.Close() })
+	conn, err := other.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.ExecContext(context.Background(), "BEGIN EXCLUSIVE"); err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		time.Sleep(hold)
+		conn.ExecContext(context.Background(), "COMMIT")
+		conn.Close()
+	}()
+	return s
+}
+
+func TestRetryBusyEventuallySucceeds(t *testing.T) {
+	s := lockedStore(t, 10, 50*time.Millisecond)
+
+	id, err := s.CreateStudent(context.Background(), "Jane Doe", "jane@example.com", 20)
+	if err != nil {
+		t.Fatalf("CreateStudent while the database was locked: %v", err)
+	}
+	if _, err := s.GetStudentById(context.Background(), id); err != nil {
+		t.Errorf("GetStudentById after the retried create: %v", err)
+	}
+}
+
+func TestRetryBusyGivesUp(t *testing.T) {
+	s := lockedStore(t, 0, 200*time.Millisecond)
+
+	_, err := s.CreateStudent(context.Background(), "Jane Doe", "jane@example.com", 20)
+	if !isBusy(err) {
+		t.Errorf("CreateStudent without retries = %v, want a lock error", err)
+	}
+}
+
+func TestRetryBusyHonorsContext(t *testing.T) {
+	s := lockedStore(t, 10, time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	if _, err := s.CreateStudent(ctx, "Jane Doe", "jane@example.com", 20); err == nil {
+		t.Error("CreateStudent succeeded past its deadline")
+	}
+}
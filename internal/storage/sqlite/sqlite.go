@@ -1,63 +1,338 @@
 package sqlite
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/gourav224/student-api/internal/config"
+	"github.com/gourav224/student-api/internal/storage"
 	"github.com/gourav224/student-api/internal/types"
-	_ "github.com/mattn/go-sqlite3" // Import for side effects: registers the SQLite3 driver
+	"github.com/mattn/go-sqlite3" // Also registers the SQLite3 driver
 )
 
 // Sqlite wraps the SQL database connection.
 type Sqlite struct {
 	Db *sql.DB
+
+	table       string // validated table name, interpolated into every query
+	maxStudents int    // quota enforced by CreateStudent; zero disables it
+
+	busyRetries int           // retries of an operation that hit a lock, see retryBusy
+	busyBackoff time.Duration // wait before the first of those retries
+
+	fts bool // search through the full-text index, see fts.go
+
+	q  queryer // Db, or tx for transaction-bound copies
+	tx *sql.Tx // non-nil when bound to a transaction by WithTx
+}
+
+// queryer is the subset of *sql.DB and *sql.Tx used by the storage methods,
+// so the same code runs inside or outside a transaction.
+type queryer interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func init() {
+	storage.Register("sqlite", func(cfg *config.Config) (storage.Storage, error) {
+		return New(cfg)
+	})
 }
 
 // New initializes and returns a new SQLite connection.
-// It also ensures the 'students' table exists before returning.
+// Students are kept in cfg.StorageTable (storage.DefaultTable if empty),
+// which must be a plain SQL identifier. With cfg.AutoMigrate it creates and
+// migrates the table as needed; otherwise it only checks that the schema is
+// in place.
 func New(cfg *config.Config) (*Sqlite, error) {
+	if cfg.StoragePath == "" {
+		return nil, fmt.Errorf("storage_path is required for the sqlite driver")
+	}
+
+	table := cfg.StorageTable
+	if table == "" {
+		table = storage.DefaultTable
+	}
+	if err := storage.ValidateIdentifier(table); err != nil {
+		return nil, err
+	}
+
+	// SQLite creates the file but not its directory
+	if dir := filepath.Dir(cfg.StoragePath); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create sqlite db directory %q: %w", dir, err)
+		}
+	}
+
 	// Open database file (creates if not exists)
 	db, err := sql.Open("sqlite3", cfg.StoragePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open sqlite db: %w", err)
 	}
 
-	// Verify the database connection
-	if err := db.Ping(); err != nil {
+	// Verify the database connection, retrying while it comes up
+	if err := storage.PingWithRetry(context.Background(), db, cfg.StorageConnectAttempts, cfg.StorageConnectInterval.Std()); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("failed to ping sqlite db: %w", err)
 	}
 
-	// Create the students table if it doesn't exist
-	createTableQuery := `
-	CREATE TABLE IF NOT EXISTS students (
+	var fts bool
+	if cfg.AutoMigrate {
+		// Create the students table if it doesn't exist
+		if _, err = db.Exec(createTable(table)); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to create %s table: %w", table, err)
+		}
+
+		// Bring tables created by older versions up to date
+		if err := migrate(db, table); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to migrate %s table: %w", table, err)
+		}
+
+		// The full-text index is optional; without it its triggers must go
+		if cfg.SQLiteFTS {
+			if fts, err = setupFTS(db, table); err != nil {
+				db.Close()
+				return nil, fmt.Errorf("failed to set up full-text search: %w", err)
+			}
+		} else if err := dropFTSTriggers(db, table); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to disable full-text search: %w", err)
+		}
+	} else if err := checkSchema(db, table); err != nil {
+		// Schema is managed externally, so fail fast rather than create a guess
+		db.Close()
+		return nil, fmt.Errorf("auto_migrate is off and the schema is not ready: %w", err)
+	} else if cfg.SQLiteFTS {
+		fts = checkFTS(db, table)
+	}
+
+	return &Sqlite{
+		Db:          db,
+		q:           db,
+		table:       table,
+		maxStudents: cfg.MaxStudents,
+		busyRetries: cfg.StorageBusyRetries,
+		busyBackoff: cfg.StorageBusyBackoff.Std(),
+		fts:         fts,
+	}, nil
+}
+
+// createTable returns the statement creating the students table named table.
+// Emails are kept unique by the index from emailIndex, which migrate creates.
+func createTable(table string) string {
+	return `
+	CREATE TABLE IF NOT EXISTS ` + table + ` (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		email TEXT NOT NULL UNIQUE,
+		email TEXT NOT NULL,
 		name TEXT NOT NULL,
-		age INTEGER NOT NULL
+		age INTEGER NOT NULL,
+		avatar_url TEXT,
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL,
+		version INTEGER NOT NULL DEFAULT 1,
+		deleted_at DATETIME
 	);`
+}
+
+// emailIndexName returns the name of the index keeping the emails of table unique.
+func emailIndexName(table string) string {
+	return table + "_email"
+}
+
+// emailIndex returns the statement creating the unique index on the emails
+// of table. It is partial, leaving out soft-deleted students, so their emails
+// can be taken by new students.
+func emailIndex(table string) string {
+	return "CREATE UNIQUE INDEX IF NOT EXISTS " + emailIndexName(table) + " ON " + table + " (email) WHERE " + storage.NotDeleted
+}
+
+// addedColumns are the columns added to the students table after its first
+// release, with their definitions. migrate adds any that are missing.
+// SQLite can't add a NOT NULL column without a constant default, so columns
+// marked backfill are added as nullable and existing rows get the migration
+// time instead.
+var addedColumns = []struct {
+	name, definition string
+	backfill         bool
+}{
+	{"avatar_url", "TEXT", false},
+	{"created_at", "DATETIME", true},
+	{"updated_at", "DATETIME", true},
+	{"version", "INTEGER NOT NULL DEFAULT 1", false},
+	{"deleted_at", "DATETIME", false},
+}
+
+// migrate adds the columns in addedColumns that an existing table lacks, and
+// creates the email index; see migrateEmailIndex.
+func migrate(db *sql.DB, table string) error {
+	now := storage.Now()
+	for _, col := range addedColumns {
+		exists, err := hasColumn(db, table, col.name)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		if _, err := db.Exec("ALTER TABLE " + table + " ADD COLUMN " + col.name + " " + col.definition); err != nil {
+			return fmt.Errorf("add column %s: %w", col.name, err)
+		}
+		if col.backfill {
+			if _, err := db.Exec("UPDATE "+table+" SET "+col.name+" = ? WHERE "+col.name+" IS NULL", now); err != nil {
+				return fmt.Errorf("backfill column %s: %w", col.name, err)
+			}
+		}
+	}
+	return migrateEmailIndex(db, table)
+}
+
+// migrateEmailIndex creates the index from emailIndex. Tables created by
+// older versions have a UNIQUE constraint on email instead, which also
+// covers soft-deleted students; SQLite can't drop a constraint, so such a
+// table is first rebuilt without it, keeping its rows and its AUTOINCREMENT
+// counter.
+func migrateEmailIndex(db *sql.DB, table string) error {
+	var constraints int
+	if err := db.QueryRow("SELECT COUNT(*) FROM pragma_index_list(?) WHERE origin = 'u'", table).Scan(&constraints); err != nil {
+		return err
+	}
+	if constraints > 0 {
+		if err := rebuildTable(db, table); err != nil {
+			return fmt.Errorf("drop email constraint: %w", err)
+		}
+	}
+
+	if _, err := db.Exec(emailIndex(table)); err != nil {
+		return fmt.Errorf("create email index: %w", err)
+	}
+	return nil
+}
+
+// rebuildTable replaces table with one created by createTable, holding the
+// same rows, in a single transaction.
+func rebuildTable(db *sql.DB, table string) error {
+	columns := []string{"id", "email", "name", "age"}
+	for _, col := range addedColumns {
+		columns = append(columns, col.name)
+	}
+	list := strings.Join(columns, ", ")
+	rebuilt := table + "_rebuilt"
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	statements := []string{
+		createTable(rebuilt),
+		"INSERT INTO " + rebuilt + " (" + list + ") SELECT " + list + " FROM " + table,
+		"UPDATE sqlite_sequence SET seq = (SELECT seq FROM sqlite_sequence WHERE name = '" + table + "') WHERE name = '" + rebuilt + "'",
+		"DROP TABLE " + table,
+		"ALTER TABLE " + rebuilt + " RENAME TO " + table,
+	}
+	for _, stmt := range statements {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// checkSchema verifies, without changing anything, that table exists and has
+// every column in addedColumns and the email index. It is used instead of
+// creating and migrating the table when auto_migrate is off.
+func checkSchema(db *sql.DB, table string) error {
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&count); err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("table %s does not exist", table)
+	}
+
+	for _, col := range addedColumns {
+		exists, err := hasColumn(db, table, col.name)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("table %s has no column %s", table, col.name)
+		}
+	}
 
-	if _, err = db.Exec(createTableQuery); err != nil {
-		return nil, fmt.Errorf("failed to create students table: %w", err)
+	index := emailIndexName(table)
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND name = ?", index).Scan(&count); err != nil {
+		return err
 	}
+	if count == 0 {
+		return fmt.Errorf("table %s has no index %s", table, index)
+	}
+	return nil
+}
+
+// hasColumn reports whether table has a column named column.
+func hasColumn(db *sql.DB, table, column string) (bool, error) {
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", table, column).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
 
-	return &Sqlite{Db: db}, nil
+// CreateStudent inserts a new student record into the students table.
+// Returns the ID of the newly created student, storage.ErrDuplicateEmail
+// if the email is already taken, or storage.ErrQuotaExceeded if the table
+// already holds the configured maximum number of students.
+// Email uniqueness is left to the database index, with no pre-check, so of
+// several concurrent creates with the same email exactly one succeeds.
+// Soft-deleted students neither hold on to their email nor count towards the
+// quota. A locked database is retried (see retryBusy).
+func (s *Sqlite) CreateStudent(ctx context.Context, name string, email string, age int) (int64, error) {
+	return retryBusy(ctx, s, func() (int64, error) {
+		return s.createStudent(ctx, name, email, age)
+	})
 }
 
-// CreateStudent inserts a new student record into the 'students' table.
-// Returns the ID of the newly created student.
-func (s *Sqlite) CreateStudent(name string, email string, age int) (int64, error) {
+func (s *Sqlite) createStudent(ctx context.Context, name string, email string, age int) (int64, error) {
+	now := storage.Now()
+	query := "INSERT INTO " + s.table + " (name, email, age, created_at, updated_at) VALUES (?, ?, ?, ?, ?)"
+	args := []any{name, email, age, now, now}
+	if s.maxStudents > 0 {
+		// Check the quota and insert in one statement, so concurrent creates
+		// can't both pass the check and overshoot it
+		query = "INSERT INTO " + s.table + " (name, email, age, created_at, updated_at) SELECT ?, ?, ?, ?, ? FROM (SELECT COUNT(*) AS n FROM " + s.table + " WHERE " + storage.NotDeleted + ") AS c WHERE c.n < ?"
+		args = append(args, s.maxStudents)
+	}
+
 	// Prepare the INSERT statement
-	stmt, err := s.Db.Prepare("INSERT INTO students (name, email, age) VALUES (?, ?, ?)")
+	stmt, err := s.q.PrepareContext(ctx, query)
 	if err != nil {
 		return 0, err
 	}
 	defer stmt.Close()
 
 	// Execute the statement with provided parameters
-	res, err := stmt.Exec(name, email, age)
+	res, err := stmt.ExecContext(ctx, args...)
 	if err != nil {
-		return 0, err
+		return 0, translateError(err)
+	}
+
+	// With a quota, no inserted row means it was reached
+	if s.maxStudents > 0 {
+		if n, err := res.RowsAffected(); err != nil {
+			return 0, err
+		} else if n == 0 {
+			return 0, fmt.Errorf("%w: at most %d students are allowed", storage.ErrQuotaExceeded, s.maxStudents)
+		}
 	}
 
 	// Retrieve the last inserted ID
@@ -70,10 +345,17 @@ func (s *Sqlite) CreateStudent(name string, email string, age int) (int64, error
 }
 
 // GetStudentById retrieves a single student record by its ID.
-// Returns a Student struct or an error if not found.
-func (s *Sqlite) GetStudentById(id int64) (types.Student, error) {
+// Returns a Student struct, or storage.ErrNotFound if no row matches or the
+// student is soft-deleted.
+func (s *Sqlite) GetStudentById(ctx context.Context, id int64) (types.Student, error) {
+	return retryBusy(ctx, s, func() (types.Student, error) {
+		return s.getStudentById(ctx, id)
+	})
+}
+
+func (s *Sqlite) getStudentById(ctx context.Context, id int64) (types.Student, error) {
 	// Prepare the SELECT statement
-	stmt, err := s.Db.Prepare("SELECT id, email, name, age FROM students WHERE id = ? LIMIT 1")
+	stmt, err := s.q.PrepareContext(ctx, "SELECT "+strings.Join(storage.StudentColumns, ", ")+" FROM "+s.table+" WHERE id = ? AND "+storage.NotDeleted+" LIMIT 1")
 	if err != nil {
 		return types.Student{}, err
 	}
@@ -82,7 +364,10 @@ func (s *Sqlite) GetStudentById(id int64) (types.Student, error) {
 	var student types.Student
 
 	// Query a single row and scan the result into the student struct
-	err = stmt.QueryRow(id).Scan(&student.Id, &student.Email, &student.Name, &student.Age)
+	err = stmt.QueryRowContext(ctx, id).Scan(storage.StudentScanTargets(&student, storage.StudentColumns)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.Student{}, fmt.Errorf("%w: id %d", storage.ErrNotFound, id)
+	}
 	if err != nil {
 		return types.Student{}, err
 	}
@@ -90,18 +375,152 @@ func (s *Sqlite) GetStudentById(id int64) (types.Student, error) {
 	return student, nil
 }
 
-// GetStudents retrieves all student records from the 'students' table.
+// Exists reports whether a student with the given id exists, and isn't
+// soft-deleted, using a SELECT 1 probe, which is cheaper than GetStudentById
+// when only existence matters. A locked database is retried (see retryBusy).
+func (s *Sqlite) Exists(ctx context.Context, id int64) (bool, error) {
+	return retryBusy(ctx, s, func() (bool, error) {
+		return s.exists(ctx, id)
+	})
+}
+
+func (s *Sqlite) exists(ctx context.Context, id int64) (bool, error) {
+	stmt, err := s.q.PrepareContext(ctx, "SELECT 1 FROM "+s.table+" WHERE id = ? AND "+storage.NotDeleted+" LIMIT 1")
+	if err != nil {
+		return false, err
+	}
+	defer stmt.Close()
+
+	var one int
+	err = stmt.QueryRowContext(ctx, id).Scan(&one)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// requireExists returns storage.ErrNotFound unless student id exists.
+func (s *Sqlite) requireExists(ctx context.Context, id int64) error {
+	exists, err := s.Exists(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("%w: id %d", storage.ErrNotFound, id)
+	}
+	return nil
+}
+
+// GetStudents retrieves student records from the students table ordered by id.
+// opts narrows the result to a keyset page (ids after opts.AfterId, at most opts.Limit rows),
+// to the students matching opts.Filter, and optionally to a subset of columns;
+// unselected fields are left zero.
 // Returns a slice of Student structs or an error.
-func (s *Sqlite) GetStudents() ([]types.Student, error) {
+func (s *Sqlite) GetStudents(ctx context.Context, opts storage.ListOptions) ([]types.Student, error) {
+	// Each attempt collects into a fresh slice, so a retry starts over
+	return retryBusy(ctx, s, func() ([]types.Student, error) {
+		var students []types.Student
+
+		err := s.EachStudent(ctx, opts, func(student types.Student) error {
+			students = append(students, student)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return students, nil
+	})
+}
+
+// EachStudent runs the GetStudents query and calls fn for each row as it is read,
+// so callers can stream large result sets. It is not retried on a locked
+// database, since fn may already have seen some rows.
+func (s *Sqlite) EachStudent(ctx context.Context, opts storage.ListOptions, fn func(types.Student) error) error {
+	columns := storage.SelectColumns(opts.Fields)
+
+	where, whereArgs := storage.WhereClause(opts.Filter)
+	query := "SELECT " + strings.Join(columns, ", ") + " FROM " + s.table + " WHERE id > ? AND " + where + " ORDER BY id"
+	args := append([]any{opts.AfterId}, whereArgs...)
+	if opts.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, opts.Limit)
+		if opts.Offset > 0 {
+			query += " OFFSET ?"
+			args = append(args, opts.Offset)
+		}
+	}
+
 	// Prepare the SELECT statement
-	stmt, err := s.Db.Prepare("SELECT id, email, name, age FROM students")
+	stmt, err := s.q.PrepareContext(ctx, query)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer stmt.Close()
 
 	// Execute the query to get multiple rows
-	rows, err := stmt.Query()
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	// Iterate over the result set and hand each row to fn
+	for rows.Next() {
+		var student types.Student
+		if err := rows.Scan(storage.StudentScanTargets(&student, columns)...); err != nil {
+			return err
+		}
+		if err := fn(student); err != nil {
+			return err
+		}
+	}
+
+	// Check for iteration errors
+	return rows.Err()
+}
+
+// SearchStudents returns up to limit students whose name or email contains q,
+// ordered by id. The term is matched literally; LIKE wildcards in q are escaped.
+//
+// With the full-text index (see fts.go), matching is by words instead: a
+// student matches when every word of q starts one of the words of their name
+// or email, so "jo do" finds "John Doe" but "ohn" finds nothing.
+func (s *Sqlite) SearchStudents(ctx context.Context, q string, limit int) ([]types.Student, error) {
+	return retryBusy(ctx, s, func() ([]types.Student, error) {
+		return s.searchStudents(ctx, q, limit)
+	})
+}
+
+func (s *Sqlite) searchStudents(ctx context.Context, q string, limit int) ([]types.Student, error) {
+	// Prepare the SELECT statement, matching through the full-text index when
+	// there is one and q has words to match
+	var query string
+	var args []any
+	if match := ftsQuery(q); s.fts && match != "" {
+		query = "SELECT " + strings.Join(storage.StudentColumns, ", ") + " FROM " + s.table + `
+		WHERE id IN (SELECT rowid FROM ` + ftsTable(s.table) + ` WHERE ` + ftsTable(s.table) + ` MATCH ?) AND ` + storage.NotDeleted + `
+		ORDER BY id LIMIT ?`
+		args = []any{match, limit}
+	} else {
+		query = "SELECT " + strings.Join(storage.StudentColumns, ", ") + " FROM " + s.table + `
+		WHERE (name LIKE ? ESCAPE '\' OR email LIKE ? ESCAPE '\') AND ` + storage.NotDeleted + `
+		ORDER BY id LIMIT ?`
+		pattern := "%" + storage.EscapeLike(q) + "%"
+		args = []any{pattern, pattern, limit}
+	}
+
+	stmt, err := s.q.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -112,7 +531,7 @@ func (s *Sqlite) GetStudents() ([]types.Student, error) {
 	// Iterate over the result set and map each row to a Student struct
 	for rows.Next() {
 		var student types.Student
-		if err := rows.Scan(&student.Id, &student.Email, &student.Name, &student.Age); err != nil {
+		if err := rows.Scan(storage.StudentScanTargets(&student, storage.StudentColumns)...); err != nil {
 			return nil, err
 		}
 		students = append(students, student)
@@ -126,78 +545,219 @@ func (s *Sqlite) GetStudents() ([]types.Student, error) {
 	return students, nil
 }
 
+// CountStudents returns the number of students matching filter with a single
+// COUNT(*) query. A locked database is retried (see retryBusy).
+func (s *Sqlite) CountStudents(ctx context.Context, filter storage.StudentFilter) (int64, error) {
+	where, args := storage.WhereClause(filter)
+	query := "SELECT COUNT(*) FROM " + s.table + " WHERE " + where
+
+	return retryBusy(ctx, s, func() (int64, error) {
+		stmt, err := s.q.PrepareContext(ctx, query)
+		if err != nil {
+			return 0, err
+		}
+		defer stmt.Close()
+
+		var count int64
+		err = stmt.QueryRowContext(ctx, args...).Scan(&count)
+		return count, err
+	})
+}
+
+// AgeDistribution counts students per age with a single GROUP BY query,
+// so callers can aggregate without fetching every row.
+func (s *Sqlite) AgeDistribution(ctx context.Context) (map[int]int, error) {
+	return retryBusy(ctx, s, func() (map[int]int, error) {
+		return s.ageDistribution(ctx)
+	})
+}
+
+func (s *Sqlite) ageDistribution(ctx context.Context) (map[int]int, error) {
+	// Prepare the aggregation statement
+	stmt, err := s.q.PrepareContext(ctx, "SELECT age, COUNT(*) FROM "+s.table+" WHERE "+storage.NotDeleted+" GROUP BY age")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	distribution := map[int]int{}
+	for rows.Next() {
+		var age, count int
+		if err := rows.Scan(&age, &count); err != nil {
+			return nil, err
+		}
+		distribution[age] = count
+	}
+
+	// Check for iteration errors
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return distribution, nil
+}
+
 // Update modifies one or more fields of a student record.
 // Accepts a map[string]any so the user can update a single field or multiple fields.
 // Builds a dynamic SQL UPDATE statement using only the provided fields.
-// Returns the updated student or an error if the student does not exist or update fails.
-func (s *Sqlite) Update(id int64, updates map[string]any) (types.Student, error) {
+// Email uniqueness is enforced by the database constraint alone, so concurrent
+// updates can't both pass a check; a violation returns storage.ErrDuplicateEmail.
+// Returns the updated student, storage.ErrNoFieldsToUpdate for empty updates,
+// storage.ErrNotFound if the student does not exist, or
+// storage.ErrVersionConflict if a non-zero version is no longer current.
+func (s *Sqlite) Update(ctx context.Context, id int64, version int64, updates map[string]any) (types.Student, error) {
 
 	// Ensure at least one field is being updated
 	if len(updates) == 0 {
-		return types.Student{}, fmt.Errorf("no fields to update")
+		return types.Student{}, storage.ErrNoFieldsToUpdate
 	}
 
+	// Run the existence check, update and re-read in one transaction so the
+	// returned record is exactly what this update produced. A locked database
+	// retries the whole transaction.
+	return retryBusy(ctx, s, func() (types.Student, error) {
+		var student types.Student
+		err := s.WithTx(ctx, func(txStorage storage.Storage) error {
+			var err error
+			student, err = txStorage.(*Sqlite).update(ctx, id, version, updates)
+			return err
+		})
+		return student, err
+	})
+}
+
+// update performs Update's work on an already transaction-bound Sqlite.
+func (s *Sqlite) update(ctx context.Context, id int64, version int64, updates map[string]any) (types.Student, error) {
 	// Check if student exists
-	_, err := s.GetStudentById(id)
-	if err != nil {
+	if err := s.requireExists(ctx, id); err != nil {
 		return types.Student{}, err
 	}
 
-	// Start building dynamic UPDATE query
-	query := "UPDATE students SET "
+	// Build dynamic UPDATE query from the provided fields
+	query, args := storage.BuildUpdateQuery(s.table, id, version, updates)
 
-	args := []any{}
-	i := 0
+	// Prepare the dynamic UPDATE statement
+	stmt, err := s.q.PrepareContext(ctx, query)
+	if err != nil {
+		return types.Student{}, err
+	}
+	defer stmt.Close()
+
+	// Execute UPDATE with values
+	res, err := stmt.ExecContext(ctx, args...)
+	if err != nil {
+		return types.Student{}, translateError(err)
+	}
 
-	// Add each field to SQL query
-	for k, v := range updates {
-		if i > 0 {
-			query += ", "
+	// The student exists, so no matched row means its version has moved on.
+	if version != 0 {
+		n, err := res.RowsAffected()
+		if err != nil {
+			return types.Student{}, err
+		}
+		if n == 0 {
+			return types.Student{}, storage.ErrVersionConflict
 		}
-		query += k + " = ?"
-		args = append(args, v)
-		i++
 	}
 
-	// Add WHERE clause
-	query += " WHERE id = ?"
-	args = append(args, id)
+	// Return updated student
+	return s.GetStudentById(ctx, id)
+}
+
+// UpdateWhere sets the fields in updates on every student matching filter
+// with a single UPDATE statement, so the change is atomic.
+// Returns the number of rows changed, or storage.ErrNoFieldsToUpdate for
+// empty updates. A locked database is retried (see retryBusy).
+func (s *Sqlite) UpdateWhere(ctx context.Context, filter storage.StudentFilter, updates map[string]any) (int64, error) {
+	if len(updates) == 0 {
+		return 0, storage.ErrNoFieldsToUpdate
+	}
 
-	// Prepare the dynamic UPDATE statement
-	stmt, err := s.Db.Prepare(query)
+	query, args := storage.BuildUpdateWhereQuery(s.table, filter, updates)
+	return retryBusy(ctx, s, func() (int64, error) {
+		res, err := s.q.ExecContext(ctx, query, args...)
+		if err != nil {
+			return 0, translateError(err)
+		}
+
+		return res.RowsAffected()
+	})
+}
+
+// IncrementAllAges adds by to every student's age. The range check and the
+// UPDATE run in one transaction, so either every age changes or none does.
+// A locked database retries the whole transaction.
+func (s *Sqlite) IncrementAllAges(ctx context.Context, by int) (int64, error) {
+	return retryBusy(ctx, s, func() (int64, error) {
+		var rowsUpdated int64
+		err := s.WithTx(ctx, func(txStorage storage.Storage) error {
+			var err error
+			rowsUpdated, err = txStorage.(*Sqlite).incrementAllAges(ctx, by)
+			return err
+		})
+		return rowsUpdated, err
+	})
+}
+
+// incrementAllAges performs IncrementAllAges's work on an already
+// transaction-bound Sqlite.
+func (s *Sqlite) incrementAllAges(ctx context.Context, by int) (int64, error) {
+	// Refuse the whole change if any student would end up out of range
+	stmt, err := s.q.PrepareContext(ctx, "SELECT COUNT(*) FROM "+s.table+" WHERE "+storage.NotDeleted+" AND age + ? NOT BETWEEN ? AND ?")
 	if err != nil {
-		return types.Student{}, err
+		return 0, err
 	}
 	defer stmt.Close()
 
-	// Execute UPDATE with values
-	_, err = stmt.Exec(args...)
+	var outOfRange int64
+	if err := stmt.QueryRowContext(ctx, by, storage.MinAge, storage.MaxAge).Scan(&outOfRange); err != nil {
+		return 0, err
+	}
+	if outOfRange > 0 {
+		return 0, fmt.Errorf("%w: %d student(s) would be outside %d-%d", storage.ErrAgeOutOfRange, outOfRange, storage.MinAge, storage.MaxAge)
+	}
+
+	res, err := s.q.ExecContext(ctx, "UPDATE "+s.table+" SET age = age + ?, updated_at = ?, version = version + 1 WHERE "+storage.NotDeleted, by, storage.Now())
 	if err != nil {
-		return types.Student{}, err
+		return 0, err
 	}
 
-	// Return updated student
-	return s.GetStudentById(id)
+	return res.RowsAffected()
+}
+
+// Delete soft-deletes a student by ID, setting its deleted_at.
+// Returns the number of rows deleted, or storage.ErrNotFound if no row matches
+// or the student is already deleted.
+// A non-zero version only deletes the student while it is still at that
+// version, returning storage.ErrVersionConflict otherwise.
+func (s *Sqlite) Delete(ctx context.Context, id int64, version int64) (int64, error) {
+	return retryBusy(ctx, s, func() (int64, error) {
+		return s.delete(ctx, id, version)
+	})
 }
 
-// Delete removes a student by ID from the database.
-// Returns the number of rows deleted (0 or 1).
-func (s *Sqlite) Delete(id int64) (int64, error) {
+func (s *Sqlite) delete(ctx context.Context, id int64, version int64) (int64, error) {
 	// Ensure the student exists before deleting
-	_, err := s.GetStudentById(id)
-	if err != nil {
-		return 0, fmt.Errorf("student not found: %w", err)
+	if err := s.requireExists(ctx, id); err != nil {
+		return 0, err
 	}
 
 	// Prepare DELETE query
-	stmt, err := s.Db.Prepare("DELETE FROM students WHERE id = ?")
+	query, args := storage.BuildDeleteQuery(s.table, id, version)
+	stmt, err := s.q.PrepareContext(ctx, query)
 	if err != nil {
 		return 0, err
 	}
 	defer stmt.Close()
 
 	// Execute the delete
-	res, err := stmt.Exec(id)
+	res, err := stmt.ExecContext(ctx, args...)
 	if err != nil {
 		return 0, err
 	}
@@ -208,5 +768,175 @@ func (s *Sqlite) Delete(id int64) (int64, error) {
 		return 0, err
 	}
 
+	// The existence check ran outside this statement, so a delete that
+	// removed nothing lost a race with another delete or, when conditional,
+	// found the student at another version
+	if rowsAffected == 0 {
+		if version == 0 {
+			return 0, fmt.Errorf("%w: id %d", storage.ErrNotFound, id)
+		}
+		if err := s.requireExists(ctx, id); err != nil {
+			return 0, err
+		}
+		return 0, storage.ErrVersionConflict
+	}
+
 	return rowsAffected, nil
 }
+
+// DeleteMany soft-deletes every student whose id is in ids using a single
+// UPDATE ... WHERE id IN (...) statement, so the batch is atomic.
+// Returns the number of rows deleted; ids that don't exist or are already
+// deleted are skipped.
+func (s *Sqlite) DeleteMany(ctx context.Context, ids []int64) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	query, args := storage.BuildDeleteManyQuery(s.table, ids)
+	return retryBusy(ctx, s, func() (int64, error) {
+		res, err := s.q.ExecContext(ctx, query, args...)
+		if err != nil {
+			return 0, err
+		}
+
+		return res.RowsAffected()
+	})
+}
+
+// DeleteAll permanently removes every student from the database, including
+// soft-deleted ones. Returns the number of rows deleted.
+func (s *Sqlite) DeleteAll(ctx context.Context) (int64, error) {
+	return retryBusy(ctx, s, func() (int64, error) {
+		res, err := s.q.ExecContext(ctx, "DELETE FROM "+s.table)
+		if err != nil {
+			return 0, err
+		}
+
+		return res.RowsAffected()
+	})
+}
+
+// Restore clears the deleted_at of a soft-deleted student and returns the
+// restored record. Returns storage.ErrNotFound unless the student exists and
+// is deleted, storage.ErrDuplicateEmail if another student has taken its
+// email since, or storage.ErrQuotaExceeded if the table already holds the
+// configured maximum number of students. The avatar removed by the delete is
+// not brought back. A locked database retries the whole transaction.
+func (s *Sqlite) Restore(ctx context.Context, id int64) (types.Student, error) {
+	return retryBusy(ctx, s, func() (types.Student, error) {
+		var student types.Student
+		err := s.WithTx(ctx, func(txStorage storage.Storage) error {
+			var err error
+			student, err = txStorage.(*Sqlite).restore(ctx, id)
+			return err
+		})
+		return student, err
+	})
+}
+
+// restore performs Restore's work on an already transaction-bound Sqlite.
+func (s *Sqlite) restore(ctx context.Context, id int64) (types.Student, error) {
+	query := "UPDATE " + s.table + " SET deleted_at = NULL, updated_at = ?, version = version + 1 WHERE id = ? AND deleted_at IS NOT NULL"
+	args := []any{storage.Now(), id}
+	if s.maxStudents > 0 {
+		// As in createStudent, check the quota in the same statement
+		query += " AND (SELECT COUNT(*) FROM " + s.table + " WHERE " + storage.NotDeleted + ") < ?"
+		args = append(args, s.maxStudents)
+	}
+
+	res, err := s.q.ExecContext(ctx, query, args...)
+	if err != nil {
+		return types.Student{}, translateError(err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return types.Student{}, err
+	}
+	if n == 0 {
+		// Either there was nothing to restore or the quota is reached
+		stmt, err := s.q.PrepareContext(ctx, "SELECT COUNT(*) FROM "+s.table+" WHERE id = ? AND deleted_at IS NOT NULL")
+		if err != nil {
+			return types.Student{}, err
+		}
+		defer stmt.Close()
+
+		var deleted int
+		if err := stmt.QueryRowContext(ctx, id).Scan(&deleted); err != nil {
+			return types.Student{}, err
+		}
+		if deleted > 0 && s.maxStudents > 0 {
+			return types.Student{}, fmt.Errorf("%w: at most %d students are allowed", storage.ErrQuotaExceeded, s.maxStudents)
+		}
+		return types.Student{}, fmt.Errorf("%w: no deleted student with id %d", storage.ErrNotFound, id)
+	}
+
+	return s.GetStudentById(ctx, id)
+}
+
+// WithTx runs fn inside a transaction, passing it a transaction-bound Sqlite.
+// The transaction is committed if fn returns nil and rolled back otherwise,
+// including when fn panics. If s is already bound to a transaction,
+// fn joins it instead of starting a new one.
+func (s *Sqlite) WithTx(ctx context.Context, fn func(txStorage storage.Storage) error) error {
+	if s.tx != nil {
+		return fn(s)
+	}
+
+	tx, err := s.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	// Roll back if fn panics, then let the panic continue
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(&Sqlite{Db: s.Db, table: s.table, maxStudents: s.maxStudents, fts: s.fts, q: tx, tx: tx}); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return errors.Join(err, fmt.Errorf("failed to roll back transaction: %w", rbErr))
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Ping verifies the database connection is still alive.
+func (s *Sqlite) Ping(ctx context.Context) error {
+	return s.Db.PingContext(ctx)
+}
+
+// Stats reports the state of the connection pool, which transaction-bound
+// copies share.
+func (s *Sqlite) Stats() sql.DBStats {
+	return s.Db.Stats()
+}
+
+// Close closes the underlying database connection.
+// It fails for transaction-bound copies, which don't own the connection.
+func (s *Sqlite) Close() error {
+	if s.tx != nil {
+		return errors.New("cannot close a transaction-bound storage")
+	}
+	return s.Db.Close()
+}
+
+// translateError maps driver-specific errors to storage sentinel errors.
+// Errors that have no storage-level meaning are returned unchanged.
+func translateError(err error) error {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+		return fmt.Errorf("%w: %v", storage.ErrDuplicateEmail, err)
+	}
+	return err
+}
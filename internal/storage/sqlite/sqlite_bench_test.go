@@ -0,0 +1,105 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/gourav224/student-api/internal/storage"
+)
+
+// seedStudents inserts students numbered from to to-1 in one transaction
+// and returns the id of the first.
+func seedStudents(tb testing.TB, s storage.Storage, from, to int) int64 {
+	tb.Helper()
+	var first int64
+	err := s.WithTx(context.Background(), func(tx storage.Storage) error {
+		for i := from; i < to; i++ {
+			id, err := tx.CreateStudent(context.Background(), "Student", fmt.Sprintf("student%d@example.com", i), 18+i%50)
+			if err != nil {
+				return err
+			}
+			if i == from {
+				first = id
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		tb.Fatalf("seeding students: %v", err)
+	}
+	return first
+}
+
+func BenchmarkCreateStudent(b *testing.B) {
+	s := openTemp(b)
+	ctx := context.Background()
+	b.ReportAllocs()
+	i := 0
+	for b.Loop() {
+		if _, err := s.CreateStudent(ctx, "Student", fmt.Sprintf("bench%d@example.com", i), 20); err != nil {
+			b.Fatal(err)
+		}
+		i++
+	}
+}
+
+func BenchmarkGetStudentById(b *testing.B) {
+	s := openTemp(b)
+	id := seedStudents(b, s, 0, 1)
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := s.GetStudentById(ctx, id); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkGetStudents lists every student of tables of growing size, and
+// the first page of 20 of the largest, to show what pagination saves.
+func BenchmarkGetStudents(b *testing.B) {
+	s := openTemp(b)
+	ctx := context.Background()
+	seeded := 0
+	for _, n := range []int{10, 100, 1000, 10000} {
+		seedStudents(b, s, seeded, n)
+		seeded = n
+		b.Run("all/n="+strconv.Itoa(n), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				students, err := s.GetStudents(ctx, storage.ListOptions{})
+				if err != nil {
+					b.Fatal(err)
+				}
+				if len(students) != n {
+					b.Fatalf("got %d students, want %d", len(students), n)
+				}
+			}
+		})
+	}
+	b.Run("page/n=10000", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := s.GetStudents(ctx, storage.ListOptions{Limit: 20}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkUpdate(b *testing.B) {
+	s := openTemp(b)
+	id := seedStudents(b, s, 0, 1)
+	ctx := context.Background()
+	b.ReportAllocs()
+	age := 0
+	for b.Loop() {
+		if _, err := s.Update(ctx, id, 0, map[string]any{"age": 18 + age%50}); err != nil {
+			b.Fatal(err)
+		}
+		age++
+	}
+}
@@ -0,0 +1,794 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gourav224/student-api/internal/config"
+	"github.com/gourav224/student-api/internal/storage"
+)
+
+// openTemp opens a SQLite database in a temporary directory that is
+// removed, with the database closed, when tb ends. Locked operations are
+// retried like in a configured server, since concurrent tests contend.
+func openTemp(tb testing.TB) *Sqlite {
+	tb.Helper()
+	s, err := New(&config.Config{
+		StoragePath:        filepath.Join(tb.TempDir(), "students.db"),
+		StorageBusyRetries: 10,
+		StorageBusyBackoff: config.Duration(5 * time.Millisecond),
+		AutoMigrate:        true,
+	})
+	if err != nil {
+		tb.Fatalf("New: %v", err)
+	}
+	tb.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestPing(t *testing.T) {
+	s := openTemp(t)
+	if err := s.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping on an open database: %v", err)
+	}
+
+	s.Close()
+	if err := s.Ping(context.Background()); err == nil {
+		t.Error("Ping on a closed database succeeded")
+	}
+}
+
+func TestSentinelErrors(t *testing.T) {
+	s := openTemp(t)
+	ctx := context.Background()
+	id, err := s.CreateStudent(ctx, "Jane Doe", "jane@example.com", 20)
+	if err != nil {
+		t.Fatalf("CreateStudent: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		call func() error
+		want error
+	}{
+		{"get missing", func() error { _, err := s.GetStudentById(ctx, id+1); return err }, storage.ErrNotFound},
+		{"update missing", func() error { _, err := s.Update(ctx, id+1, 0, map[string]any{"age": 21}); return err }, storage.ErrNotFound},
+		{"delete missing", func() error { _, err := s.Delete(ctx, id+1, 0); return err }, storage.ErrNotFound},
+		{"update nothing", func() error { _, err := s.Update(ctx, id, 0, map[string]any{}); return err }, storage.ErrNoFieldsToUpdate},
+		{"update stale version", func() error { _, err := s.Update(ctx, id, 2, map[string]any{"age": 21}); return err }, storage.ErrVersionConflict},
+		{"delete stale version", func() error { _, err := s.Delete(ctx, id, 2); return err }, storage.ErrVersionConflict},
+		{"create duplicate", func() error { _, err := s.CreateStudent(ctx, "Jane Roe", "jane@example.com", 22); return err }, storage.ErrDuplicateEmail},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestConcurrentUpdatesToSameEmail(t *testing.T) {
+	s := openTemp(t)
+	const n = 8
+	ids := make([]int64, n)
+	for i := range ids {
+		id, err := s.CreateStudent(context.Background(), "Student", fmt.Sprintf("student%d@example.com", i), 20)
+		if err != nil {
+			t.Fatalf("CreateStudent: %v", err)
+		}
+		ids[i] = id
+	}
+
+	// Every student tries to take the same address at once
+	errs := make(chan error, n)
+	var wg sync.WaitGroup
+	for _, id := range ids {
+		wg.Go(func() {
+			_, err := s.Update(context.Background(), id, 0, map[string]any{"email": "taken@example.com"})
+			errs <- err
+		})
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, storage.ErrDuplicateEmail):
+			t.Errorf("Update error = %v, want storage.ErrDuplicateEmail", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("%d updates succeeded, want exactly 1", succeeded)
+	}
+}
+
+func TestNewAddsMissingColumns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "students.db")
+
+	// A database created before avatar_url and the timestamps existed
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`CREATE TABLE students (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		email TEXT NOT NULL UNIQUE,
+		name TEXT NOT NULL,
+		age INTEGER NOT NULL
+	); INSERT INTO students (email, name, age) VALUES ('jane@example.com', 'Jane Doe', 20);`); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	s, err := New(&config.Config{StoragePath: path, AutoMigrate: true})
+	if err != nil {
+		t.Fatalf("New on an old database: %v", err)
+	}
+	defer s.Close()
+
+	student, err := s.GetStudentById(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("GetStudentById: %v", err)
+	}
+	if student.Name != "Jane Doe" || student.AvatarURL != nil {
+		t.Errorf("student = %+v, want the existing row without an avatar", student)
+	}
+	if student.CreatedAt.IsZero() || !student.UpdatedAt.Equal(student.CreatedAt.Time) {
+		t.Errorf("timestamps = %v, %v, want both backfilled with the migration time", student.CreatedAt, student.UpdatedAt)
+	}
+}
+
+func TestCustomTable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "students.db")
+	s, err := New(&config.Config{StoragePath: path, StorageTable: "pupils", AutoMigrate: true})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer s.Close()
+
+	id, err := s.CreateStudent(context.Background(), "Jane Doe", "jane@example.com", 20)
+	if err != nil {
+		t.Fatalf("CreateStudent: %v", err)
+	}
+	var count int
+	if err := s.Db.QueryRow("SELECT COUNT(*) FROM pupils WHERE id = ?", id).Scan(&count); err != nil || count != 1 {
+		t.Errorf("student not stored in the pupils table (count %d, err %v)", count, err)
+	}
+
+	for _, table := range []string{"students; DROP TABLE pupils", "1students", "my-table"} {
+		if _, err := New(&config.Config{StoragePath: path, StorageTable: table, AutoMigrate: true}); err == nil {
+			t.Errorf("New accepted table name %q", table)
+		}
+	}
+}
+
+func TestNullAvatarURL(t *testing.T) {
+	s := openTemp(t)
+	ctx := context.Background()
+	id, err := s.CreateStudent(ctx, "Jane Doe", "jane@example.com", 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A fresh student has a NULL avatar_url, which must scan cleanly
+	student, err := s.GetStudentById(ctx, id)
+	if err != nil {
+		t.Fatalf("GetStudentById with a NULL avatar_url: %v", err)
+	}
+	if student.AvatarURL != nil {
+		t.Errorf("AvatarURL = %q, want nil", *student.AvatarURL)
+	}
+	students, err := s.GetStudents(ctx, storage.ListOptions{Limit: 10})
+	if err != nil {
+		t.Fatalf("GetStudents with a NULL avatar_url: %v", err)
+	}
+	if len(students) != 1 || students[0].AvatarURL != nil {
+		t.Errorf("GetStudents = %+v, want one student without an avatar", students)
+	}
+
+	// Once set the value comes back, and clearing it restores NULL
+	if _, err := s.Update(ctx, id, 0, map[string]any{"avatar_url": "/avatars/1.png"}); err != nil {
+		t.Fatal(err)
+	}
+	if student, _ = s.GetStudentById(ctx, id); student.AvatarURL == nil || *student.AvatarURL != "/avatars/1.png" {
+		t.Errorf("AvatarURL after upload = %v, want /avatars/1.png", student.AvatarURL)
+	}
+	if _, err := s.Update(ctx, id, 0, map[string]any{"avatar_url": nil}); err != nil {
+		t.Fatal(err)
+	}
+	if student, _ = s.GetStudentById(ctx, id); student.AvatarURL != nil {
+		t.Errorf("AvatarURL after clearing = %q, want nil", *student.AvatarURL)
+	}
+}
+
+func TestQuota(t *testing.T) {
+	s, err := New(&config.Config{
+		StoragePath: filepath.Join(t.TempDir(), "students.db"),
+		MaxStudents: 3,
+		AutoMigrate: true,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	ctx := context.Background()
+
+	// Creates succeed up to the quota
+	var first int64
+	for i := range 3 {
+		id, err := s.CreateStudent(ctx, "Student", fmt.Sprintf("student%d@example.com", i), 20)
+		if err != nil {
+			t.Fatalf("CreateStudent %d under the quota: %v", i+1, err)
+		}
+		if i == 0 {
+			first = id
+		}
+	}
+
+	_, err = s.CreateStudent(ctx, "One Too Many", "extra@example.com", 20)
+	if !errors.Is(err, storage.ErrQuotaExceeded) {
+		t.Fatalf("CreateStudent over the quota = %v, want storage.ErrQuotaExceeded", err)
+	}
+	if students, _ := s.GetStudents(ctx, storage.ListOptions{}); len(students) != 3 {
+		t.Errorf("%d students stored, want 3", len(students))
+	}
+
+	// Deleting one frees its slot
+	if _, err := s.Delete(ctx, first, 0); err != nil {
+		t.Fatal(err)
+	}
+	extra, err := s.CreateStudent(ctx, "One Too Many", "extra@example.com", 20)
+	if err != nil {
+		t.Fatalf("CreateStudent after a delete: %v", err)
+	}
+
+	// which the deleted student can't take back while it is in use
+	if _, err := s.Restore(ctx, first); !errors.Is(err, storage.ErrQuotaExceeded) {
+		t.Fatalf("Restore over the quota = %v, want storage.ErrQuotaExceeded", err)
+	}
+	if _, err := s.Delete(ctx, extra, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Restore(ctx, first); err != nil {
+		t.Errorf("Restore under the quota: %v", err)
+	}
+}
+
+func TestNewWithoutAutoMigrate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "students.db")
+	open := func() error {
+		s, err := New(&config.Config{StoragePath: path})
+		if err == nil {
+			s.Close()
+		}
+		return err
+	}
+
+	// Nothing is created for a database without the table
+	if err := open(); err == nil || !strings.Contains(err.Error(), "table students does not exist") {
+		t.Fatalf("New on an empty database = %v, want a missing table error", err)
+	}
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var tables int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'students'").Scan(&tables); err != nil || tables != 0 {
+		t.Errorf("students table created with auto_migrate off (count %d, err %v)", tables, err)
+	}
+
+	// A table missing a column is rejected, not altered
+	if _, err := db.Exec(`CREATE TABLE students (id INTEGER PRIMARY KEY AUTOINCREMENT, email TEXT NOT NULL UNIQUE, name TEXT NOT NULL, age INTEGER NOT NULL)`); err != nil {
+		t.Fatal(err)
+	}
+	if err := open(); err == nil || !strings.Contains(err.Error(), "has no column avatar_url") {
+		t.Fatalf("New on an outdated table = %v, want a missing column error", err)
+	}
+
+	// So is one missing the email index
+	for _, col := range []string{"avatar_url TEXT", "created_at DATETIME", "updated_at DATETIME", "version INTEGER NOT NULL DEFAULT 1", "deleted_at DATETIME"} {
+		if _, err := db.Exec(`ALTER TABLE students ADD COLUMN ` + col); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := open(); err == nil || !strings.Contains(err.Error(), "has no index students_email") {
+		t.Fatalf("New on a table without the email index = %v, want a missing index error", err)
+	}
+
+	// A complete schema is accepted as is
+	if _, err := db.Exec(`CREATE UNIQUE INDEX students_email ON students (email) WHERE deleted_at IS NULL`); err != nil {
+		t.Fatal(err)
+	}
+	if err := open(); err != nil {
+		t.Errorf("New on a migrated table: %v", err)
+	}
+}
+
+// racingQueryer deletes the student through the underlying database just
+// before the store prepares its own soft delete, as a concurrent request would.
+type racingQueryer struct {
+	queryer
+	race func()
+}
+
+func (q racingQueryer) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	if strings.HasPrefix(query, "UPDATE") {
+		q.race()
+	}
+	return q.queryer.PrepareContext(ctx, query)
+}
+
+func TestDeleteRacingAnotherDelete(t *testing.T) {
+	s := openTemp(t)
+	id, err := s.CreateStudent(context.Background(), "Jane Doe", "jane@example.com", 20)
+	if err != nil {
+		t.Fatalf("CreateStudent: %v", err)
+	}
+	s.q = racingQueryer{queryer: s.q, race: func() {
+		if _, err := s.Db.Exec("UPDATE students SET deleted_at = ? WHERE id = ?", storage.Now(), id); err != nil {
+			t.Fatalf("racing delete: %v", err)
+		}
+	}}
+
+	if _, err := s.Delete(context.Background(), id, 0); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Delete error = %v, want storage.ErrNotFound", err)
+	}
+}
+
+func TestExists(t *testing.T) {
+	s := openTemp(t)
+	ctx := context.Background()
+	id, err := s.CreateStudent(ctx, "Jane Doe", "jane@example.com", 20)
+	if err != nil {
+		t.Fatalf("CreateStudent: %v", err)
+	}
+
+	for probe, want := range map[int64]bool{id: true, id + 1: false} {
+		if got, err := s.Exists(ctx, probe); err != nil || got != want {
+			t.Errorf("Exists(%d) = %v, %v; want %v", probe, got, err, want)
+		}
+	}
+}
+
+func TestConcurrentCreatesWithSameEmail(t *testing.T) {
+	s := openTemp(t)
+	const n = 8
+
+	errs := make(chan error, n)
+	var wg sync.WaitGroup
+	for range n {
+		wg.Go(func() {
+			_, err := s.CreateStudent(context.Background(), "Jane Doe", "jane@example.com", 20)
+			errs <- err
+		})
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, storage.ErrDuplicateEmail):
+			t.Errorf("CreateStudent error = %v, want storage.ErrDuplicateEmail", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("%d creates succeeded, want exactly 1", succeeded)
+	}
+}
+
+func TestNewCreatesStorageDirectory(t *testing.T) {
+	root := t.TempDir()
+	open := func(path string) (*Sqlite, error) {
+		return New(&config.Config{
+			StoragePath:            path,
+			StorageTable:           "students",
+			StorageConnectAttempts: 1,
+			AutoMigrate:            true,
+		})
+	}
+
+	s, err := open(filepath.Join(root, "data", "nested", "students.db"))
+	if err != nil {
+		t.Fatalf("New with a nonexistent directory: %v", err)
+	}
+	if _, err := s.CreateStudent(context.Background(), "Jane Doe", "jane@example.com", 20); err != nil {
+		t.Errorf("CreateStudent: %v", err)
+	}
+	s.Close()
+
+	// A directory that can't be created is reported as such
+	blocker := filepath.Join(root, "file")
+	if err := os.WriteFile(blocker, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, err = open(filepath.Join(blocker, "data", "students.db"))
+	if err == nil || !strings.Contains(err.Error(), "failed to create sqlite db directory") {
+		t.Errorf("New under a regular file = %v, want a directory creation error", err)
+	}
+}
+
+func TestTimestamps(t *testing.T) {
+	s := openTemp(t)
+	ctx := context.Background()
+
+	before := time.Now().UTC()
+	id, err := s.CreateStudent(ctx, "Jane Doe", "jane@example.com", 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	created, err := s.GetStudentById(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.CreatedAt.Before(before.Truncate(time.Microsecond)) || !created.UpdatedAt.Equal(created.CreatedAt.Time) {
+		t.Errorf("after create: created_at %v, updated_at %v, want both the creation time", created.CreatedAt, created.UpdatedAt)
+	}
+
+	updated, err := s.Update(ctx, id, 0, map[string]any{"age": 21})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !updated.CreatedAt.Equal(created.CreatedAt.Time) || !updated.UpdatedAt.After(created.UpdatedAt.Time) {
+		t.Errorf("after update: created_at %v, updated_at %v, want only updated_at to move", updated.CreatedAt, updated.UpdatedAt)
+	}
+
+	// The creation range is inclusive below and exclusive above
+	at := created.CreatedAt.Time
+	later := at.Add(time.Second)
+	for _, tt := range []struct {
+		filter storage.StudentFilter
+		want   int64
+	}{
+		{storage.StudentFilter{CreatedAfter: &at}, 1},
+		{storage.StudentFilter{CreatedBefore: &at}, 0},
+		{storage.StudentFilter{CreatedAfter: &later}, 0},
+		{storage.StudentFilter{CreatedBefore: &later}, 1},
+	} {
+		if n, err := s.CountStudents(ctx, tt.filter); err != nil || n != tt.want {
+			t.Errorf("CountStudents(%+v) = %d, %v, want %d", tt.filter, n, err, tt.want)
+		}
+	}
+}
+
+func TestIncrementAllAges(t *testing.T) {
+	s := openTemp(t)
+	ctx := context.Background()
+	young, _ := s.CreateStudent(ctx, "Jane Doe", "jane@example.com", 20)
+	old, _ := s.CreateStudent(ctx, "John Doe", "john@example.com", storage.MaxAge-1)
+
+	ages := func() (int, int) {
+		t.Helper()
+		a, errA := s.GetStudentById(ctx, young)
+		b, errB := s.GetStudentById(ctx, old)
+		if err := errors.Join(errA, errB); err != nil {
+			t.Fatal(err)
+		}
+		return a.Age, b.Age
+	}
+
+	n, err := s.IncrementAllAges(ctx, 1)
+	if err != nil {
+		t.Fatalf("IncrementAllAges(1): %v", err)
+	}
+	if n != 2 {
+		t.Errorf("IncrementAllAges(1) updated %d rows, want 2", n)
+	}
+	if a, b := ages(); a != 21 || b != storage.MaxAge {
+		t.Errorf("ages = %d, %d; want 21, %d", a, b, storage.MaxAge)
+	}
+
+	// Pushing anyone out of range changes no one
+	if _, err := s.IncrementAllAges(ctx, 1); !errors.Is(err, storage.ErrAgeOutOfRange) {
+		t.Fatalf("IncrementAllAges past the max = %v, want storage.ErrAgeOutOfRange", err)
+	}
+	if a, b := ages(); a != 21 || b != storage.MaxAge {
+		t.Errorf("ages after a refused increment = %d, %d; want them unchanged", a, b)
+	}
+
+	if _, err := s.IncrementAllAges(ctx, -1); err != nil {
+		t.Fatalf("IncrementAllAges(-1): %v", err)
+	}
+	if a, b := ages(); a != 20 || b != storage.MaxAge-1 {
+		t.Errorf("ages after decrementing = %d, %d; want 20, %d", a, b, storage.MaxAge-1)
+	}
+}
+
+func TestWithTx(t *testing.T) {
+	s := openTemp(t)
+	ctx := context.Background()
+	errStop := errors.New("stop")
+
+	count := func() int {
+		t.Helper()
+		students, err := s.GetStudents(ctx, storage.ListOptions{})
+		if err != nil {
+			t.Fatalf("GetStudents: %v", err)
+		}
+		return len(students)
+	}
+
+	// An error rolls back everything fn did, and is returned as is
+	err := s.WithTx(ctx, func(tx storage.Storage) error {
+		if _, err := tx.CreateStudent(ctx, "Jane Doe", "jane@example.com", 20); err != nil {
+			return err
+		}
+		// Nested calls join the transaction rather than committing on their own
+		return tx.WithTx(ctx, func(tx storage.Storage) error {
+			if _, err := tx.CreateStudent(ctx, "John Doe", "john@example.com", 21); err != nil {
+				return err
+			}
+			return errStop
+		})
+	})
+	if !errors.Is(err, errStop) {
+		t.Fatalf("WithTx = %v, want fn's error", err)
+	}
+	if n := count(); n != 0 {
+		t.Errorf("%d students after a rollback, want 0", n)
+	}
+
+	// So does a panic, which WithTx passes on
+	func() {
+		defer func() {
+			if p := recover(); p != errStop {
+				t.Errorf("recovered %v, want fn's panic", p)
+			}
+		}()
+		s.WithTx(ctx, func(tx storage.Storage) error {
+			tx.CreateStudent(ctx, "Jane Doe", "jane@example.com", 20)
+			panic(errStop)
+		})
+	}()
+	if n := count(); n != 0 {
+		t.Errorf("%d students after a panic, want 0", n)
+	}
+
+	// A nil error commits
+	if err := s.WithTx(ctx, func(tx storage.Storage) error {
+		_, err := tx.CreateStudent(ctx, "Jane Doe", "jane@example.com", 20)
+		return err
+	}); err != nil {
+		t.Fatalf("WithTx: %v", err)
+	}
+	if n := count(); n != 1 {
+		t.Errorf("%d students after a commit, want 1", n)
+	}
+}
+
+func TestSearchStudents(t *testing.T) {
+	s := openTemp(t)
+	ctx := context.Background()
+	for _, st := range []struct{ name, email string }{
+		{"John Doe", "john@example.com"},
+		{"Jane Doe", "jane@school.edu"},
+		{"Bob Stone", "bob@example.com"},
+	} {
+		if _, err := s.CreateStudent(ctx, st.name, st.email, 20); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		q     string
+		limit int
+		want  []int64
+	}{
+		{"doe", 10, []int64{1, 2}},
+		{"DOE", 10, []int64{1, 2}},
+		{"school", 10, []int64{2}},
+		{"example.com", 10, []int64{1, 3}},
+		{"doe", 1, []int64{1}},
+		{"nobody", 10, nil},
+	}
+	for _, tt := range tests {
+		students, err := s.SearchStudents(ctx, tt.q, tt.limit)
+		if err != nil {
+			t.Fatalf("SearchStudents(%q): %v", tt.q, err)
+		}
+		var ids []int64
+		for _, st := range students {
+			ids = append(ids, st.Id)
+		}
+		if !slices.Equal(ids, tt.want) {
+			t.Errorf("SearchStudents(%q, %d) = ids %v, want %v", tt.q, tt.limit, ids, tt.want)
+		}
+	}
+}
+
+func TestNewReplacesEmailConstraint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "students.db")
+	ctx := context.Background()
+
+	// A table with the UNIQUE email constraint of older versions, whose last
+	// student was deleted for good
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`CREATE TABLE students (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		email TEXT NOT NULL UNIQUE,
+		name TEXT NOT NULL,
+		age INTEGER NOT NULL
+	);
+	INSERT INTO students (email, name, age) VALUES ('jane@example.com', 'Jane Doe', 20), ('john@example.com', 'John Doe', 30);
+	DELETE FROM students WHERE id = 2;`); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	s, err := New(&config.Config{StoragePath: path, AutoMigrate: true})
+	if err != nil {
+		t.Fatalf("New on an old database: %v", err)
+	}
+	defer s.Close()
+
+	if student, err := s.GetStudentById(ctx, 1); err != nil || student.Email != "jane@example.com" {
+		t.Fatalf("GetStudentById = %+v, %v; want the existing row kept", student, err)
+	}
+	// Ids of removed students are still not reused
+	if id, err := s.CreateStudent(ctx, "John Doe", "john@example.com", 30); err != nil || id != 3 {
+		t.Errorf("CreateStudent = %d, %v; want id 3", id, err)
+	}
+
+	// Only students that aren't deleted hold on to their email
+	if _, err := s.CreateStudent(ctx, "Jane Again", "jane@example.com", 20); !errors.Is(err, storage.ErrDuplicateEmail) {
+		t.Errorf("CreateStudent with a taken email = %v, want storage.ErrDuplicateEmail", err)
+	}
+	if _, err := s.Delete(ctx, 1, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateStudent(ctx, "Jane Again", "jane@example.com", 20); err != nil {
+		t.Errorf("CreateStudent with a deleted student's email = %v, want it created", err)
+	}
+}
+
+func TestSoftDelete(t *testing.T) {
+	s := openTemp(t)
+	ctx := context.Background()
+	id, _ := s.CreateStudent(ctx, "Jane Doe", "jane@example.com", 20)
+	s.CreateStudent(ctx, "John Doe", "john@example.com", 30)
+
+	if n, err := s.Delete(ctx, id, 0); err != nil || n != 1 {
+		t.Fatalf("Delete = %d, %v; want 1 row", n, err)
+	}
+
+	// The row is kept but every read and write skips it
+	var rows int
+	if err := s.Db.QueryRow("SELECT COUNT(*) FROM students").Scan(&rows); err != nil || rows != 2 {
+		t.Errorf("%d rows in the table (err %v), want the deleted one kept", rows, err)
+	}
+	if _, err := s.GetStudentById(ctx, id); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("GetStudentById = %v, want storage.ErrNotFound", err)
+	}
+	if ok, err := s.Exists(ctx, id); err != nil || ok {
+		t.Errorf("Exists = %v, %v; want false", ok, err)
+	}
+	if students, err := s.GetStudents(ctx, storage.ListOptions{}); err != nil || len(students) != 1 {
+		t.Errorf("GetStudents = %d students, %v; want 1", len(students), err)
+	}
+	if n, err := s.CountStudents(ctx, storage.StudentFilter{}); err != nil || n != 1 {
+		t.Errorf("CountStudents = %d, %v; want 1", n, err)
+	}
+	if found, err := s.SearchStudents(ctx, "jane", 10); err != nil || len(found) != 0 {
+		t.Errorf("SearchStudents = %v, %v; want no match", found, err)
+	}
+	if dist, err := s.AgeDistribution(ctx); err != nil || dist[20] != 0 {
+		t.Errorf("AgeDistribution = %v, %v; want no student aged 20", dist, err)
+	}
+	if _, err := s.Update(ctx, id, 0, map[string]any{"age": 21}); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Update = %v, want storage.ErrNotFound", err)
+	}
+	if n, err := s.IncrementAllAges(ctx, 1); err != nil || n != 1 {
+		t.Errorf("IncrementAllAges = %d, %v; want 1 row", n, err)
+	}
+	if _, err := s.Delete(ctx, id, 0); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Delete again = %v, want storage.ErrNotFound", err)
+	}
+	if n, err := s.DeleteMany(ctx, []int64{id}); err != nil || n != 0 {
+		t.Errorf("DeleteMany of a deleted id = %d, %v; want 0", n, err)
+	}
+
+	// The email is free again, and once taken the deleted student can't
+	// come back with it
+	if _, err := s.CreateStudent(ctx, "Jane Again", "jane@example.com", 20); err != nil {
+		t.Errorf("CreateStudent with a deleted student's email = %v, want it created", err)
+	}
+	if _, err := s.Restore(ctx, id); !errors.Is(err, storage.ErrDuplicateEmail) {
+		t.Errorf("Restore with the email taken = %v, want storage.ErrDuplicateEmail", err)
+	}
+
+	// DeleteAll purges deleted students too
+	if n, err := s.DeleteAll(ctx); err != nil || n != 3 {
+		t.Errorf("DeleteAll = %d, %v; want 3 rows", n, err)
+	}
+	if _, err := s.Restore(ctx, id); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Restore after DeleteAll = %v, want storage.ErrNotFound", err)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	s := openTemp(t)
+	ctx := context.Background()
+	id, _ := s.CreateStudent(ctx, "Jane Doe", "jane@example.com", 20)
+
+	// Only deleted students can be restored
+	for _, target := range []int64{id, 999} {
+		if _, err := s.Restore(ctx, target); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("Restore(%d) = %v, want storage.ErrNotFound", target, err)
+		}
+	}
+
+	if _, err := s.Delete(ctx, id, 0); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := s.Restore(ctx, id)
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	// Created at version 1, then deleted and restored
+	if restored.Id != id || restored.Email != "jane@example.com" || restored.Version != 3 {
+		t.Errorf("Restore = %+v, want jane at version 3", restored)
+	}
+
+	got, err := s.GetStudentById(ctx, id)
+	if err != nil {
+		t.Fatalf("GetStudentById after Restore: %v", err)
+	}
+	if got != restored {
+		t.Errorf("GetStudentById = %+v, want %+v", got, restored)
+	}
+	if _, err := s.Restore(ctx, id); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Restore again = %v, want storage.ErrNotFound", err)
+	}
+}
+
+func TestDeleteAll(t *testing.T) {
+	s := openTemp(t)
+	ctx := context.Background()
+
+	for i := range 3 {
+		if _, err := s.CreateStudent(ctx, "Student", fmt.Sprintf("s%d@example.com", i), 20); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.Delete(ctx, 1, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// Soft-deleted students are removed too
+	n, err := s.DeleteAll(ctx)
+	if err != nil {
+		t.Fatalf("DeleteAll: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("DeleteAll = %d, want 3", n)
+	}
+	if _, err := s.Restore(ctx, 1); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Restore after DeleteAll = %v, want storage.ErrNotFound", err)
+	}
+	if count, err := s.CountStudents(ctx, storage.StudentFilter{}); err != nil || count != 0 {
+		t.Errorf("CountStudents = %d, %v, want 0", count, err)
+	}
+
+	if n, err := s.DeleteAll(ctx); err != nil || n != 0 {
+		t.Errorf("DeleteAll on an empty table = %d, %v, want 0", n, err)
+	}
+}
@@ -1,11 +1,133 @@
 package storage
 
-import "github.com/gourav224/student-api/internal/types"
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
 
+	"github.com/gourav224/student-api/internal/types"
+)
+
+// ErrNotFound is returned when the requested student does not exist.
+var ErrNotFound = errors.New("student not found")
+
+// ErrDuplicateEmail is returned when a write would violate the unique
+// constraint on a student's email.
+var ErrDuplicateEmail = errors.New("a student with this email already exists")
+
+// ErrVersionConflict is returned by Update when the student was changed
+// since the version the caller expected.
+var ErrVersionConflict = errors.New("student has been modified since the given version")
+
+// ErrNoFieldsToUpdate is returned by Update when updates is empty.
+var ErrNoFieldsToUpdate = errors.New("no fields to update")
+
+// ErrQuotaExceeded is returned by CreateStudent when the configured maximum
+// number of students has been reached.
+var ErrQuotaExceeded = errors.New("student quota reached")
+
+// ErrAgeOutOfRange is returned by IncrementAllAges when the change would
+// move some student's age outside MinAge..MaxAge.
+var ErrAgeOutOfRange = errors.New("age would be out of range")
+
+// ErrTimeout is returned when an operation runs longer than the storage
+// timeout; see WithTimeout.
+var ErrTimeout = errors.New("database operation timed out")
+
+// MinAge and MaxAge bound a student's age, as validated on create and update.
+const (
+	MinAge = 1
+	MaxAge = 120
+)
+
+// ListOptions controls which students GetStudents returns.
+type ListOptions struct {
+	// AfterId is a keyset pagination cursor: only students with an id
+	// greater than it are returned. Zero starts from the beginning.
+	AfterId int64
+	// Limit caps the number of students returned. Zero means no limit.
+	Limit int
+	// Offset skips this many students after AfterId, for page-based
+	// pagination. It is only applied together with Limit.
+	Offset int
+	// Fields restricts the selected columns to this subset of StudentColumns.
+	// The id is always selected. Empty selects every column.
+	Fields []string
+	// Filter restricts the result to matching students.
+	Filter StudentFilter
+}
+
+// StudentFilter selects students by age and creation time, e.g. the ones
+// UpdateWhere changes. Nil bounds are not applied and the others are
+// combined with AND. The age bounds are inclusive; CreatedAfter is inclusive
+// and CreatedBefore exclusive, so adjacent ranges don't overlap.
+type StudentFilter struct {
+	MinAge        *int
+	MaxAge        *int
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+}
+
+// Storage is implemented by every persistence backend.
+// All operations honor cancellation and deadlines of the passed context.
 type Storage interface {
-	CreateStudent(name string, email string, age int) (int64, error)
-	GetStudentById(id int64) (types.Student, error)
-	GetStudents() ([]types.Student, error)
-	Update(id int64, updates map[string]any) (types.Student, error)
-	Delete(id int64) (int64, error)
+	CreateStudent(ctx context.Context, name string, email string, age int) (int64, error)
+	GetStudentById(ctx context.Context, id int64) (types.Student, error)
+	// Exists reports whether a student with the given id exists, without
+	// fetching the row.
+	Exists(ctx context.Context, id int64) (bool, error)
+	GetStudents(ctx context.Context, opts ListOptions) ([]types.Student, error)
+	// EachStudent streams the students GetStudents would return to fn, one row
+	// at a time, without holding the whole result set in memory. Iteration
+	// stops at the first error returned by fn, which EachStudent returns.
+	EachStudent(ctx context.Context, opts ListOptions, fn func(types.Student) error) error
+	SearchStudents(ctx context.Context, q string, limit int) ([]types.Student, error)
+	// CountStudents returns the number of students matching filter.
+	CountStudents(ctx context.Context, filter StudentFilter) (int64, error)
+	// AgeDistribution returns the number of students per age.
+	AgeDistribution(ctx context.Context) (map[int]int, error)
+	// Update applies updates to the student with the given id and returns the
+	// updated record. Every update increments the student's version; a
+	// non-zero version makes the update conditional on the student still being
+	// at that version, returning ErrVersionConflict otherwise.
+	Update(ctx context.Context, id int64, version int64, updates map[string]any) (types.Student, error)
+	// UpdateWhere applies updates to every student matching filter in a single
+	// statement and returns how many rows were changed.
+	UpdateWhere(ctx context.Context, filter StudentFilter, updates map[string]any) (int64, error)
+	// IncrementAllAges adds by (which may be negative) to every student's age
+	// and returns how many rows were changed. If any age would leave
+	// MinAge..MaxAge, nothing changes and ErrAgeOutOfRange is returned.
+	IncrementAllAges(ctx context.Context, by int) (int64, error)
+	// Delete soft-deletes the student with the given id and returns how many
+	// rows were deleted. Soft-deleted students keep their row but are
+	// invisible to every other method except Restore, and a new student may
+	// take their email. As with Update, a non-zero version makes the delete
+	// conditional on the student still being at that version, returning
+	// ErrVersionConflict otherwise.
+	Delete(ctx context.Context, id int64, version int64) (int64, error)
+	// DeleteMany soft-deletes the students with the given ids in a single
+	// statement and returns how many rows were deleted; unknown and already
+	// deleted ids are ignored.
+	DeleteMany(ctx context.Context, ids []int64) (int64, error)
+	// DeleteAll permanently removes every student, soft-deleted or not.
+	DeleteAll(ctx context.Context) (int64, error)
+	// Restore undoes the soft delete of the student with the given id and
+	// returns the restored record. It returns ErrNotFound unless the student
+	// exists and is currently deleted, and ErrDuplicateEmail if another
+	// student has taken its email in the meantime.
+	Restore(ctx context.Context, id int64) (types.Student, error)
+
+	// WithTx runs fn inside a single transaction. fn receives a Storage bound to
+	// that transaction; the transaction commits if fn returns nil and rolls back
+	// if it returns an error or panics. Calling WithTx on a transaction-bound
+	// Storage joins the existing transaction.
+	WithTx(ctx context.Context, fn func(txStorage Storage) error) error
+
+	// Ping verifies the backing database is reachable.
+	Ping(ctx context.Context) error
+	// Stats reports the state of the database connection pool.
+	Stats() sql.DBStats
+
+	Close() error
 }
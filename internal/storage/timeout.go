@@ -0,0 +1,160 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/gourav224/student-api/internal/types"
+)
+
+// bounded is a Storage decorator that gives every operation its own deadline.
+// See WithTimeout.
+type bounded struct {
+	next    Storage
+	timeout time.Duration
+}
+
+// WithTimeout wraps next so that every operation is cancelled once it has run
+// for timeout, even if the caller's context (usually the request's) allows
+// longer. An operation cut short by this deadline returns an error wrapping
+// ErrTimeout; one cut short by the caller's own deadline or cancellation
+// returns the error unchanged.
+//
+// EachStudent is not limited, since its duration includes the callback, such
+// as streaming an export to a slow client. WithTx limits the transaction as a
+// whole and hands fn a transaction-bound Storage whose operations are
+// limited individually as well.
+func WithTimeout(next Storage, timeout time.Duration) Storage {
+	return &bounded{next: next, timeout: timeout}
+}
+
+// run calls op with ctx limited to the timeout, reporting the error of an
+// operation that ran out of time as ErrTimeout.
+func run[T any](s *bounded, ctx context.Context, op func(ctx context.Context) (T, error)) (T, error) {
+	ctx, cancel := context.WithTimeoutCause(ctx, s.timeout, ErrTimeout)
+	defer cancel()
+
+	result, err := op(ctx)
+	if err != nil && context.Cause(ctx) == ErrTimeout {
+		err = fmt.Errorf("%w after %s: %w", ErrTimeout, s.timeout, err)
+	}
+	return result, err
+}
+
+// run0 is run for operations that return only an error.
+func run0(s *bounded, ctx context.Context, op func(ctx context.Context) error) error {
+	_, err := run(s, ctx, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, op(ctx)
+	})
+	return err
+}
+
+func (s *bounded) CreateStudent(ctx context.Context, name string, email string, age int) (int64, error) {
+	return run(s, ctx, func(ctx context.Context) (int64, error) {
+		return s.next.CreateStudent(ctx, name, email, age)
+	})
+}
+
+func (s *bounded) GetStudentById(ctx context.Context, id int64) (types.Student, error) {
+	return run(s, ctx, func(ctx context.Context) (types.Student, error) {
+		return s.next.GetStudentById(ctx, id)
+	})
+}
+
+func (s *bounded) Exists(ctx context.Context, id int64) (bool, error) {
+	return run(s, ctx, func(ctx context.Context) (bool, error) {
+		return s.next.Exists(ctx, id)
+	})
+}
+
+func (s *bounded) GetStudents(ctx context.Context, opts ListOptions) ([]types.Student, error) {
+	return run(s, ctx, func(ctx context.Context) ([]types.Student, error) {
+		return s.next.GetStudents(ctx, opts)
+	})
+}
+
+func (s *bounded) EachStudent(ctx context.Context, opts ListOptions, fn func(types.Student) error) error {
+	return s.next.EachStudent(ctx, opts, fn)
+}
+
+func (s *bounded) SearchStudents(ctx context.Context, q string, limit int) ([]types.Student, error) {
+	return run(s, ctx, func(ctx context.Context) ([]types.Student, error) {
+		return s.next.SearchStudents(ctx, q, limit)
+	})
+}
+
+func (s *bounded) CountStudents(ctx context.Context, filter StudentFilter) (int64, error) {
+	return run(s, ctx, func(ctx context.Context) (int64, error) {
+		return s.next.CountStudents(ctx, filter)
+	})
+}
+
+func (s *bounded) AgeDistribution(ctx context.Context) (map[int]int, error) {
+	return run(s, ctx, func(ctx context.Context) (map[int]int, error) {
+		return s.next.AgeDistribution(ctx)
+	})
+}
+
+func (s *bounded) Update(ctx context.Context, id int64, version int64, updates map[string]any) (types.Student, error) {
+	return run(s, ctx, func(ctx context.Context) (types.Student, error) {
+		return s.next.Update(ctx, id, version, updates)
+	})
+}
+
+func (s *bounded) UpdateWhere(ctx context.Context, filter StudentFilter, updates map[string]any) (int64, error) {
+	return run(s, ctx, func(ctx context.Context) (int64, error) {
+		return s.next.UpdateWhere(ctx, filter, updates)
+	})
+}
+
+func (s *bounded) IncrementAllAges(ctx context.Context, by int) (int64, error) {
+	return run(s, ctx, func(ctx context.Context) (int64, error) {
+		return s.next.IncrementAllAges(ctx, by)
+	})
+}
+
+func (s *bounded) Delete(ctx context.Context, id int64, version int64) (int64, error) {
+	return run(s, ctx, func(ctx context.Context) (int64, error) {
+		return s.next.Delete(ctx, id, version)
+	})
+}
+
+func (s *bounded) DeleteMany(ctx context.Context, ids []int64) (int64, error) {
+	return run(s, ctx, func(ctx context.Context) (int64, error) {
+		return s.next.DeleteMany(ctx, ids)
+	})
+}
+
+func (s *bounded) DeleteAll(ctx context.Context) (int64, error) {
+	return run(s, ctx, func(ctx context.Context) (int64, error) {
+		return s.next.DeleteAll(ctx)
+	})
+}
+
+func (s *bounded) Restore(ctx context.Context, id int64) (types.Student, error) {
+	return run(s, ctx, func(ctx context.Context) (types.Student, error) {
+		return s.next.Restore(ctx, id)
+	})
+}
+
+func (s *bounded) WithTx(ctx context.Context, fn func(txStorage Storage) error) error {
+	return run0(s, ctx, func(ctx context.Context) error {
+		return s.next.WithTx(ctx, func(txStorage Storage) error {
+			return fn(&bounded{next: txStorage, timeout: s.timeout})
+		})
+	})
+}
+
+func (s *bounded) Ping(ctx context.Context) error {
+	return run0(s, ctx, s.next.Ping)
+}
+
+func (s *bounded) Stats() sql.DBStats {
+	return s.next.Stats()
+}
+
+func (s *bounded) Close() error {
+	return s.next.Close()
+}
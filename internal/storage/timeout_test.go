@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gourav224/student-api/internal/types"
+)
+
+// blockingStore is a Storage whose GetStudentById blocks until its context
+// ends, like a query stuck behind a lock.
+type blockingStore struct {
+	Storage
+}
+
+func (blockingStore) GetStudentById(ctx context.Context, id int64) (types.Student, error) {
+	<-ctx.Done()
+	return types.Student{}, ctx.Err()
+}
+
+func TestWithTimeout(t *testing.T) {
+	store := WithTimeout(blockingStore{}, 20*time.Millisecond)
+
+	start := time.Now()
+	_, err := store.GetStudentById(context.Background(), 1)
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("GetStudentById = %v, want ErrTimeout", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetStudentById = %v, want it to keep the underlying error", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("GetStudentById returned after %s, want about the 20ms timeout", elapsed)
+	}
+}
+
+func TestWithTimeoutLeavesCallerErrors(t *testing.T) {
+	store := WithTimeout(blockingStore{}, time.Minute)
+
+	// A request deadline shorter than the storage timeout isn't a storage timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := store.GetStudentById(ctx, 1)
+	if !errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrTimeout) {
+		t.Errorf("GetStudentById past the caller's deadline = %v, want context.DeadlineExceeded only", err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	_, err = store.GetStudentById(ctx, 1)
+	if !errors.Is(err, context.Canceled) || errors.Is(err, ErrTimeout) {
+		t.Errorf("GetStudentById after cancellation = %v, want context.Canceled only", err)
+	}
+}
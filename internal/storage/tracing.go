@@ -0,0 +1,190 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/gourav224/student-api/internal/types"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName identifies the spans started by WithTracing.
+const tracerName = "github.com/gourav224/student-api/internal/storage"
+
+// traced is a Storage decorator that wraps every operation in a span.
+// See WithTracing.
+type traced struct {
+	next   Storage
+	tracer trace.Tracer
+}
+
+// WithTracing wraps next so that every operation runs in an OpenTelemetry
+// span named "storage.<Operation>", created with tp as a child of the span in
+// the passed context (usually the request span). Failed operations record
+// their error on the span. As in WithSlowQueryLog, only ids, limits and
+// column names are recorded, never personal data.
+func WithTracing(next Storage, tp trace.TracerProvider) Storage {
+	return &traced{next: next, tracer: tp.Tracer(tracerName)}
+}
+
+// start begins the span of op.
+func (s *traced) start(ctx context.Context, op string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs, attribute.String("db.operation.name", op))
+	return s.tracer.Start(ctx, "storage."+op, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// end finishes span, recording err if the operation failed.
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// listSpanAttrs summarizes opts for a span.
+func listSpanAttrs(opts ListOptions) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.Int64("list.after_id", opts.AfterId),
+		attribute.Int("list.limit", opts.Limit),
+		attribute.Int("list.offset", opts.Offset),
+	}
+}
+
+// updateSpanAttr lists the updated columns, but not their values.
+func updateSpanAttr(updates map[string]any) attribute.KeyValue {
+	return attribute.StringSlice("update.columns", updatedColumns(updates))
+}
+
+func (s *traced) CreateStudent(ctx context.Context, name string, email string, age int) (int64, error) {
+	ctx, span := s.start(ctx, "CreateStudent")
+	id, err := s.next.CreateStudent(ctx, name, email, age)
+	end(span, err)
+	return id, err
+}
+
+func (s *traced) GetStudentById(ctx context.Context, id int64) (types.Student, error) {
+	ctx, span := s.start(ctx, "GetStudentById", attribute.Int64("student.id", id))
+	student, err := s.next.GetStudentById(ctx, id)
+	end(span, err)
+	return student, err
+}
+
+func (s *traced) Exists(ctx context.Context, id int64) (bool, error) {
+	ctx, span := s.start(ctx, "Exists", attribute.Int64("student.id", id))
+	exists, err := s.next.Exists(ctx, id)
+	end(span, err)
+	return exists, err
+}
+
+func (s *traced) GetStudents(ctx context.Context, opts ListOptions) ([]types.Student, error) {
+	ctx, span := s.start(ctx, "GetStudents", listSpanAttrs(opts)...)
+	students, err := s.next.GetStudents(ctx, opts)
+	end(span, err)
+	return students, err
+}
+
+func (s *traced) EachStudent(ctx context.Context, opts ListOptions, fn func(types.Student) error) error {
+	ctx, span := s.start(ctx, "EachStudent", listSpanAttrs(opts)...)
+	err := s.next.EachStudent(ctx, opts, fn)
+	end(span, err)
+	return err
+}
+
+func (s *traced) SearchStudents(ctx context.Context, q string, limit int) ([]types.Student, error) {
+	ctx, span := s.start(ctx, "SearchStudents", attribute.Int("search.query_length", len(q)), attribute.Int("list.limit", limit))
+	students, err := s.next.SearchStudents(ctx, q, limit)
+	end(span, err)
+	return students, err
+}
+
+func (s *traced) CountStudents(ctx context.Context, filter StudentFilter) (int64, error) {
+	ctx, span := s.start(ctx, "CountStudents")
+	count, err := s.next.CountStudents(ctx, filter)
+	end(span, err)
+	return count, err
+}
+
+func (s *traced) AgeDistribution(ctx context.Context) (map[int]int, error) {
+	ctx, span := s.start(ctx, "AgeDistribution")
+	distribution, err := s.next.AgeDistribution(ctx)
+	end(span, err)
+	return distribution, err
+}
+
+func (s *traced) Update(ctx context.Context, id int64, version int64, updates map[string]any) (types.Student, error) {
+	ctx, span := s.start(ctx, "Update", attribute.Int64("student.id", id), attribute.Int64("student.version", version), updateSpanAttr(updates))
+	student, err := s.next.Update(ctx, id, version, updates)
+	end(span, err)
+	return student, err
+}
+
+func (s *traced) UpdateWhere(ctx context.Context, filter StudentFilter, updates map[string]any) (int64, error) {
+	ctx, span := s.start(ctx, "UpdateWhere", updateSpanAttr(updates))
+	n, err := s.next.UpdateWhere(ctx, filter, updates)
+	end(span, err)
+	return n, err
+}
+
+func (s *traced) IncrementAllAges(ctx context.Context, by int) (int64, error) {
+	ctx, span := s.start(ctx, "IncrementAllAges", attribute.Int("increment.by", by))
+	n, err := s.next.IncrementAllAges(ctx, by)
+	end(span, err)
+	return n, err
+}
+
+func (s *traced) Delete(ctx context.Context, id int64, version int64) (int64, error) {
+	ctx, span := s.start(ctx, "Delete", attribute.Int64("student.id", id), attribute.Int64("student.version", version))
+	n, err := s.next.Delete(ctx, id, version)
+	end(span, err)
+	return n, err
+}
+
+func (s *traced) DeleteMany(ctx context.Context, ids []int64) (int64, error) {
+	ctx, span := s.start(ctx, "DeleteMany", attribute.Int("delete.ids", len(ids)))
+	n, err := s.next.DeleteMany(ctx, ids)
+	end(span, err)
+	return n, err
+}
+
+func (s *traced) DeleteAll(ctx context.Context) (int64, error) {
+	ctx, span := s.start(ctx, "DeleteAll")
+	n, err := s.next.DeleteAll(ctx)
+	end(span, err)
+	return n, err
+}
+
+func (s *traced) Restore(ctx context.Context, id int64) (types.Student, error) {
+	ctx, span := s.start(ctx, "Restore", attribute.Int64("student.id", id))
+	student, err := s.next.Restore(ctx, id)
+	end(span, err)
+	return student, err
+}
+
+// WithTx wraps the whole transaction in a span and hands fn a traced
+// transaction-bound Storage, so the operations inside are traced too.
+func (s *traced) WithTx(ctx context.Context, fn func(txStorage Storage) error) error {
+	ctx, span := s.start(ctx, "WithTx")
+	err := s.next.WithTx(ctx, func(txStorage Storage) error {
+		return fn(&traced{next: txStorage, tracer: s.tracer})
+	})
+	end(span, err)
+	return err
+}
+
+func (s *traced) Ping(ctx context.Context) error {
+	ctx, span := s.start(ctx, "Ping")
+	err := s.next.Ping(ctx)
+	end(span, err)
+	return err
+}
+
+func (s *traced) Stats() sql.DBStats {
+	return s.next.Stats()
+}
+
+func (s *traced) Close() error {
+	return s.next.Close()
+}
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gourav224/student-api/internal/types"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// failingStore is a Storage whose GetStudentById fails with ErrNotFound.
+type failingStore struct {
+	Storage
+}
+
+func (failingStore) GetStudentById(ctx context.Context, id int64) (types.Student, error) {
+	return types.Student{}, ErrNotFound
+}
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "request")
+
+	WithTracing(&delayStore{}, tp).Update(ctx, 7, 0, map[string]any{"name": "Jane Doe", "email": "jane@example.com"})
+	_, err := WithTracing(failingStore{}, tp).GetStudentById(ctx, 8)
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("GetStudentById error = %v, want the store's error unchanged", err)
+	}
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("got %d spans, want 3", len(spans))
+	}
+	update, get := spans[0], spans[1]
+
+	if update.Name() != "storage.Update" || update.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("span %q with parent %v, want storage.Update under the request span", update.Name(), update.Parent().SpanID())
+	}
+	attrs := map[string]string{}
+	for _, kv := range update.Attributes() {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+	if attrs["student.id"] != "7" || attrs["update.columns"] != `["email","name"]` {
+		t.Errorf("Update attributes = %v, want the id and the column names", attrs)
+	}
+	for _, v := range attrs {
+		if v == "Jane Doe" || v == "jane@example.com" {
+			t.Errorf("Update attributes contain personal data: %v", attrs)
+		}
+	}
+
+	if get.Name() != "storage.GetStudentById" || get.Status().Code != codes.Error || len(get.Events()) == 0 {
+		t.Errorf("failed span %q has status %v and %d events, want an error status and the recorded error", get.Name(), get.Status(), len(get.Events()))
+	}
+}
@@ -0,0 +1,58 @@
+// Package tracing sets up optional OpenTelemetry tracing.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gourav224/student-api/internal/version"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// ServiceName identifies this service in exported traces.
+const ServiceName = "student-api"
+
+// Setup returns the tracer provider for the server and a function that flushes
+// and stops it on shutdown.
+//
+// With an empty endpoint tracing is disabled: the provider is a no-op and
+// Enabled reports false for it. Otherwise spans are batched and exported over
+// OTLP/HTTP to endpoint, a URL such as "http://otel-collector:4318", and the
+// provider is also installed globally together with the W3C trace context
+// and baggage propagators, so incoming trace context is continued.
+func Setup(ctx context.Context, endpoint string) (trace.TracerProvider, func(context.Context) error, error) {
+	if endpoint == "" {
+		return noop.NewTracerProvider(), func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(endpoint))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	res := resource.NewSchemaless(
+		attribute.String("service.name", ServiceName),
+		attribute.String("service.version", version.Version),
+	)
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return tp, tp.Shutdown, nil
+}
+
+// Enabled reports whether tp records spans, i.e. isn't the no-op provider
+// Setup returns when tracing is disabled. Callers use it to skip installing
+// instrumentation altogether.
+func Enabled(tp trace.TracerProvider) bool {
+	_, isNoop := tp.(noop.TracerProvider)
+	return !isNoop
+}
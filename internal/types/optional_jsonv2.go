@@ -0,0 +1,30 @@
+//go:build go1.27 && goexperiment.jsonv2
+
+package types
+
+import (
+	"encoding/json"
+	"encoding/json/jsontext"
+	"errors"
+	"strings"
+)
+
+// UnmarshalJSONFrom implements json.UnmarshalerFrom, which encoding/json
+// prefers over UnmarshalJSON when it is built on the v2 implementation.
+// That implementation doesn't fill in UnmarshalTypeError.Field for errors
+// raised by UnmarshalJSON, so it is set here from the decoder's position,
+// the way encoding/json names the field for any other type error.
+func (o *Optional[T]) UnmarshalJSONFrom(dec *jsontext.Decoder) error {
+	ptr := dec.StackPointer()
+	data, err := dec.ReadValue()
+	if err != nil {
+		return err
+	}
+
+	err = o.UnmarshalJSON(data)
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field == "" {
+		typeErr.Field = strings.ReplaceAll(strings.TrimPrefix(string(ptr), "/"), "/", ".")
+	}
+	return err
+}
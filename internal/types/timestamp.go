@@ -0,0 +1,75 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// TimeFormats lists the accepted JSON formats of Timestamp: RFC 3339 strings
+// ("rfc3339") or whole Unix seconds ("unix").
+var TimeFormats = []string{"rfc3339", "unix"}
+
+// unixTimestamps is set by SetTimeFormat("unix"). It is read on every
+// marshal, so it is atomic even though it is normally set once at startup.
+var unixTimestamps atomic.Bool
+
+// SetTimeFormat selects how every Timestamp is written to JSON, one of
+// TimeFormats. The default is "rfc3339".
+func SetTimeFormat(format string) error {
+	switch strings.ToLower(format) {
+	case "rfc3339":
+		unixTimestamps.Store(false)
+	case "unix":
+		unixTimestamps.Store(true)
+	default:
+		return fmt.Errorf("invalid time format %q (supported: %s)", format, strings.Join(TimeFormats, ", "))
+	}
+	return nil
+}
+
+// Timestamp is a point in time whose JSON form follows SetTimeFormat, e.g.
+// "2024-01-15T09:30:00.123456Z" or 1705311000.
+type Timestamp struct {
+	time.Time
+}
+
+// MarshalJSON implements json.Marshaler.
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	if unixTimestamps.Load() {
+		return strconv.AppendInt(nil, t.Unix(), 10), nil
+	}
+	return t.Time.MarshalJSON()
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Both formats are accepted,
+// whichever one is configured.
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	if sec, err := strconv.ParseInt(string(data), 10, 64); err == nil {
+		t.Time = time.Unix(sec, 0).UTC()
+		return nil
+	}
+	return t.Time.UnmarshalJSON(data)
+}
+
+// Scan implements sql.Scanner, so timestamp columns scan straight into a
+// Timestamp. Drivers must return them as time.Time.
+func (t *Timestamp) Scan(src any) error {
+	v, ok := src.(time.Time)
+	if !ok {
+		return fmt.Errorf("cannot scan %T into Timestamp", src)
+	}
+	t.Time = v.UTC()
+	return nil
+}
+
+// ValidationValue exposes the underlying time.Time to go-playground/validator,
+// which only applies tags such as "isdefault" to times, not other structs.
+func (t Timestamp) ValidationValue() any {
+	return t.Time
+}
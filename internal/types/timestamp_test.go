@@ -0,0 +1,70 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestTimestampFormats(t *testing.T) {
+	t.Cleanup(func() { SetTimeFormat("rfc3339") })
+	student := Student{
+		Id:        1,
+		Name:      "Jane Doe",
+		Email:     "jane@example.com",
+		Age:       20,
+		CreatedAt: Timestamp{time.Date(2024, 1, 15, 9, 30, 0, 123456000, time.UTC)},
+		UpdatedAt: Timestamp{time.Date(2024, 1, 16, 10, 0, 0, 0, time.UTC)},
+	}
+
+	tests := []struct {
+		format    string
+		createdAt any
+		updatedAt any
+	}{
+		{"rfc3339", "2024-01-15T09:30:00.123456Z", "2024-01-16T10:00:00Z"},
+		{"unix", 1705311000.0, 1705399200.0},
+		{"UNIX", 1705311000.0, 1705399200.0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			if err := SetTimeFormat(tt.format); err != nil {
+				t.Fatal(err)
+			}
+			b, err := json.Marshal(student)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got map[string]any
+			if err := json.Unmarshal(b, &got); err != nil {
+				t.Fatal(err)
+			}
+			if got["created_at"] != tt.createdAt || got["updated_at"] != tt.updatedAt {
+				t.Errorf("created_at, updated_at = %v, %v; want %v, %v",
+					got["created_at"], got["updated_at"], tt.createdAt, tt.updatedAt)
+			}
+		})
+	}
+}
+
+func TestSetTimeFormatRejectsUnknown(t *testing.T) {
+	t.Cleanup(func() { SetTimeFormat("rfc3339") })
+	if err := SetTimeFormat("iso8601"); err == nil {
+		t.Error("SetTimeFormat accepted an unknown format")
+	}
+}
+
+func TestTimestampUnmarshal(t *testing.T) {
+	want := time.Date(2024, 1, 15, 9, 30, 0, 0, time.UTC)
+	// Both formats are read whichever one is configured
+	for _, input := range []string{`"2024-01-15T09:30:00Z"`, `1705311000`} {
+		var ts Timestamp
+		if err := json.Unmarshal([]byte(input), &ts); err != nil {
+			t.Fatalf("Unmarshal(%s): %v", input, err)
+		}
+		if !ts.Equal(want) {
+			t.Errorf("Unmarshal(%s) = %v, want %v", input, ts.Time, want)
+		}
+	}
+}
@@ -1,8 +1,91 @@
 package types
 
+import (
+	"encoding/json"
+)
+
+// Student is both the body of a create request and the representation
+// returned by every endpoint. JSON keys are lowercase snake_case; optional
+// fields use omitempty (omitzero for times) so they are left out rather than
+// sent as null or a zero value.
 type Student struct {
 	Id    int64  `json:"id"`
-	Name  string `json:"name" validate:"required"`
-	Email string `json:"email" validate:"required,email"`
+	Name  string `json:"name" validate:"required,name_chars"`
+	Email string `json:"email" validate:"required,email,email_domain"`
 	Age   int    `json:"age" validate:"required,gte=1,lte=120"`
+	// AvatarURL is set by uploading an avatar, never from a request body.
+	AvatarURL *string `json:"avatar_url,omitempty" validate:"isdefault"`
+	// CreatedAt and UpdatedAt are set by the server, in UTC.
+	CreatedAt Timestamp `json:"created_at,omitzero" validate:"isdefault"`
+	UpdatedAt Timestamp `json:"updated_at,omitzero" validate:"isdefault"`
+	// Version starts at 1 and is incremented by every update, for
+	// optimistic concurrency control.
+	Version int64 `json:"version,omitempty" validate:"isdefault"`
+}
+
+// StudentUpdate is the body of a partial update (PATCH).
+// Each field records whether its key was sent and whether it was null,
+// so an absent key (leave unchanged) is told apart from an explicit null
+// (clear the field) and from a zero value.
+type StudentUpdate struct {
+	Name  Optional[string] `json:"name" validate:"omitnil,min=1,name_chars"`
+	Email Optional[string] `json:"email" validate:"omitnil,email,email_domain"`
+	Age   Optional[int]    `json:"age" validate:"omitnil,gte=1,lte=120"`
+	// Version is not a field to update but the version the client last saw;
+	// when sent, the update only applies if the student is still at it.
+	Version Optional[int] `json:"version" validate:"omitnil,gte=1"`
+}
+
+// Fields returns the fields that were sent, keyed by column name.
+// A field sent as null maps to a nil value. Version is not included.
+func (u StudentUpdate) Fields() map[string]any {
+	fields := map[string]any{}
+	u.Name.addTo(fields, "name")
+	u.Email.addTo(fields, "email")
+	u.Age.addTo(fields, "age")
+	return fields
+}
+
+// Optional is a JSON field that distinguishes three states:
+// absent (Set is false), null (Set and Null are true), and a value.
+type Optional[T any] struct {
+	Set   bool
+	Null  bool
+	Value T
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It is only called when the key
+// is present, which is how Set gets recorded. Values are decoded like plain
+// fields of type T, so an int field rejects 20.0 just as Student.Age does.
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if string(data) == "null" {
+		o.Null = true
+		return nil
+	}
+	return json.Unmarshal(data, &o.Value)
+}
+
+// Ptr returns a pointer to the value, or nil when the field is absent or null.
+func (o Optional[T]) Ptr() *T {
+	if !o.Set || o.Null {
+		return nil
+	}
+	return &o.Value
+}
+
+// ValidationValue exposes the field to go-playground/validator as a pointer,
+// so "omitnil" skips absent and null fields.
+func (o Optional[T]) ValidationValue() any {
+	return o.Ptr()
+}
+
+func (o Optional[T]) addTo(fields map[string]any, key string) {
+	switch {
+	case !o.Set:
+	case o.Null:
+		fields[key] = nil
+	default:
+		fields[key] = o.Value
+	}
 }
@@ -0,0 +1,136 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestStudentUpdateFields(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want map[string]any
+	}{
+		{"absent keys are left out", `{}`, map[string]any{}},
+		{"values are set", `{"name":"Jane","age":21}`, map[string]any{"name": "Jane", "age": 21}},
+		{"null clears", `{"email":null}`, map[string]any{"email": nil}},
+		{"zero values are values", `{"name":"","age":0}`, map[string]any{"name": "", "age": 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var u StudentUpdate
+			if err := json.Unmarshal([]byte(tt.body), &u); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if got := u.Fields(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Fields() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOptionalStates(t *testing.T) {
+	tests := []struct {
+		body string
+		want Optional[string]
+		ptr  bool
+	}{
+		{`{}`, Optional[string]{}, false},
+		{`{"name":null}`, Optional[string]{Set: true, Null: true}, false},
+		{`{"name":""}`, Optional[string]{Set: true}, true},
+		{`{"name":"Jane"}`, Optional[string]{Set: true, Value: "Jane"}, true},
+	}
+
+	for _, tt := range tests {
+		var u StudentUpdate
+		if err := json.Unmarshal([]byte(tt.body), &u); err != nil {
+			t.Fatalf("Unmarshal(%s): %v", tt.body, err)
+		}
+		if u.Name != tt.want {
+			t.Errorf("Unmarshal(%s) name = %+v, want %+v", tt.body, u.Name, tt.want)
+		}
+		if got := u.Name.Ptr() != nil; got != tt.ptr {
+			t.Errorf("Unmarshal(%s) name.Ptr() != nil is %v, want %v", tt.body, got, tt.ptr)
+		}
+	}
+}
+
+func TestOptionalIntNumbers(t *testing.T) {
+	tests := []struct {
+		json    string
+		want    int
+		wantErr bool
+	}{
+		{`20`, 20, false},
+		{`-3`, -3, false},
+		{`9223372036854775807`, math.MaxInt, false},
+		{`-9223372036854775808`, math.MinInt, false},
+		{`20.0`, 0, true},
+		{`2e1`, 0, true},
+		{`9223372036854775808.0`, 0, true},
+		{`9223372036854775808`, 0, true},
+		{`99999999999999999999`, 0, true},
+		{`-99999999999999999999`, 0, true},
+		{`1e30`, 0, true},
+		{`20.5`, 0, true},
+		{`0.1`, 0, true},
+		{`"20"`, 0, true},
+		{`true`, 0, true},
+	}
+
+	for _, tt := range tests {
+		var u StudentUpdate
+		err := json.Unmarshal([]byte(`{"age":`+tt.json+`}`), &u)
+		if tt.wantErr {
+			var typeErr *json.UnmarshalTypeError
+			if !errors.As(err, &typeErr) || typeErr.Field != "age" {
+				t.Errorf("age %s: error = %#v, want a *json.UnmarshalTypeError for field age", tt.json, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("age %s: %v", tt.json, err)
+			continue
+		}
+		if !u.Age.Set || u.Age.Value != tt.want {
+			t.Errorf("age %s decoded to %+v, want %d", tt.json, u.Age, tt.want)
+		}
+	}
+}
+
+func TestStudentJSONKeys(t *testing.T) {
+	avatar := "/api/avatars/1.png"
+
+	tests := []struct {
+		name    string
+		student Student
+		want    string
+	}{
+		{
+			name:    "complete",
+			student: Student{Id: 1, Name: "Jane Doe", Email: "jane@example.com", Age: 20, AvatarURL: &avatar},
+			want:    `{"id":1,"name":"Jane Doe","email":"jane@example.com","age":20,"avatar_url":"/api/avatars/1.png"}`,
+		},
+		{
+			name:    "optional fields omitted",
+			student: Student{Id: 1, Name: "Jane Doe", Email: "jane@example.com", Age: 20},
+			want:    `{"id":1,"name":"Jane Doe","email":"jane@example.com","age":20}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.student)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Marshal =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package request
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gourav224/student-api/internal/types"
+)
+
+// MaxBodySize caps how many bytes of a JSON request body are read.
+const MaxBodySize = 1 << 20 // 1 MiB
+
+// ErrEmptyBody is returned when the request has no body.
+var ErrEmptyBody = errors.New("empty request body")
+
+// ErrBodyTooLarge is returned when the body exceeds MaxBodySize.
+var ErrBodyTooLarge = fmt.Errorf("request body exceeds %d bytes", MaxBodySize)
+
+// DecodeError reports a body that is not valid JSON for the target type:
+// a syntax error, a value of the wrong type, an unknown field, or trailing data.
+type DecodeError struct {
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(e.Err, &typeErr) {
+		// An error without a key may be about the top-level value itself,
+		// e.g. an array sent where an object is expected
+		if typeErr.Field == "" {
+			switch typeErr.Type.Kind() {
+			case reflect.Struct, reflect.Map:
+				return "request body must be a JSON object, got " + typeErr.Value
+			case reflect.Slice, reflect.Array:
+				return "request body must be a JSON array, got " + typeErr.Value
+			}
+			return fmt.Sprintf("a field must be of type %s, got %s", typeErr.Type, typeErr.Value)
+		}
+		return fmt.Sprintf("field %q must be of type %s", typeErr.Field, typeErr.Type)
+	}
+	return "invalid JSON: " + e.Err.Error()
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// validate is shared by every request; a validator caches struct metadata
+// and is safe for concurrent use.
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(jsonFieldName)
+	v.RegisterCustomTypeFunc(validationValue, types.Optional[string]{}, types.Optional[int]{}, types.Timestamp{})
+	if err := v.RegisterValidation("email_domain", emailDomainAllowed); err != nil {
+		panic(err)
+	}
+	if err := v.RegisterValidation("name_chars", nameCharsAllowed); err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// allowedEmailDomains holds the lowercased domains set by
+// SetAllowedEmailDomains. It is read on every validation, so it is atomic
+// even though it is normally set once at startup.
+var allowedEmailDomains atomic.Pointer[[]string]
+
+// SetAllowedEmailDomains restricts the fields tagged "email_domain" to
+// addresses at one of domains, compared case-insensitively. Subdomains must
+// be listed on their own: "school.edu" doesn't allow "mail.school.edu".
+// An empty list, the default, allows every domain.
+func SetAllowedEmailDomains(domains []string) {
+	lowered := make([]string, len(domains))
+	for i, domain := range domains {
+		lowered[i] = strings.ToLower(domain)
+	}
+	allowedEmailDomains.Store(&lowered)
+}
+
+// emailDomainAllowed implements the "email_domain" validation; see
+// SetAllowedEmailDomains.
+func emailDomainAllowed(fl validator.FieldLevel) bool {
+	domains := allowedEmailDomains.Load()
+	if domains == nil || len(*domains) == 0 {
+		return true
+	}
+	email := fl.Field().String()
+	at := strings.LastIndexByte(email, '@')
+	return at >= 0 && slices.Contains(*domains, strings.ToLower(email[at+1:]))
+}
+
+// DefaultNamePunctuation is the punctuation allowed in names until
+// SetNamePunctuation is called, enough for names like "Jean-Luc O'Brien".
+const DefaultNamePunctuation = "-'"
+
+// namePunctuation holds the characters set by SetNamePunctuation, atomic for
+// the same reason as allowedEmailDomains.
+var namePunctuation atomic.Pointer[string]
+
+// SetNamePunctuation sets the characters that fields tagged "name_chars" may
+// contain besides letters (including accents and other combining marks) and
+// spaces. Digits, control characters and any other symbols are rejected.
+func SetNamePunctuation(chars string) {
+	namePunctuation.Store(&chars)
+}
+
+// nameCharsAllowed implements the "name_chars" validation; see
+// SetNamePunctuation.
+func nameCharsAllowed(fl validator.FieldLevel) bool {
+	punctuation := DefaultNamePunctuation
+	if chars := namePunctuation.Load(); chars != nil {
+		punctuation = *chars
+	}
+	for _, r := range fl.Field().String() {
+		if !unicode.IsLetter(r) && !unicode.IsMark(r) && r != ' ' && !strings.ContainsRune(punctuation, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Decode reads a single JSON value from the request body into dst.
+// Unknown fields are rejected and the body is limited to MaxBodySize.
+// It returns ErrEmptyBody, ErrBodyTooLarge or a *DecodeError.
+func Decode(r *http.Request, dst any) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(nil, r.Body, MaxBodySize))
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(dst)
+	if err == nil && decoder.More() {
+		err = errors.New("body must contain a single JSON value")
+	}
+
+	var maxErr *http.MaxBytesError
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, io.EOF):
+		return ErrEmptyBody
+	case errors.As(err, &maxErr):
+		return ErrBodyTooLarge
+	default:
+		return &DecodeError{Err: err}
+	}
+}
+
+// Validate checks v against its `validate` struct tags, reporting fields by
+// their JSON names. Failures are returned as validator.ValidationErrors.
+func Validate(v any) error {
+	return validate.Struct(v)
+}
+
+// DecodeAndValidate decodes the request body into dst like Decode and then
+// validates it like Validate, returning the first error of either step.
+func DecodeAndValidate(r *http.Request, dst any) error {
+	if err := Decode(r, dst); err != nil {
+		return err
+	}
+	return Validate(dst)
+}
+
+// validationValue unwraps a types.Optional or types.Timestamp for the
+// validator; see their ValidationValue methods.
+func validationValue(v reflect.Value) any {
+	return v.Interface().(interface{ ValidationValue() any }).ValidationValue()
+}
+
+// jsonFieldName reports a struct field by its JSON name in validation errors,
+// so clients see "email" rather than "Email".
+func jsonFieldName(fld reflect.StructField) string {
+	name, _, _ := strings.Cut(fld.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return fld.Name
+	}
+	return name
+}
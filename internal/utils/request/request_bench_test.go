@@ -0,0 +1,31 @@
+package request
+
+import (
+	"testing"
+
+	"github.com/gourav224/student-api/internal/types"
+)
+
+var benchStudent = types.Student{Name: "Jane Doe", Email: "jane@example.com", Age: 20}
+
+// BenchmarkValidate validates with the shared validator, whose struct
+// metadata is cached after the first call.
+func BenchmarkValidate(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		if err := Validate(benchStudent); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkValidateFreshValidator builds a validator per call, as every
+// request did before the validator was shared, for comparison.
+func BenchmarkValidateFreshValidator(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		if err := newValidator().Struct(benchStudent); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package request
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gourav224/student-api/internal/types"
+)
+
+// newRequest returns a POST request with body.
+func newRequest(body string) *http.Request {
+	return httptest.NewRequest(http.MethodPost, "/students", strings.NewReader(body))
+}
+
+func TestDecodeRejectsNonObjects(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		got  string
+	}{
+		{"array", `[1,2,3]`, "array"},
+		{"string", `"hello"`, "string"},
+		{"number", `42`, "number"},
+		{"boolean", `true`, "bool"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var student types.Student
+			err := Decode(newRequest(tt.body), &student)
+
+			var decodeErr *DecodeError
+			if !errors.As(err, &decodeErr) {
+				t.Fatalf("Decode(%s) = %v, want a *DecodeError", tt.body, err)
+			}
+			if want := "request body must be a JSON object, got " + tt.got; err.Error() != want {
+				t.Errorf("error = %q, want %q", err, want)
+			}
+		})
+	}
+}
+
+func TestDecodeRejectsNonArrays(t *testing.T) {
+	var students []types.Student
+	err := Decode(newRequest(`{"name":"Jane Doe"}`), &students)
+
+	if want := "request body must be a JSON array, got object"; err == nil || err.Error() != want {
+		t.Errorf("error = %v, want %q", err, want)
+	}
+}
+
+func TestEmailDomain(t *testing.T) {
+	t.Cleanup(func() { SetAllowedEmailDomains(nil) })
+	tests := []struct {
+		name    string
+		domains []string
+		email   string
+		allowed bool
+	}{
+		{"no list", nil, "jane@anywhere.com", true},
+		{"listed", []string{"school.edu", "college.edu"}, "jane@college.edu", true},
+		{"case-insensitive", []string{"School.EDU"}, "jane@SCHOOL.edu", true},
+		{"not listed", []string{"school.edu"}, "jane@gmail.com", false},
+		{"subdomain", []string{"school.edu"}, "jane@mail.school.edu", false},
+		{"suffix only", []string{"school.edu"}, "jane@notschool.edu", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetAllowedEmailDomains(tt.domains)
+
+			student := types.Student{Name: "Jane Doe", Email: tt.email, Age: 20}
+			update := types.StudentUpdate{Email: types.Optional[string]{Set: true, Value: tt.email}}
+			for _, v := range []any{student, update} {
+				err := Validate(v)
+				if tt.allowed && err != nil {
+					t.Errorf("Validate(%T) = %v, want the domain allowed", v, err)
+				}
+				var errs validator.ValidationErrors
+				if !tt.allowed && (!errors.As(err, &errs) || errs[0].Field() != "email" || errs[0].Tag() != "email_domain") {
+					t.Errorf("Validate(%T) = %v, want an email_domain failure on email", v, err)
+				}
+			}
+		})
+	}
+}
+
+func TestNameChars(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed bool
+	}{
+		{"Jane Doe", true},
+		{"Jean-Luc O'Brien", true},
+		{"José Müller", true},
+		{"Zoë", true},
+		{"Nguyễn Văn An", true},
+		{"李小龍", true},
+		{"Jane2", false},
+		{"Jane\tDoe", false},
+		{"Jane\x00", false},
+		{"Jane_Doe", false},
+		{"Jane.Doe", false},
+		{"<script>", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(types.Student{Name: tt.name, Email: "jane@example.com", Age: 20})
+			if tt.allowed && err != nil {
+				t.Errorf("Validate = %v, want the name allowed", err)
+			}
+			var errs validator.ValidationErrors
+			if !tt.allowed && (!errors.As(err, &errs) || errs[0].Field() != "name" || errs[0].Tag() != "name_chars") {
+				t.Errorf("Validate = %v, want a name_chars failure on name", err)
+			}
+		})
+	}
+}
+
+func TestSetNamePunctuation(t *testing.T) {
+	t.Cleanup(func() { SetNamePunctuation(DefaultNamePunctuation) })
+	SetNamePunctuation(".")
+
+	for name, allowed := range map[string]bool{"J. R. Smith": true, "O'Brien": false, "Anne-Marie": false} {
+		err := Validate(types.Student{Name: name, Email: "jane@example.com", Age: 20})
+		if (err == nil) != allowed {
+			t.Errorf("Validate(%q) = %v, want allowed %v", name, err, allowed)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package response
+
+import "net/http"
+
+// Machine-readable error codes sent in the "code" field of error responses.
+// Clients may branch on them, so existing codes must never change meaning.
+//
+// Errors without a more specific code get the generic code of their HTTP
+// status (see statusCodes).
+const (
+	CodeBadRequest           = "BAD_REQUEST"
+	CodeValidationFailed     = "VALIDATION_FAILED"
+	CodeInvalidJSON          = "INVALID_JSON"
+	CodeEmptyBody            = "EMPTY_BODY"
+	CodeNoFieldsToUpdate     = "NO_FIELDS_TO_UPDATE"
+	CodeUnauthorized         = "UNAUTHORIZED"
+	CodeForbidden            = "FORBIDDEN"
+	CodeQuotaExceeded        = "QUOTA_EXCEEDED"
+	CodeNotFound             = "NOT_FOUND"
+	CodeMethodNotAllowed     = "METHOD_NOT_ALLOWED"
+	CodeNotAcceptable        = "NOT_ACCEPTABLE"
+	CodeConflict             = "CONFLICT"
+	CodeDuplicateEmail       = "DUPLICATE_EMAIL"
+	CodeVersionConflict      = "VERSION_CONFLICT"
+	CodePreconditionFailed   = "PRECONDITION_FAILED"
+	CodeAgeOutOfRange        = "AGE_OUT_OF_RANGE"
+	CodeIdempotencyKeyInUse  = "IDEMPOTENCY_KEY_IN_USE"
+	CodeIdempotencyKeyReused = "IDEMPOTENCY_KEY_REUSED"
+	CodePayloadTooLarge      = "PAYLOAD_TOO_LARGE"
+	CodeUnsupportedMediaType = "UNSUPPORTED_MEDIA_TYPE"
+	CodeUnprocessable        = "UNPROCESSABLE_ENTITY"
+	CodeTooManyRequests      = "TOO_MANY_REQUESTS"
+	CodeInternal             = "INTERNAL_ERROR"
+	CodeUnavailable          = "SERVICE_UNAVAILABLE"
+	CodeReadOnly             = "READ_ONLY"
+	CodeTimeout              = "TIMEOUT"
+	CodeServerBusy           = "SERVER_BUSY"
+)
+
+// statusCodes maps HTTP statuses to the code used for errors that don't set one.
+var statusCodes = map[int]string{
+	http.StatusBadRequest:            CodeBadRequest,
+	http.StatusUnauthorized:          CodeUnauthorized,
+	http.StatusForbidden:             CodeForbidden,
+	http.StatusNotFound:              CodeNotFound,
+	http.StatusMethodNotAllowed:      CodeMethodNotAllowed,
+	http.StatusNotAcceptable:         CodeNotAcceptable,
+	http.StatusConflict:              CodeConflict,
+	http.StatusPreconditionFailed:    CodePreconditionFailed,
+	http.StatusRequestEntityTooLarge: CodePayloadTooLarge,
+	http.StatusUnsupportedMediaType:  CodeUnsupportedMediaType,
+	http.StatusUnprocessableEntity:   CodeUnprocessable,
+	http.StatusTooManyRequests:       CodeTooManyRequests,
+	http.StatusInternalServerError:   CodeInternal,
+	http.StatusServiceUnavailable:    CodeUnavailable,
+}
+
+// codeForStatus returns the generic code of an HTTP error status: one from
+// statusCodes, or BAD_REQUEST / INTERNAL_ERROR for other 4xx / 5xx statuses.
+func codeForStatus(status int) string {
+	if code, ok := statusCodes[status]; ok {
+		return code
+	}
+	if status >= http.StatusInternalServerError {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
@@ -0,0 +1,162 @@
+package response
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// JSONAPIMediaType is the media type of JSON:API documents
+// (https://jsonapi.org).
+const JSONAPIMediaType = "application/vnd.api+json"
+
+// jsonAPIResource is a JSON:API resource object.
+type jsonAPIResource struct {
+	Type       string         `json:"type"`
+	Id         string         `json:"id"`
+	Attributes map[string]any `json:"attributes"`
+}
+
+// jsonAPIError is a JSON:API error object.
+type jsonAPIError struct {
+	Status string         `json:"status"`
+	Code   string         `json:"code,omitempty"`
+	Title  string         `json:"title"`
+	Detail string         `json:"detail,omitempty"`
+	Source map[string]any `json:"source,omitempty"`
+	Meta   map[string]any `json:"meta,omitempty"`
+}
+
+// jsonAPIType returns the resource type set by the writer w or any writer it
+// wraps (see middleware.JSONAPI), or "" when JSON:API wasn't requested.
+func jsonAPIType(w http.ResponseWriter) string {
+	for {
+		if p, ok := w.(interface{ JSONAPIType() string }); ok {
+			return p.JSONAPIType()
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return ""
+		}
+		w = u.Unwrap()
+	}
+}
+
+// toJSONAPI converts data, as passed to WriteJson, into a JSON:API document.
+//
+// An error Response becomes an "errors" array, with one error object per
+// failed field when it has any. In a success envelope, objects carrying an
+// "id" (and arrays made only of such objects) become resources of
+// resourceType in "data", with their other fields as attributes. Any other
+// payload, such as a count, goes to meta.result, joined by the message and
+// the envelope's other keys, with a nested "meta" block flattened into them.
+// Data that is neither is returned unchanged.
+func toJSONAPI(resourceType string, status int, data any) (any, error) {
+	if resp, ok := data.(Response); ok {
+		return map[string]any{"errors": jsonAPIErrors(status, resp)}, nil
+	}
+	if envelope, ok := data.(map[string]any); !ok || envelope["status"] != "success" {
+		return data, nil
+	}
+
+	// Round-trip the envelope so students, page metadata and projected field
+	// maps all come out as plain maps, keyed and formatted as in plain JSON
+	var generic map[string]any
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+
+	doc := map[string]any{}
+	meta := map[string]any{}
+	for key, value := range generic {
+		switch key {
+		case "status":
+		case "data":
+			if resources, ok := jsonAPIResources(resourceType, value); ok {
+				doc["data"] = resources
+			} else {
+				meta["result"] = value
+			}
+		case "meta":
+			if nested, ok := value.(map[string]any); ok {
+				for k, v := range nested {
+					meta[k] = v
+				}
+			} else {
+				meta[key] = value
+			}
+		default:
+			meta[key] = value
+		}
+	}
+	if len(meta) > 0 {
+		doc["meta"] = meta
+	}
+	return doc, nil
+}
+
+// jsonAPIResources converts a decoded object, or array of objects, that
+// carries ids into resource objects. It reports false for anything else.
+func jsonAPIResources(resourceType string, value any) (any, bool) {
+	switch v := value.(type) {
+	case map[string]any:
+		return jsonAPIResourceOf(resourceType, v)
+	case []any:
+		resources := make([]jsonAPIResource, 0, len(v))
+		for _, item := range v {
+			object, ok := item.(map[string]any)
+			if !ok {
+				return nil, false
+			}
+			resource, ok := jsonAPIResourceOf(resourceType, object)
+			if !ok {
+				return nil, false
+			}
+			resources = append(resources, resource)
+		}
+		return resources, true
+	default:
+		return nil, false
+	}
+}
+
+// jsonAPIResourceOf converts a decoded object with an "id" into a resource.
+func jsonAPIResourceOf(resourceType string, object map[string]any) (jsonAPIResource, bool) {
+	id, ok := object["id"].(json.Number)
+	if !ok {
+		return jsonAPIResource{}, false
+	}
+	attributes := make(map[string]any, len(object)-1)
+	for key, value := range object {
+		if key != "id" {
+			attributes[key] = value
+		}
+	}
+	return jsonAPIResource{Type: resourceType, Id: id.String(), Attributes: attributes}, true
+}
+
+// jsonAPIErrors converts an error response into JSON:API error objects.
+// Field errors point at the failed field of the (plain JSON) request body.
+func jsonAPIErrors(status int, resp Response) []jsonAPIError {
+	base := jsonAPIError{Status: strconv.Itoa(status), Code: resp.Code, Title: http.StatusText(status)}
+	if len(resp.Fields) == 0 {
+		base.Detail = resp.Error
+		return []jsonAPIError{base}
+	}
+
+	errs := make([]jsonAPIError, len(resp.Fields))
+	for i, field := range resp.Fields {
+		errs[i] = base
+		errs[i].Detail = field.Message
+		errs[i].Source = map[string]any{"pointer": "/" + field.Field}
+		errs[i].Meta = map[string]any{"tag": field.Tag}
+	}
+	return errs
+}
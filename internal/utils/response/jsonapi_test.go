@@ -0,0 +1,131 @@
+package response
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// jsonAPIRecorder is a recorder that asks for JSON:API documents of
+// "students", as middleware.JSONAPI does.
+type jsonAPIRecorder struct {
+	*httptest.ResponseRecorder
+}
+
+func (jsonAPIRecorder) JSONAPIType() string { return "students" }
+
+// writeJSONAPI writes data with WriteJson as JSON:API and decodes the document.
+func writeJSONAPI(t *testing.T, status int, data any) map[string]any {
+	t.Helper()
+	rec := jsonAPIRecorder{httptest.NewRecorder()}
+	if err := WriteJson(rec, status, data); err != nil {
+		t.Fatal(err)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != JSONAPIMediaType {
+		t.Errorf("Content-Type = %q, want %s", ct, JSONAPIMediaType)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON response %q: %v", rec.Body, err)
+	}
+	return doc
+}
+
+func TestJSONAPIResource(t *testing.T) {
+	doc := writeJSONAPI(t, http.StatusOK, map[string]any{
+		"status":  "success",
+		"message": "student fetched successfully",
+		"data":    map[string]any{"id": 7, "name": "Jane Doe", "age": 20},
+	})
+
+	want := map[string]any{
+		"data": map[string]any{
+			"type":       "students",
+			"id":         "7",
+			"attributes": map[string]any{"name": "Jane Doe", "age": 20.0},
+		},
+		"meta": map[string]any{"message": "student fetched successfully"},
+	}
+	if !reflect.DeepEqual(doc, want) {
+		t.Errorf("document = %v, want %v", doc, want)
+	}
+}
+
+func TestJSONAPICollection(t *testing.T) {
+	doc := writeJSONAPI(t, http.StatusOK, map[string]any{
+		"status":  "success",
+		"message": "students fetched successfully",
+		"limit":   2,
+		"data":    []map[string]any{{"id": 1, "name": "Jane Doe"}, {"id": 2, "name": "John Doe"}},
+		"meta":    map[string]any{"page": 1, "total": 5},
+	})
+
+	want := map[string]any{
+		"data": []any{
+			map[string]any{"type": "students", "id": "1", "attributes": map[string]any{"name": "Jane Doe"}},
+			map[string]any{"type": "students", "id": "2", "attributes": map[string]any{"name": "John Doe"}},
+		},
+		"meta": map[string]any{"message": "students fetched successfully", "limit": 2.0, "page": 1.0, "total": 5.0},
+	}
+	if !reflect.DeepEqual(doc, want) {
+		t.Errorf("document = %v, want %v", doc, want)
+	}
+
+	// An empty list is still a collection
+	doc = writeJSONAPI(t, http.StatusOK, map[string]any{"status": "success", "data": []map[string]any{}})
+	if data, ok := doc["data"].([]any); !ok || len(data) != 0 {
+		t.Errorf("data of an empty list = %v, want []", doc["data"])
+	}
+}
+
+func TestJSONAPIResult(t *testing.T) {
+	doc := writeJSONAPI(t, http.StatusOK, map[string]any{
+		"status":  "success",
+		"message": "student deleted successfully",
+		"data":    1,
+	})
+
+	want := map[string]any{"meta": map[string]any{"message": "student deleted successfully", "result": 1.0}}
+	if !reflect.DeepEqual(doc, want) {
+		t.Errorf("document = %v, want %v", doc, want)
+	}
+}
+
+func TestJSONAPIErrors(t *testing.T) {
+	doc := writeJSONAPI(t, http.StatusNotFound, GeneralError(errors.New("student with id 7 not found")))
+	want := map[string]any{"errors": []any{map[string]any{
+		"status": "404",
+		"code":   "NOT_FOUND",
+		"title":  "Not Found",
+		"detail": "student with id 7 not found",
+	}}}
+	if !reflect.DeepEqual(doc, want) {
+		t.Errorf("document = %v, want %v", doc, want)
+	}
+
+	type input struct {
+		Name string `validate:"required"`
+		Age  int    `validate:"gte=1"`
+	}
+	doc = writeJSONAPI(t, http.StatusBadRequest, ValidationError(validationErrors(t, input{})))
+	want = map[string]any{"errors": []any{
+		map[string]any{
+			"status": "400", "code": "VALIDATION_FAILED", "title": "Bad Request",
+			"detail": "field 'Name' is required",
+			"source": map[string]any{"pointer": "/Name"},
+			"meta":   map[string]any{"tag": "required"},
+		},
+		map[string]any{
+			"status": "400", "code": "VALIDATION_FAILED", "title": "Bad Request",
+			"detail": "field 'Age' must be at least 1",
+			"source": map[string]any{"pointer": "/Age"},
+			"meta":   map[string]any{"tag": "gte"},
+		},
+	}}
+	if !reflect.DeepEqual(doc, want) {
+		t.Errorf("document = %v, want %v", doc, want)
+	}
+}
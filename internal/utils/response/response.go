@@ -9,17 +9,152 @@ import (
 	"github.com/go-playground/validator/v10"
 )
 
+// Response is the body of an error response. Code is one of the Code*
+// constants; WriteJson fills in the generic code of the status if it is empty.
 type Response struct {
-	Status string `json:"status"`
-	Error  string `json:"error,omitempty"`
+	Status string       `json:"status"`
+	Code   string       `json:"code,omitempty"`
+	Error  string       `json:"error,omitempty"`
+	Fields []FieldError `json:"fields,omitempty"`
 }
 
+// FieldError describes a single failed validation rule in a machine-readable form.
+type FieldError struct {
+	Field   string `json:"field"`
+	Tag     string `json:"tag"`
+	Message string `json:"message"`
+}
+
+// WriteJson writes data as a JSON response with the given status code.
+// The output is indented when a wrapping writer asks for it (see
+// middleware.PrettyJSON), and compact otherwise. When a wrapping writer asks
+// for raw responses (see middleware.RawResponse), a success envelope is
+// replaced by its "data"; errors keep their structured form. When one asks
+// for JSON:API (see middleware.JSONAPI), which takes precedence over raw
+// responses, data is converted by toJSONAPI. Otherwise the Content-Type is
+// the media type of the negotiated API version when the client named one
+// (see middleware.Versioning), and application/json when it didn't.
+// A Response without a Code gets the generic code of status.
 func WriteJson(w http.ResponseWriter, status int, data any) error {
-	w.Header().Set("Content-Type", "application/json")
+	resourceType := jsonAPIType(w)
+	if resourceType == "" && wants(w, rawMarker) {
+		data = unwrapEnvelope(data)
+	}
+	if resp, ok := data.(Response); ok && resp.Code == "" {
+		resp.Code = codeForStatus(status)
+		data = resp
+	}
+
+	contentType := "application/json"
+	if mediaType := apiMediaType(w); mediaType != "" {
+		contentType = mediaType
+	}
+	if resourceType != "" {
+		doc, err := toJSONAPI(resourceType, status, data)
+		if err != nil {
+			return err
+		}
+		data = doc
+		contentType = JSONAPIMediaType
+	}
+
+	w.Header().Set("Content-Type", contentType)
 	w.WriteHeader(status)
-	return json.NewEncoder(w).Encode(data)
+
+	encoder := json.NewEncoder(w)
+	if wants(w, prettyMarker) {
+		encoder.SetIndent("", "  ")
+	}
+	return encoder.Encode(data)
+}
+
+// Variant names the representation WriteJson produces through w, so that
+// strong ETags can tell representations apart. It is "" for the default
+// compact JSON envelope, and otherwise joins with "-" whichever of "jsonapi",
+// the API version (e.g. "v1"), "raw" and "pretty" apply. Content codings
+// such as gzip are not included; whoever applies them marks the ETag.
+func Variant(w http.ResponseWriter) string {
+	var parts []string
+	if jsonAPIType(w) != "" {
+		parts = append(parts, "jsonapi")
+	} else {
+		if mediaType := apiMediaType(w); mediaType != "" {
+			parts = append(parts, mediaTypeVersion(mediaType))
+		}
+		if wants(w, rawMarker) {
+			parts = append(parts, "raw")
+		}
+	}
+	if wants(w, prettyMarker) {
+		parts = append(parts, "pretty")
+	}
+	return strings.Join(parts, "-")
+}
+
+// mediaTypeVersion returns the version part of a versioned media type, e.g.
+// "v1" for "application/vnd.studentapi.v1+json".
+func mediaTypeVersion(mediaType string) string {
+	mediaType, _, _ = strings.Cut(mediaType, "+")
+	return mediaType[strings.LastIndex(mediaType, ".")+1:]
+}
+
+// prettyMarker and rawMarker read the output options a writer may carry.
+var (
+	prettyMarker = func(w http.ResponseWriter) bool {
+		p, ok := w.(interface{ PrettyJSON() bool })
+		return ok && p.PrettyJSON()
+	}
+	rawMarker = func(w http.ResponseWriter) bool {
+		p, ok := w.(interface{ RawJSON() bool })
+		return ok && p.RawJSON()
+	}
+)
+
+// wants reports whether w, or any writer it wraps, sets the option read by marker.
+func wants(w http.ResponseWriter, marker func(http.ResponseWriter) bool) bool {
+	for {
+		if marker(w) {
+			return true
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return false
+		}
+		w = u.Unwrap()
+	}
+}
+
+// apiMediaType returns the API version media type set by the writer w or any
+// writer it wraps (see middleware.Versioning), or "" when none was requested.
+func apiMediaType(w http.ResponseWriter) string {
+	for {
+		if p, ok := w.(interface{ APIMediaType() string }); ok {
+			return p.APIMediaType()
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return ""
+		}
+		w = u.Unwrap()
+	}
+}
+
+// unwrapEnvelope returns the "data" of a {"status": "success", ...} envelope,
+// and anything else unchanged.
+func unwrapEnvelope(data any) any {
+	envelope, ok := data.(map[string]any)
+	if !ok || envelope["status"] != "success" {
+		return data
+	}
+	if inner, ok := envelope["data"]; ok {
+		return inner
+	}
+	return data
 }
 
+// GeneralError returns an error response carrying err's message. Its code
+// defaults to the generic one of the response status; use WithCode to be
+// more specific.
 func GeneralError(err error) Response {
 	return Response{
 		Status: "error",
@@ -27,22 +162,53 @@ func GeneralError(err error) Response {
 	}
 }
 
+// WithCode returns r with its machine-readable code set to code.
+func (r Response) WithCode(code string) Response {
+	r.Code = code
+	return r
+}
+
+// ValidationError converts validator errors into a response that carries both
+// a human-readable summary in Error and one FieldError per failed rule in Fields.
 func ValidationError(errs validator.ValidationErrors) Response {
 	var errMsgs []string
+	var fields []FieldError
 
 	for _, err := range errs {
+		var msg string
 		switch err.Tag() {
 		case "required":
-			errMsgs = append(errMsgs, fmt.Sprintf("field '%s' is required", err.Field()))
+			msg = fmt.Sprintf("field '%s' is required", err.Field())
 		case "email":
-			errMsgs = append(errMsgs, fmt.Sprintf("field '%s' must be a valid email", err.Field()))
+			msg = fmt.Sprintf("field '%s' must be a valid email", err.Field())
+		case "email_domain":
+			msg = fmt.Sprintf("field '%s' must be an email address at an allowed domain", err.Field())
+		case "name_chars":
+			msg = fmt.Sprintf("field '%s' may only contain letters, spaces and allowed punctuation", err.Field())
+		case "isdefault":
+			msg = fmt.Sprintf("field '%s' cannot be set", err.Field())
+		case "min":
+			msg = fmt.Sprintf("field '%s' must be at least %s characters long", err.Field(), err.Param())
+		case "gte":
+			msg = fmt.Sprintf("field '%s' must be at least %s", err.Field(), err.Param())
+		case "lte":
+			msg = fmt.Sprintf("field '%s' must be at most %s", err.Field(), err.Param())
 		default:
-			errMsgs = append(errMsgs, fmt.Sprintf("field '%s' is invalid", err.Field()))
+			msg = fmt.Sprintf("field '%s' is invalid", err.Field())
 		}
+
+		errMsgs = append(errMsgs, msg)
+		fields = append(fields, FieldError{
+			Field:   err.Field(),
+			Tag:     err.Tag(),
+			Message: msg,
+		})
 	}
 
 	return Response{
 		Status: "error",
+		Code:   CodeValidationFailed,
 		Error:  strings.Join(errMsgs, ", "),
+		Fields: fields,
 	}
 }
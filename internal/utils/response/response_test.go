@@ -0,0 +1,149 @@
+package response
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// validationErrors validates v, whose fields are reported by their Go names,
+// and returns the failures.
+func validationErrors(t *testing.T, v any) validator.ValidationErrors {
+	t.Helper()
+	var errs validator.ValidationErrors
+	if !errors.As(validator.New().Struct(v), &errs) {
+		t.Fatalf("%+v passed validation", v)
+	}
+	return errs
+}
+
+func TestValidationError(t *testing.T) {
+	type input struct {
+		Name  string `validate:"required"`
+		Email string `validate:"email"`
+		Age   int    `validate:"gte=1,lte=120"`
+	}
+
+	resp := ValidationError(validationErrors(t, input{Email: "nope", Age: 200}))
+
+	want := []FieldError{
+		{Field: "Name", Tag: "required", Message: "field 'Name' is required"},
+		{Field: "Email", Tag: "email", Message: "field 'Email' must be a valid email"},
+		{Field: "Age", Tag: "lte", Message: "field 'Age' must be at most 120"},
+	}
+	if !reflect.DeepEqual(resp.Fields, want) {
+		t.Errorf("Fields = %+v, want %+v", resp.Fields, want)
+	}
+	if wantSummary := "field 'Name' is required, field 'Email' must be a valid email, field 'Age' must be at most 120"; resp.Error != wantSummary {
+		t.Errorf("Error = %q, want %q", resp.Error, wantSummary)
+	}
+	if resp.Status != "error" {
+		t.Errorf("Status = %q, want error", resp.Status)
+	}
+}
+
+func TestValidationErrorJSON(t *testing.T) {
+	type input struct {
+		Age int `validate:"gte=1"`
+	}
+
+	rec := httptest.NewRecorder()
+	WriteJson(rec, http.StatusBadRequest, ValidationError(validationErrors(t, input{})))
+
+	var body map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	want := []any{map[string]any{"field": "Age", "tag": "gte", "message": "field 'Age' must be at least 1"}}
+	if !reflect.DeepEqual(body["fields"], want) {
+		t.Errorf("fields = %v, want %v", body["fields"], want)
+	}
+	if body["error"] != "field 'Age' must be at least 1" {
+		t.Errorf("error = %v, want the summary", body["error"])
+	}
+}
+
+func TestGeneralErrorCodes(t *testing.T) {
+	tests := []struct {
+		status int
+		resp   Response
+		code   string
+	}{
+		{http.StatusBadRequest, GeneralError(errors.New("bad")), CodeBadRequest},
+		{http.StatusNotFound, GeneralError(errors.New("missing")), CodeNotFound},
+		{http.StatusTooManyRequests, GeneralError(errors.New("slow down")), CodeTooManyRequests},
+		{http.StatusGone, GeneralError(errors.New("gone")), CodeBadRequest},
+		{http.StatusInternalServerError, GeneralError(errors.New("boom")), CodeInternal},
+		{http.StatusBadGateway, GeneralError(errors.New("upstream")), CodeInternal},
+		{http.StatusConflict, GeneralError(errors.New("taken")).WithCode(CodeDuplicateEmail), CodeDuplicateEmail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			WriteJson(rec, tt.status, tt.resp)
+
+			var body map[string]any
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body["code"] != tt.code {
+				t.Errorf("status %d: code = %v, want %s", tt.status, body["code"], tt.code)
+			}
+		})
+	}
+}
+
+func TestValidationErrorNameChars(t *testing.T) {
+	v := validator.New()
+	v.RegisterValidation("name_chars", func(fl validator.FieldLevel) bool { return false })
+	var errs validator.ValidationErrors
+	if !errors.As(v.Struct(struct {
+		Name string `validate:"name_chars"`
+	}{"Jane2"}), &errs) {
+		t.Fatal("name passed validation")
+	}
+
+	resp := ValidationError(errs)
+	if want := "field 'Name' may only contain letters, spaces and allowed punctuation"; resp.Error != want {
+		t.Errorf("Error = %q, want %q", resp.Error, want)
+	}
+}
+
+// optionsWriter carries the output options the middleware would set.
+type optionsWriter struct {
+	http.ResponseWriter
+	pretty, raw bool
+	mediaType   string
+}
+
+func (w optionsWriter) PrettyJSON() bool     { return w.pretty }
+func (w optionsWriter) RawJSON() bool        { return w.raw }
+func (w optionsWriter) APIMediaType() string { return w.mediaType }
+
+func (w optionsWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+func TestVariant(t *testing.T) {
+	tests := []struct {
+		name string
+		w    http.ResponseWriter
+		want string
+	}{
+		{"default", httptest.NewRecorder(), ""},
+		{"raw", optionsWriter{ResponseWriter: httptest.NewRecorder(), raw: true}, "raw"},
+		{"pretty", optionsWriter{ResponseWriter: httptest.NewRecorder(), pretty: true}, "pretty"},
+		{"versioned raw", optionsWriter{ResponseWriter: httptest.NewRecorder(), raw: true, mediaType: "application/vnd.studentapi.v1+json"}, "v1-raw"},
+		{"jsonapi ignores raw", optionsWriter{ResponseWriter: jsonAPIRecorder{httptest.NewRecorder()}, raw: true, pretty: true}, "jsonapi-pretty"},
+	}
+
+	for _, tt := range tests {
+		if got := Variant(tt.w); got != tt.want {
+			t.Errorf("%s: Variant = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+// Package version holds build information injected at link time, e.g.
+//
+//	go build -ldflags "-X github.com/gourav224/student-api/internal/version.Version=v1.2.0 \
+//	  -X github.com/gourav224/student-api/internal/version.Commit=$(git rev-parse --short HEAD) \
+//	  -X github.com/gourav224/student-api/internal/version.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)" \
+//	  ./cmd/student-api
+package version
+
+import (
+	"net/http"
+
+	"github.com/gourav224/student-api/internal/utils/response"
+)
+
+// Build information, overridden via -ldflags "-X ...".
+// The defaults identify a local, non-release build.
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildTime = "unknown"
+)
+
+// Info is the build information served by Handler.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+}
+
+// Get returns the build information of the running binary.
+func Get() Info {
+	return Info{Version: Version, Commit: Commit, BuildTime: BuildTime}
+}
+
+// Handler returns an HTTP handler serving the build information as JSON,
+// so a deployment can be checked against the expected build.
+func Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		response.WriteJson(w, http.StatusOK, map[string]any{
+			"status":  "success",
+			"message": "version fetched successfully",
+			"data":    Get(),
+		})
+	}
+}
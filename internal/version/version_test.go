@@ -0,0 +1,49 @@
+package version
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// setBuild overrides the build information for the rest of t, as -ldflags
+// would.
+func setBuild(t *testing.T, version, commit, buildTime string) {
+	t.Helper()
+	oldVersion, oldCommit, oldBuildTime := Version, Commit, BuildTime
+	Version, Commit, BuildTime = version, commit, buildTime
+	t.Cleanup(func() { Version, Commit, BuildTime = oldVersion, oldCommit, oldBuildTime })
+}
+
+func TestGetDefaults(t *testing.T) {
+	if got, want := Get(), (Info{Version: "dev", Commit: "unknown", BuildTime: "unknown"}); got != want {
+		t.Errorf("Get() = %+v, want the local build defaults %+v", got, want)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	setBuild(t, "v1.2.0", "abc1234", "2026-01-15T09:30:00Z")
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/version", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+
+	var body struct {
+		Status string `json:"status"`
+		Data   Info   `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON response %q: %v", rec.Body, err)
+	}
+	want := Info{Version: "v1.2.0", Commit: "abc1234", BuildTime: "2026-01-15T09:30:00Z"}
+	if body.Status != "success" || body.Data != want {
+		t.Errorf("body = %+v, want success with %+v", body, want)
+	}
+}